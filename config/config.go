@@ -13,6 +13,7 @@ var ContentTypes = map[string]string{
 	"json":      "application/json",
 	"form":      "application/x-www-form-urlencoded",
 	"multipart": "multipart/form-data",
+	"xml":       "application/xml",
 }
 
 // Duration is used to automatically unmarshal timeout strings to
@@ -27,23 +28,109 @@ func (d *Duration) UnmarshalText(text []byte) error {
 	return err
 }
 
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
 type Config struct {
-	General GeneralOptions
-	Keys    map[string]map[string]string
+	General        GeneralOptions
+	Keys           map[string]map[string]string
+	Mock           []MockResponse
+	Listen         ListenOptions
+	DefaultHeaders map[string]string `toml:"default_headers"`
+	// headers of the requests sent to a host, indexed by host name
+	Host map[string]map[string]string
+	// accepted "sha256/BASE64" public key hashes, indexed by host name
+	Pins map[string][]string
+	// HMAC signing profiles of the hmac auth type, indexed by name
+	Signing map[string]SigningProfile
+	CSRF    CSRFOptions `toml:"csrf"`
+	// frame colors of the response views, indexed by status class, e.g. "4xx"
+	StatusColors map[string]string `toml:"status_colors"`
 }
 
 type GeneralOptions struct {
+	AutoContentType        bool // set the Content-Type of JSON and XML bodies without asking
+	AutoSave               bool
+	AutoSaveDirectory      string
+	BodyOnAnyMethod        bool // send the request body with every method without asking
+	Cache                  bool
+	CertExpiryWarning      Duration // warn about certificates expiring within this duration
 	ContextSpecificSearch  bool
 	DefaultURLScheme       string
+	DisableKeepAlives      bool
 	Editor                 string
+	ExpectContinueTimeout  Duration
+	FakeSeed               uint64 // seed of the dynamic variables, 0 for random values on every run
 	FollowRedirects        bool
 	FormatJSON             bool
+	FreshConnect           bool
+	GzipRequestBody        bool // compress request bodies and set Content-Encoding: gzip
+	HistoryDeduplication   bool
+	HostCheck              bool   // check the host of the URL is reachable when leaving the URL view
+	HTMLFormat             string // raw, indent or text
 	Insecure               bool
+	IPVersion              int    // 4 or 6 to only connect over IPv4 or IPv6
+	KeyHints               int    // number of keybindings of the focused view shown by {{.KeyHints}}
+	LocalAddr              string // IP address or interface name connections are made from
+	LogFile                string // file the JSON log of requests, responses and errors is appended to
+	Netrc                  bool
+	NetrcFile              string
+	Offline                bool   // serve the recorded responses instead of sending the requests
+	OfflineHAR             string // HAR file of responses served in offline mode
+	OpenAPISpec            string // JSON OpenAPI 3 spec the responses are validated against
+	PostResponseCommand    string // shell command run after every response
 	PreserveScrollPosition bool
+	RateLimit              float64  // maximum number of requests sent per second, 0 for no limit
+	RenderLimit            int      // KB of the formatted response body displayed at once, 0 for no limit
+	SocketIdleTimeout      Duration // tcp:// and tls:// connections are closed after receiving nothing for this duration
 	StatusLine             string
 	TLSVersionMax          uint16
 	TLSVersionMin          uint16
 	Timeout                Duration
+	UserAgent              string // User-Agent of the requests not setting one, none is sent if empty
+}
+
+// MockResponse is a canned response served by the mock server for the
+// requests matching Method and Path. An empty Method matches every method,
+// a Path ending with "*" matches every path starting with the rest of it.
+type MockResponse struct {
+	Method  string
+	Path    string
+	Status  int
+	Headers map[string]string
+	Body    string
+	Delay   Duration
+}
+
+// SigningProfile describes an HMAC request signature. StringToSign and
+// Value are Go templates, Value defaults to the signature itself.
+type SigningProfile struct {
+	Algorithm         string // md5, sha1, sha256 or sha512
+	Key               string
+	KeyEncoding       string // raw, base64 or hex
+	StringToSign      string
+	SignatureEncoding string // base64 or hex
+	Header            string
+	Value             string
+	DateHeader        string // header set to the date if the request does not set it
+	DateFormat        string // http, rfc3339, unix, unixms or a Go time layout
+}
+
+// CSRFOptions describe where the CSRF token is found in the responses and
+// the request header it is sent back in
+type CSRFOptions struct {
+	Source string // cookie, header, meta or json, empty to disable
+	Name   string // cookie, response header or meta tag name, JSONPath of a json token
+	Header string // request header of the token
+}
+
+// ListenOptions configure the listener started by "buzz listen". The
+// "{port}" placeholder of TunnelCommand is replaced by the listening port.
+type ListenOptions struct {
+	Status        int
+	Body          string
+	TunnelCommand string
 }
 
 var defaultTimeoutDuration, _ = time.ParseDuration("1m")
@@ -55,15 +142,33 @@ var DefaultKeys = map[string]map[string]string{
 		"CtrlS": "saveResponse",
 		"CtrlF": "loadRequest",
 		"CtrlE": "saveRequest",
+		"CtrlP": "previewRequest",
 		"CtrlD": "deleteLine",
 		"CtrlW": "deleteWord",
 		"CtrlO": "openEditor",
 		"CtrlT": "toggleContextSpecificSearch",
 		"CtrlX": "clearHistory",
+		"CtrlZ": "undo",
+		"CtrlY": "redo",
 		"Tab":   "nextView",
 		"CtrlJ": "nextView",
 		"CtrlK": "prevView",
+		"AltC":  "toggleCaptureSearch",
+		"AltF":  "forceRefresh",
 		"AltH":  "history",
+		"AltK":  "toggleKeepAlive",
+		"AltL":  "serverLog",
+		"CtrlL": "wireLog",
+		"CtrlB": "keybindings",
+		"CtrlN": "rawRequest",
+		"CtrlA": "notes",
+		"CtrlV": "transportOptions",
+		"CtrlU": "duplicateRequest",
+		"CtrlG": "workspace",
+		"AltS":  "responseSchema",
+		"AltY":  "toggleOffline",
+		"AltQ":  "saveSession",
+		"AltI":  "loadSession",
 		"F2":    "focus url",
 		"F3":    "focus get",
 		"F4":    "focus method",
@@ -73,27 +178,115 @@ var DefaultKeys = map[string]map[string]string{
 		"F8":    "focus response-headers",
 		"F9":    "focus response-body",
 		"F11":   "redirectRestriction",
+		"F10":   "compareEnvironments",
+		"F12":   "toggleAutoSave",
 	},
 	"url": {
 		"Enter": "submit",
 	},
+	"data": {
+		"AltP": "prettifyJSON",
+		"AltM": "minifyJSON",
+		"AltE": "multipartEditor",
+		"AltA": "toggleAuth",
+		"AltZ": "toggleGzipBody",
+		"AltW": "soapOperations",
+		"AltG": "graphQL",
+	},
+	"auth": {
+		"AltA": "toggleAuth",
+		"AltT": "cycleAuthType",
+	},
+	"search": {
+		"Enter": "searchMatches",
+	},
+	"multipart": {
+		"Enter":  "editMultipartPart",
+		"Insert": "addMultipartText",
+		"AltO":   "addMultipartFile",
+		"Delete": "deleteMultipartPart",
+	},
+	"headers": {
+		"AltU": "userAgent",
+	},
 	"response-headers": {
 		"ArrowUp":   "scrollUp",
 		"ArrowDown": "scrollDown",
 		"PageUp":    "pageUp",
 		"PageDown":  "pageDown",
+		"AltU":      "halfPageUp",
+		"AltD":      "halfPageDown",
+		"Home":      "scrollTop",
+		"End":       "scrollBottom",
+		"/":         "filterResponseHeaders",
+		"AltV":      "copyHeaderValue",
+		"AltA":      "copyHeadersAsCurl",
 	},
 	"response-body": {
+		"ArrowUp":    "scrollUp",
+		"ArrowDown":  "scrollDown",
+		"ArrowLeft":  "scrollLeft",
+		"ArrowRight": "scrollRight",
+		"PageUp":     "pageUp",
+		"PageDown":   "pageDown",
+		"AltU":       "halfPageUp",
+		"AltD":       "halfPageDown",
+		"Home":       "scrollTop",
+		"End":        "scrollBottom",
+		"AltW":       "toggleWrap",
+		"AltR":       "toggleRawResponse",
+		"AltN":       "loadMoreBody",
+		"AltJ":       "copyJSONPath",
+		"AltV":       "copyJSONValue",
+		"AltB":       "openInBrowser",
+		"AltX":       "extractJSONPath",
+		"AltT":       "toggleHTMLFormat",
+		"AltE":       "saveResponseBody",
+		"|":          "pipeResponse",
+	},
+	"history": {
+		"Delete": "deleteHistoryEntry",
+		"AltP":   "pinHistoryEntry",
+		"AltR":   "replayHistoryEntry",
+		"AltE":   "exportHistory",
+	},
+	"preview": {
 		"ArrowUp":   "scrollUp",
 		"ArrowDown": "scrollDown",
 		"PageUp":    "pageUp",
 		"PageDown":  "pageDown",
 	},
+	"server-log": {
+		"ArrowUp":   "scrollUp",
+		"ArrowDown": "scrollDown",
+		"PageUp":    "pageUp",
+		"PageDown":  "pageDown",
+		"Home":      "scrollTop",
+		"End":       "scrollBottom",
+	},
+	"env-diff": {
+		"ArrowUp":   "scrollUp",
+		"ArrowDown": "scrollDown",
+		"PageUp":    "pageUp",
+		"PageDown":  "pageDown",
+		"Home":      "scrollTop",
+		"End":       "scrollBottom",
+	},
+	"wire-log": {
+		"ArrowUp":   "scrollUp",
+		"ArrowDown": "scrollDown",
+		"PageUp":    "pageUp",
+		"PageDown":  "pageDown",
+		"Home":      "scrollTop",
+		"End":       "scrollBottom",
+	},
 	"help": {
 		"ArrowUp":   "scrollUp",
 		"ArrowDown": "scrollDown",
 		"PageUp":    "pageUp",
 		"PageDown":  "pageDown",
+		"Home":      "scrollTop",
+		"End":       "scrollBottom",
 	},
 }
 
@@ -103,12 +296,31 @@ var DefaultConfig = Config{
 		Editor:                 "vim",
 		FollowRedirects:        true,
 		FormatJSON:             true,
+		HTMLFormat:             "indent",
 		Insecure:               false,
 		PreserveScrollPosition: true,
-		StatusLine:             "[buzz {{.Version}}]{{if .Duration}} [Response time: {{.Duration}}]{{end}} [Request no.: {{.RequestNumber}}/{{.HistorySize}}] [Search type: {{.SearchType}}]{{if .DisableRedirect}} [Redirects Restricted Mode {{.DisableRedirect}}]{{end}}",
+		StatusLine:             "[buzz {{.Version}}]{{if .Duration}} [Response time: {{.Duration}}] [Size: {{.Size}}, {{.Speed}}]{{end}} [Request no.: {{.RequestNumber}}/{{.HistorySize}}] [Search type: {{.SearchType}}]{{if .DisableRedirect}} [Redirects Restricted Mode {{.DisableRedirect}}]{{end}}{{if .AutoSave}} [Auto save: {{.AutoSave}}]{{end}}{{if .CacheStatus}} [Cache: {{.CacheStatus}}]{{end}}{{if .KeepAliveDisabled}} [Keep-alive: off]{{end}}{{if .Offline}} [Offline]{{end}}{{if .GraphQL}} [GraphQL: {{.GraphQL}}]{{end}}{{if .Transport}} [Transport: {{.Transport}}]{{end}}{{if .GzipBody}} [Gzip body: {{.GzipBody}}]{{end}}{{if .CertExpiry}} [{{.CertExpiry}}]{{end}}{{if .DurationTrend}} [Trend: {{.DurationTrend}}]{{end}}{{if .Schema}} [Schema: {{.Schema}}]{{end}}{{if .Contract}} [OpenAPI: {{.Contract}}]{{end}}{{if .HostCheck}} [{{.HostCheck}}]{{end}}{{if .KeyHints}} [{{.KeyHints}}]{{end}}",
 		Timeout: Duration{
 			defaultTimeoutDuration,
 		},
+		ExpectContinueTimeout: Duration{
+			time.Second,
+		},
+		RenderLimit: 1024,
+		KeyHints:    3,
+		CertExpiryWarning: Duration{
+			14 * 24 * time.Hour,
+		},
+		SocketIdleTimeout: Duration{
+			2 * time.Second,
+		},
+	},
+	CSRF: CSRFOptions{
+		Header: "X-CSRF-Token",
+	},
+	Listen: ListenOptions{
+		Status: 200,
+		Body:   "Request received by buzz, you can close this window.\n",
 	},
 }
 
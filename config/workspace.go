@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Workspace is a versionable file holding the requests, variables and
+// settings of a project
+type Workspace struct {
+	// Variables are the values of the {{name}} placeholders of the requests
+	Variables map[string]string
+	// Environments override the variables, e.g. per deployment
+	Environments map[string]map[string]string
+	Requests     []WorkspaceRequest
+	// Groups share a base URL between their requests
+	Groups map[string]Group
+	// APIKey is added to every request, APIKeys override it per environment
+	APIKey  APIKey            `toml:"apiKey"`
+	APIKeys map[string]APIKey `toml:"apiKeys"`
+}
+
+// API_KEY_PLACEMENTS are the parts of the request an API key can be sent in
+var API_KEY_PLACEMENTS = []string{"header", "query", "cookie"}
+
+// APIKey is sent as the header, query parameter or cookie Name. Its Value
+// may use the {{name}} variables of the environment.
+type APIKey struct {
+	In    string // header (default), query or cookie
+	Name  string
+	Value string
+}
+
+// Group is a set of requests sent to the same base URL, the url of its
+// requests is appended to it unless it is absolute
+type Group struct {
+	BaseURL string `toml:"baseURL"`
+	// BaseURLs are the other base URLs the group can be switched to
+	BaseURLs []string `toml:"baseURLs"`
+}
+
+// WorkspaceRequest is a request of a workspace, its fields match the views
+type WorkspaceRequest struct {
+	Name      string
+	Method    string
+	URL       string
+	Params    string
+	Headers   string
+	Body      string
+	Auth      string
+	Schema    string // JSON Schema of the response body
+	Group     string // name of the group whose base URL is prepended to URL
+	Notes     string
+	Transport string            // transport options, e.g. "http2 compression"
+	Needs     []string          // names of the requests run before this one
+	Capture   map[string]string // variables set to a JSONPath of the response when run as a dependency
+}
+
+// LoadWorkspace reads the workspace file, its [general] settings override
+// those of conf
+func LoadWorkspace(path string, conf *Config) (*Workspace, error) {
+	workspace := &Workspace{}
+	if _, err := toml.DecodeFile(path, workspace); err != nil {
+		return nil, err
+	}
+	if err := LoadWorkspaceOptions(path, &conf.General); err != nil {
+		return nil, err
+	}
+	for environment, key := range workspace.APIKeys {
+		if err := key.validate(); err != nil {
+			return nil, fmt.Errorf("API key of %v: %v", environment, err)
+		}
+	}
+	if err := workspace.APIKey.validate(); err != nil {
+		return nil, fmt.Errorf("API key: %v", err)
+	}
+	for name, group := range workspace.Groups {
+		if group.BaseURL == "" {
+			return nil, fmt.Errorf("group %v has no baseURL", name)
+		}
+	}
+	for i, r := range workspace.Requests {
+		if r.URL == "" {
+			return nil, fmt.Errorf("request %d (%v) has no url", i+1, r.Name)
+		}
+		if _, found := workspace.Groups[r.Group]; r.Group != "" && !found {
+			return nil, fmt.Errorf("request %d (%v) has an unknown group: %v", i+1, r.Name, r.Group)
+		}
+	}
+	for i := range workspace.Requests {
+		if _, err := workspace.Dependencies(i); err != nil {
+			return nil, err
+		}
+	}
+	return workspace, nil
+}
+
+// LoadWorkspaceOptions sets the options of the [general] settings of the
+// workspace file
+func LoadWorkspaceOptions(path string, options *GeneralOptions) error {
+	settings := struct {
+		General *GeneralOptions
+	}{options}
+	_, err := toml.DecodeFile(path, &settings)
+	return err
+}
+
+// requestIndex returns the index of the first request named name, -1 if
+// there is none
+func (w *Workspace) requestIndex(name string) int {
+	for i, r := range w.Requests {
+		if r.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Dependencies returns the indexes of the requests needed by the request i,
+// directly or not, in the order they are run
+func (w *Workspace) Dependencies(i int) ([]int, error) {
+	var order []int
+	// requests being visited are false, visited ones true
+	visited := make(map[int]bool)
+	var visit func(i int, path []string) error
+	visit = func(i int, path []string) error {
+		r := w.Requests[i]
+		path = append(path, r.Name)
+		if done, found := visited[i]; found {
+			if !done {
+				return fmt.Errorf("circular dependency: %v", strings.Join(path, " -> "))
+			}
+			return nil
+		}
+		visited[i] = false
+		for _, name := range r.Needs {
+			j := w.requestIndex(name)
+			if j < 0 {
+				return fmt.Errorf("request %v needs an unknown request: %v", r.Name, name)
+			}
+			if err := visit(j, path); err != nil {
+				return err
+			}
+		}
+		visited[i] = true
+		order = append(order, i)
+		return nil
+	}
+	if err := visit(i, nil); err != nil {
+		return nil, err
+	}
+	return order[:len(order)-1], nil
+}
+
+// EnvironmentVariables returns the variables of the workspace overridden by
+// those of the environment
+func (w *Workspace) EnvironmentVariables(environment string) (map[string]string, error) {
+	variables := make(map[string]string)
+	for name, value := range w.Variables {
+		variables[name] = value
+	}
+	if environment == "" {
+		return variables, nil
+	}
+	overrides, found := w.Environments[environment]
+	if !found {
+		return nil, fmt.Errorf("unknown environment: %v", environment)
+	}
+	for name, value := range overrides {
+		variables[name] = value
+	}
+	return variables, nil
+}
+
+func (k APIKey) validate() error {
+	if k.Name == "" && k.Value == "" {
+		return nil
+	}
+	if k.Name == "" {
+		return fmt.Errorf("name is not set")
+	}
+	for _, placement := range API_KEY_PLACEMENTS {
+		if k.In == "" || k.In == placement {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown placement %q, expected header, query or cookie", k.In)
+}
+
+// EnvironmentAPIKey returns the API key of the environment, nil if none is
+// configured
+func (w *Workspace) EnvironmentAPIKey(environment string) *APIKey {
+	if key, found := w.APIKeys[environment]; found && key.Name != "" {
+		return &key
+	}
+	if w.APIKey.Name != "" {
+		key := w.APIKey
+		return &key
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package formatter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ARCHIVE_TYPES maps the media types of archives to the kind of their
+// listing
+var ARCHIVE_TYPES = map[string]string{
+	"application/zip":              "zip",
+	"application/x-zip-compressed": "zip",
+	"application/java-archive":     "zip",
+	"application/gzip":             "gzip",
+	"application/x-gzip":           "gzip",
+	"application/x-tgz":            "gzip",
+	"application/x-compressed-tar": "gzip",
+	"application/x-bzip2":          "bzip2",
+	"application/x-tar":            "tar",
+	"application/x-gtar":           "tar",
+}
+
+// archiveFormatter lists the files of an archive with their size
+type archiveFormatter struct {
+	kind string
+}
+
+type archiveEntry struct {
+	name     string
+	size     int64
+	modified time.Time
+}
+
+func (f *archiveFormatter) Format(writer io.Writer, data []byte) error {
+	entries, kind, err := f.entries(data)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, e := range entries {
+		fmt.Fprintln(writer, formatArchiveEntry(e))
+		total += e.size
+	}
+	fmt.Fprintf(writer, "\x1b[0;33m%d entries, %d bytes uncompressed (%s)\x1b[0;0m\n", len(entries), total, kind)
+	return nil
+}
+
+func formatArchiveEntry(e archiveEntry) string {
+	modified := "                "
+	if !e.modified.IsZero() {
+		modified = e.modified.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("%12d  %s  %s", e.size, modified, e.name)
+}
+
+// entries returns the files of the archive and its kind, gzip and bzip2
+// streams are listed as tar archives if they contain one
+func (f *archiveFormatter) entries(data []byte) ([]archiveEntry, string, error) {
+	switch f.kind {
+	case "zip":
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid zip archive: %v", err)
+		}
+		entries := make([]archiveEntry, 0, len(r.File))
+		for _, file := range r.File {
+			entries = append(entries, archiveEntry{file.Name, int64(file.UncompressedSize64), file.Modified})
+		}
+		return entries, "zip", nil
+	case "gzip":
+		open := func() (*gzip.Reader, error) {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid gzip stream: %v", err)
+			}
+			return r, nil
+		}
+		r, err := open()
+		if err != nil {
+			return nil, "", err
+		}
+		if entries, err := tarEntries(r); err == nil {
+			return entries, "tar.gz", nil
+		}
+		if r, err = open(); err != nil {
+			return nil, "", err
+		}
+		size, err := streamSize("gzip", r)
+		if err != nil {
+			return nil, "", err
+		}
+		name := r.Header.Name
+		if name == "" {
+			name = "(unnamed)"
+		}
+		return []archiveEntry{{name, size, r.Header.ModTime}}, "gzip", nil
+	case "bzip2":
+		if entries, err := tarEntries(bzip2.NewReader(bytes.NewReader(data))); err == nil {
+			return entries, "tar.bz2", nil
+		}
+		size, err := streamSize("bzip2", bzip2.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return nil, "", err
+		}
+		return []archiveEntry{{"(unnamed)", size, time.Time{}}}, "bzip2", nil
+	}
+	entries, err := tarEntries(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid tar archive: %v", err)
+	}
+	return entries, "tar", nil
+}
+
+// ARCHIVE_SIZE_LIMIT is the maximum number of bytes uncompressed to list a
+// compressed stream, so that a small response cannot expand without end
+var ARCHIVE_SIZE_LIMIT int64 = 1 << 30
+
+// streamSize returns the uncompressed size of the stream r of kind, it is
+// read without being kept
+func streamSize(kind string, r io.Reader) (int64, error) {
+	size, err := io.Copy(io.Discard, io.LimitReader(r, ARCHIVE_SIZE_LIMIT+1))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v stream: %v", kind, err)
+	}
+	if size > ARCHIVE_SIZE_LIMIT {
+		return 0, fmt.Errorf("%v stream larger than %d bytes uncompressed", kind, ARCHIVE_SIZE_LIMIT)
+	}
+	return size, nil
+}
+
+// tarEntries reads the headers of the tar archive in data, the content of
+// the files is skipped. At most ARCHIVE_SIZE_LIMIT bytes are read.
+func tarEntries(data io.Reader) ([]archiveEntry, error) {
+	r := tar.NewReader(io.LimitReader(data, ARCHIVE_SIZE_LIMIT))
+	var entries []archiveEntry
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry{header.Name, header.Size, header.ModTime})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("empty tar archive")
+	}
+	return entries, nil
+}
+
+func (f *archiveFormatter) Title() string {
+	return "[" + f.kind + "]"
+}
+
+func (f *archiveFormatter) Searchable() bool {
+	return true
+}
+
+// Search returns the entries whose line of the listing matches q
+func (f *archiveFormatter) Search(q string, body []byte) ([]string, error) {
+	re, err := regexp.Compile(q)
+	if err != nil {
+		return nil, err
+	}
+	entries, _, err := f.entries(body)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, e := range entries {
+		if line := formatArchiveEntry(e); re.MatchString(line) {
+			results = append(results, strings.TrimSpace(line))
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,217 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// WINDOWS_1252_HIGH are the characters of the bytes 0x80-0x9f of
+// windows-1252, the other bytes are the same as in ISO-8859-1
+var WINDOWS_1252_HIGH = [32]rune{
+	0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+	0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+}
+
+// WINDOWS_1251_HIGH are the characters of the bytes 0x80-0xbf of
+// windows-1251, the bytes 0xc0-0xff are the Cyrillic letters А-я
+var WINDOWS_1251_HIGH = [64]rune{
+	0x0402, 0x0403, 0x201a, 0x0453, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x20ac, 0x2030, 0x0409, 0x2039, 0x040a, 0x040c, 0x040b, 0x040f,
+	0x0452, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0xfffd, 0x2122, 0x0459, 0x203a, 0x045a, 0x045c, 0x045b, 0x045f,
+	0x00a0, 0x040e, 0x045e, 0x0408, 0x00a4, 0x0490, 0x00a6, 0x00a7,
+	0x0401, 0x00a9, 0x0404, 0x00ab, 0x00ac, 0x00ad, 0x00ae, 0x0407,
+	0x00b0, 0x00b1, 0x0406, 0x0456, 0x0491, 0x00b5, 0x00b6, 0x00b7,
+	0x0451, 0x2116, 0x0454, 0x00bb, 0x0458, 0x0405, 0x0455, 0x0457,
+}
+
+// ISO_8859_15_CHANGES are the characters of ISO-8859-15 differing from
+// ISO-8859-1
+var ISO_8859_15_CHANGES = map[byte]rune{
+	0xa4: 0x20ac, 0xa6: 0x0160, 0xa8: 0x0161, 0xb4: 0x017d,
+	0xb8: 0x017e, 0xbc: 0x0152, 0xbd: 0x0153, 0xbe: 0x0178,
+}
+
+// CHARSET_DECODERS transcode bodies of the supported charsets to UTF-8
+var CHARSET_DECODERS = map[string]func([]byte) []byte{
+	"iso-8859-1": decodeSingleByte(func(b byte) rune { return rune(b) }),
+	"windows-1252": decodeSingleByte(func(b byte) rune {
+		if b >= 0x80 && b < 0xa0 {
+			return WINDOWS_1252_HIGH[b-0x80]
+		}
+		return rune(b)
+	}),
+	"iso-8859-15": decodeSingleByte(func(b byte) rune {
+		if r, found := ISO_8859_15_CHANGES[b]; found {
+			return r
+		}
+		return rune(b)
+	}),
+	"windows-1251": decodeSingleByte(func(b byte) rune {
+		if b < 0x80 {
+			return rune(b)
+		} else if b < 0xc0 {
+			return WINDOWS_1251_HIGH[b-0x80]
+		}
+		return 0x0410 + rune(b-0xc0)
+	}),
+	"utf-16le": func(data []byte) []byte { return decodeUTF16(data, binary.LittleEndian) },
+	"utf-16be": func(data []byte) []byte { return decodeUTF16(data, binary.BigEndian) },
+}
+
+// CHARSET_ALIASES maps other labels of the supported charsets to their
+// name in CHARSET_DECODERS
+var CHARSET_ALIASES = map[string]string{
+	"latin1":     "iso-8859-1",
+	"iso8859-1":  "iso-8859-1",
+	"l1":         "iso-8859-1",
+	"cp1252":     "windows-1252",
+	"latin9":     "iso-8859-15",
+	"iso8859-15": "iso-8859-15",
+	"cp1251":     "windows-1251",
+	"utf-16":     "utf-16le",
+}
+
+var (
+	META_CHARSET_PATTERN   = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9._:-]+)`)
+	XML_ENCODING_PATTERN   = regexp.MustCompile(`^\s*<\?xml[^>]+encoding\s*=\s*["']([a-zA-Z0-9._:-]+)`)
+	UTF8_BOM               = []byte{0xef, 0xbb, 0xbf}
+	UTF16LE_BOM            = []byte{0xff, 0xfe}
+	UTF16BE_BOM            = []byte{0xfe, 0xff}
+	charsetDetectionLength = 1024
+)
+
+func decodeSingleByte(decode func(byte) rune) func([]byte) []byte {
+	return func(data []byte) []byte {
+		out := make([]byte, 0, len(data)+len(data)/4)
+		for _, b := range data {
+			if b < utf8.RuneSelf {
+				out = append(out, b)
+			} else {
+				out = utf8.AppendRune(out, decode(b))
+			}
+		}
+		return out
+	}
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, order.Uint16(data[i:]))
+	}
+	if len(units) > 0 && units[0] == 0xfeff {
+		units = units[1:]
+	}
+	out := make([]byte, 0, len(data))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// DetectCharset returns the charset of the body, declared by the
+// Content-Type, a byte order mark, an HTML meta tag or an XML declaration
+func DetectCharset(contentType string, body []byte) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return strings.ToLower(strings.Trim(params["charset"], `"' `))
+	}
+	switch {
+	case bytes.HasPrefix(body, UTF8_BOM):
+		return "utf-8"
+	case bytes.HasPrefix(body, UTF16LE_BOM):
+		return "utf-16le"
+	case bytes.HasPrefix(body, UTF16BE_BOM):
+		return "utf-16be"
+	}
+	head := body
+	if len(head) > charsetDetectionLength {
+		head = head[:charsetDetectionLength]
+	}
+	if m := XML_ENCODING_PATTERN.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	if strings.Contains(contentType, "html") {
+		if m := META_CHARSET_PATTERN.FindSubmatch(head); m != nil {
+			return strings.ToLower(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// charsetDecoder returns the decoder of charset, nil for UTF-8 and ASCII
+// which need no transcoding
+func charsetDecoder(charset string) (func([]byte) []byte, bool) {
+	charset = strings.ReplaceAll(charset, "_", "-")
+	switch charset {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return nil, true
+	}
+	if alias, found := CHARSET_ALIASES[charset]; found {
+		charset = alias
+	}
+	decode, found := CHARSET_DECODERS[charset]
+	return decode, found
+}
+
+// NewForBody returns the formatter of the content type, transcoding the
+// body to UTF-8 if it declares another charset. The formatter of missing or
+// generic content types is picked from the sniffed content of the body.
+func NewForBody(appConfig *config.Config, contentType string, body []byte) ResponseFormatter {
+	if isGenericContentType(contentType) {
+		if sniffed := SniffContentType(body); sniffed != "" {
+			return &sniffedFormatter{ResponseFormatter: NewForBody(appConfig, sniffed, body), contentType: sniffed}
+		}
+	}
+	f := New(appConfig, contentType)
+	switch f.(type) {
+	case *binaryFormatter, *archiveFormatter:
+		return f
+	}
+	charset := DetectCharset(contentType, body)
+	decode, supported := charsetDecoder(charset)
+	if decode == nil && supported {
+		return f
+	}
+	return &charsetFormatter{ResponseFormatter: f, charset: charset, decode: decode}
+}
+
+// charsetFormatter transcodes the body to UTF-8 before formatting it, bodies
+// of unsupported charsets are displayed as they are
+type charsetFormatter struct {
+	ResponseFormatter
+	charset string
+	decode  func([]byte) []byte
+}
+
+func (f *charsetFormatter) transcode(data []byte) []byte {
+	if f.decode == nil {
+		return data
+	}
+	return f.decode(data)
+}
+
+func (f *charsetFormatter) Format(writer io.Writer, data []byte) error {
+	return f.ResponseFormatter.Format(writer, f.transcode(data))
+}
+
+func (f *charsetFormatter) Title() string {
+	if f.decode == nil {
+		return f.ResponseFormatter.Title() + " [" + f.charset + ", not transcoded]"
+	}
+	return f.ResponseFormatter.Title() + " [" + f.charset + "]"
+}
+
+func (f *charsetFormatter) Search(q string, body []byte) ([]string, error) {
+	return f.ResponseFormatter.Search(q, f.transcode(body))
+}
@@ -19,8 +19,12 @@ func New(appConfig *config.Config, contentType string) ResponseFormatter {
 	ctype, _, err := mime.ParseMediaType(contentType)
 	if err == nil && appConfig.General.FormatJSON && (ctype == config.ContentTypes["json"] || strings.HasSuffix(ctype, "+json")) {
 		return &jsonFormatter{}
+	} else if err == nil && isXMLContentType(ctype) {
+		return &xmlFormatter{}
+	} else if kind, found := ARCHIVE_TYPES[ctype]; found && err == nil {
+		return &archiveFormatter{kind: kind}
 	} else if strings.Contains(contentType, "text/html") {
-		return &htmlFormatter{}
+		return &htmlFormatter{format: appConfig.General.HTMLFormat}
 	} else if !strings.Contains(contentType, "text") && !strings.Contains(contentType, "application") {
 		return &binaryFormatter{}
 	} else {
@@ -1,8 +1,13 @@
 package formatter
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hitstill/buzz/config"
 	"github.com/nwidger/jsoncolor"
@@ -101,3 +106,182 @@ func configFixture(jsonEnabled bool) *config.Config {
 		},
 	}
 }
+
+func TestHTMLFormats(t *testing.T) {
+	doc := []byte(`<html><head><title>Buzz</title><script>var x = "<p>";</script></head><body><h2>Docs</h2><p>See <a href="/api">the API</a>.</p><ul><li>one</li><li>two</li></ul></body></html>`)
+	conf := configFixture(true)
+
+	conf.General.HTMLFormat = "indent"
+	if indented := string(indentHTML(doc)); indented != `<html>
+  <head>
+    <title>Buzz</title>
+    <script>var x = "<p>";</script>
+  </head>
+  <body>
+    <h2>Docs</h2>
+    <p>
+      See
+      <a href="/api">the API</a>
+      .
+    </p>
+    <ul>
+      <li>one</li>
+      <li>two</li>
+    </ul>
+  </body>
+</html>
+` {
+		t.Error("Unexpected indented html:\n" + indented)
+	}
+	if title := New(conf, "text/html").Title(); title != "[html indent]" {
+		t.Error("Unexpected title " + title)
+	}
+
+	conf.General.HTMLFormat = "text"
+	var textBuffer bytes.Buffer
+	New(conf, "text/html").Format(&textBuffer, doc)
+	if text := textBuffer.String(); text != "Buzz\n\n\x1b[1m## Docs\x1b[0m\n\nSee the API \x1b[0;34m(/api)\x1b[0m.\n\n- one\n- two\n" {
+		t.Errorf("Unexpected html text %q", text)
+	}
+}
+
+func TestArchiveListing(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	var zipBuffer bytes.Buffer
+	zw := zip.NewWriter(&zipBuffer)
+	w, _ := zw.CreateHeader(&zip.FileHeader{Name: "docs/readme.txt", Modified: modified})
+	w.Write([]byte("hello"))
+	zw.Close()
+
+	var tarBuffer bytes.Buffer
+	gw := gzip.NewWriter(&tarBuffer)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "data.json", Size: 2, Mode: 0o644, ModTime: modified})
+	tw.Write([]byte("{}"))
+	tw.Close()
+	gw.Close()
+
+	for _, tc := range []struct {
+		contentType, title, listing string
+		body                        []byte
+	}{
+		{"application/zip", "[zip]", "           5  2024-05-01 12:30  docs/readme.txt\n\x1b[0;33m1 entries, 5 bytes uncompressed (zip)\x1b[0;0m\n", zipBuffer.Bytes()},
+		{"application/gzip", "[gzip]", "           2  2024-05-01 12:30  data.json\n\x1b[0;33m1 entries, 2 bytes uncompressed (tar.gz)\x1b[0;0m\n", tarBuffer.Bytes()},
+	} {
+		f := New(configFixture(true), tc.contentType)
+		if title := f.Title(); title != tc.title {
+			t.Errorf("Unexpected title %s for %s", title, tc.contentType)
+		}
+		var buffer bytes.Buffer
+		if err := f.Format(&buffer, tc.body); err != nil {
+			t.Fatal(err)
+		}
+		if listing := buffer.String(); listing != tc.listing {
+			t.Errorf("Unexpected listing %q", listing)
+		}
+	}
+
+	results, err := New(configFixture(true), "application/zip").Search("readme", zipBuffer.Bytes())
+	if err != nil || len(results) != 1 {
+		t.Errorf("Unexpected search results %v %v", results, err)
+	}
+}
+
+func TestCharsetTranscoding(t *testing.T) {
+	for _, tc := range []struct {
+		contentType, title, expected string
+		body                         []byte
+	}{
+		{"text/plain; charset=ISO-8859-1", "[text] [iso-8859-1]", "café", []byte("caf\xe9")},
+		{"text/plain; charset=windows-1252", "[text] [windows-1252]", "“€”", []byte("\x93\x80\x94")},
+		{"text/plain; charset=windows-1251", "[text] [windows-1251]", "Привет", []byte("\xcf\xf0\xe8\xe2\xe5\xf2")},
+		{"text/plain", "[text] [utf-16le]", "hé", []byte("\xff\xfeh\x00\xe9\x00")},
+		{"text/html", "[html] [iso-8859-15]", `<meta charset="iso-8859-15">€`, []byte(`<meta charset="iso-8859-15">` + "\xa4")},
+		{"text/plain; charset=shift_jis", "[text] [shift_jis, not transcoded]", "\x82\xa0", []byte("\x82\xa0")},
+		{"text/plain; charset=utf-8", "[text]", "café", []byte("café")},
+	} {
+		conf := configFixture(true)
+		conf.General.HTMLFormat = "raw"
+		f := NewForBody(conf, tc.contentType, tc.body)
+		if title := f.Title(); title != tc.title {
+			t.Errorf("Unexpected title %s for %s", title, tc.contentType)
+		}
+		var buffer bytes.Buffer
+		f.Format(&buffer, tc.body)
+		if text := buffer.String(); text != tc.expected {
+			t.Errorf("Unexpected transcoded body %q for %s", text, tc.contentType)
+		}
+	}
+}
+
+func TestSniffedFormatter(t *testing.T) {
+	for _, tc := range []struct {
+		contentType, title string
+		body               []byte
+	}{
+		{"", "[json] [sniffed application/json]", []byte(` {"a": 1}`)},
+		{"application/octet-stream", "[html] [sniffed text/html]", []byte("<!DOCTYPE html><p>hi</p>")},
+		{"application/octet-stream", "[xml] [sniffed text/xml]", []byte(`<?xml version="1.0"?><a/>`)},
+		{"binary/octet-stream", "[binary] [sniffed image/png]", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		{"", "[text] [sniffed text/plain]", []byte("plain text")},
+		{"application/octet-stream", "[text]", []byte("\x00\x01\x02")},
+		{"text/plain", "[text]", []byte(`{"a": 1}`)},
+	} {
+		if title := NewForBody(configFixture(true), tc.contentType, tc.body).Title(); title != tc.title {
+			t.Errorf("Unexpected title %s for %q", title, tc.body)
+		}
+	}
+}
+
+func TestXMLFormatter(t *testing.T) {
+	f := New(configFixture(true), "application/soap+xml; charset=utf-8")
+	if title := f.Title(); title != "[xml]" {
+		t.Errorf("Unexpected title %s", title)
+	}
+	body := `<?xml version="1.0"?><soap:Envelope xmlns:soap="urn:s"><soap:Body><m:Price><m:Item>a &amp; b</m:Item><m:Empty/><m:None></m:None></m:Price></soap:Body></soap:Envelope>`
+	var buffer bytes.Buffer
+	if err := f.Format(&buffer, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="urn:s">
+  <soap:Body>
+    <m:Price>
+      <m:Item>a &amp; b</m:Item>
+      <m:Empty/>
+      <m:None></m:None>
+    </m:Price>
+  </soap:Body>
+</soap:Envelope>
+`
+	if text := strings.NewReplacer("\x1b[0;36m", "", "\x1b[0;0m", "").Replace(buffer.String()); text != expected {
+		t.Errorf("Unexpected indented XML %q", text)
+	}
+	if err := f.Format(&buffer, []byte("<a><b></a")); err == nil {
+		t.Errorf("expected an error for invalid XML")
+	}
+}
+
+func TestArchiveSizeLimit(t *testing.T) {
+	defer func(limit int64) { ARCHIVE_SIZE_LIMIT = limit }(ARCHIVE_SIZE_LIMIT)
+	ARCHIVE_SIZE_LIMIT = 1000
+
+	var buffer bytes.Buffer
+	gw := gzip.NewWriter(&buffer)
+	gw.Write(bytes.Repeat([]byte{0}, 100))
+	gw.Close()
+	small := buffer.Bytes()
+	f := &archiveFormatter{kind: "gzip"}
+	if entries, _, err := f.entries(small); err != nil || entries[0].size != 100 {
+		t.Errorf("unexpected entries %v, %v", entries, err)
+	}
+
+	buffer = bytes.Buffer{}
+	gw = gzip.NewWriter(&buffer)
+	gw.Write(bytes.Repeat([]byte{0}, 10000))
+	gw.Close()
+	if _, _, err := f.entries(buffer.Bytes()); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected the size limit error, got %v", err)
+	}
+}
@@ -3,18 +3,33 @@ package formatter
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/x86kernel/htmlcolor"
+	"golang.org/x/net/html"
 )
 
+// HTML_FORMATS are the ways HTML responses are displayed: as they are,
+// indented or as readable text
+var HTML_FORMATS = []string{"raw", "indent", "text"}
+
 type htmlFormatter struct {
 	_ goquery.Document
 	TextFormatter
+	format string
 }
 
 func (f *htmlFormatter) Format(writer io.Writer, data []byte) error {
+	switch f.format {
+	case "indent":
+		data = indentHTML(data)
+	case "text":
+		_, err := io.WriteString(writer, htmlText(data))
+		return err
+	}
 	htmlFormatter := htmlcolor.NewFormatter()
 	buf := bytes.NewBuffer(make([]byte, 0, len(data)))
 	err := htmlFormatter.Format(buf, data)
@@ -28,6 +43,10 @@ func (f *htmlFormatter) Format(writer io.Writer, data []byte) error {
 }
 
 func (f *htmlFormatter) Title() string {
+	switch f.format {
+	case "indent", "text":
+		return "[html " + f.format + "]"
+	}
 	return "[html]"
 }
 
@@ -52,3 +71,219 @@ func (f *htmlFormatter) Search(q string, body []byte) ([]string, error) {
 
 	return results, nil
 }
+
+// elements whose content is written as it is
+var preformattedElements = map[string]bool{"pre": true, "script": true, "style": true, "textarea": true}
+
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+type htmlToken struct {
+	kind html.TokenType
+	name string
+	raw  string
+}
+
+// indentHTML writes every element on its own line, indented by its depth.
+// Elements containing only text are kept on one line.
+func indentHTML(data []byte) []byte {
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	var tokens []htmlToken
+	for {
+		kind := tokenizer.Next()
+		if kind == html.ErrorToken {
+			break
+		}
+		raw := string(tokenizer.Raw())
+		name, _ := tokenizer.TagName()
+		tokens = append(tokens, htmlToken{kind, string(name), raw})
+	}
+
+	out := &bytes.Buffer{}
+	depth := 0
+	line := func(s string) {
+		out.WriteString(strings.Repeat("  ", depth))
+		out.WriteString(s)
+		out.WriteByte('\n')
+	}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.kind {
+		case html.StartTagToken:
+			if voidElements[t.name] {
+				line(t.raw)
+				continue
+			}
+			if preformattedElements[t.name] {
+				// copy the content up to the end tag
+				content := t.raw
+				for i+1 < len(tokens) && !(tokens[i+1].kind == html.EndTagToken && tokens[i+1].name == t.name) {
+					i++
+					content += tokens[i].raw
+				}
+				if i+1 < len(tokens) {
+					i++
+					content += tokens[i].raw
+				}
+				line(content)
+				continue
+			}
+			if i+2 < len(tokens) && tokens[i+1].kind == html.TextToken && tokens[i+2].kind == html.EndTagToken && tokens[i+2].name == t.name {
+				line(t.raw + strings.TrimSpace(tokens[i+1].raw) + tokens[i+2].raw)
+				i += 2
+				continue
+			}
+			if i+1 < len(tokens) && tokens[i+1].kind == html.EndTagToken && tokens[i+1].name == t.name {
+				line(t.raw + tokens[i+1].raw)
+				i++
+				continue
+			}
+			line(t.raw)
+			depth++
+		case html.EndTagToken:
+			if depth > 0 {
+				depth--
+			}
+			line(t.raw)
+		case html.TextToken:
+			if text := strings.TrimSpace(t.raw); text != "" {
+				line(text)
+			}
+		default:
+			line(strings.TrimSpace(t.raw))
+		}
+	}
+	return out.Bytes()
+}
+
+// blockElements are separated by a blank line, lineElements start a new line
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "div": true, "dl": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"header": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "ul": true, "title": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+var lineElements = map[string]bool{"dd": true, "dt": true, "li": true, "tr": true}
+
+var hiddenElements = map[string]bool{"script": true, "style": true, "noscript": true, "template": true}
+
+// htmlText renders the readable text of an HTML document: headings are
+// prefixed by #, list items by - and links are followed by their URL in
+// parentheses
+func htmlText(data []byte) string {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	w := &htmlTextWriter{}
+	w.write(doc)
+	lines := strings.Split(w.String(), "\n")
+	result := make([]string, 0, len(lines))
+	blank := true
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if line == "" {
+			if !blank {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		result = append(result, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(result, "\n")) + "\n"
+}
+
+type htmlTextWriter struct {
+	strings.Builder
+	pre int
+}
+
+func (w *htmlTextWriter) write(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre > 0 {
+			w.WriteString(n.Data)
+		} else if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+			if strings.IndexFunc(n.Data[:1], isSpace) == 0 {
+				text = " " + text
+			}
+			if strings.IndexFunc(n.Data[len(n.Data)-1:], isSpace) == 0 {
+				text += " "
+			}
+			w.WriteString(text)
+		}
+		return
+	case html.ElementNode:
+		if hiddenElements[n.Data] {
+			return
+		}
+		switch n.Data {
+		case "br":
+			w.WriteString("\n")
+			return
+		case "hr":
+			w.WriteString("\n----------\n")
+			return
+		case "img":
+			if alt := htmlAttr(n, "alt"); alt != "" {
+				w.WriteString("[" + alt + "]")
+			}
+			return
+		}
+	}
+
+	separator := ""
+	if n.Type == html.ElementNode && blockElements[n.Data] {
+		separator = "\n\n"
+	} else if n.Type == html.ElementNode && lineElements[n.Data] {
+		w.WriteString("\n")
+	}
+	w.WriteString(separator)
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			w.WriteString("\x1b[1m" + strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		case "li":
+			w.WriteString("- ")
+		case "pre":
+			w.pre++
+		case "td", "th":
+			w.WriteString("\t")
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.write(c)
+	}
+	if n.Type == html.ElementNode {
+		switch n.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			w.WriteString("\x1b[0m")
+		case "a":
+			if href := htmlAttr(n, "href"); href != "" && !strings.HasPrefix(href, "#") {
+				fmt.Fprintf(w, " \x1b[0;34m(%s)\x1b[0m", href)
+			}
+		case "pre":
+			w.pre--
+		}
+	}
+	w.WriteString(separator)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f'
+}
+
+func htmlAttr(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
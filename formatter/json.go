@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"sync"
 
 	"github.com/nwidger/jsoncolor"
 	"github.com/tidwall/gjson"
 )
 
 type jsonFormatter struct {
+	// searches can run concurrently while the previous one is cancelled
+	mu         sync.Mutex
 	parsedBody gjson.Result
 	TextFormatter
 }
@@ -32,10 +35,13 @@ func (f *jsonFormatter) Title() string {
 
 func (f *jsonFormatter) Search(q string, body []byte) ([]string, error) {
 	if q != "" {
+		f.mu.Lock()
 		if f.parsedBody.Type != gjson.JSON {
 			f.parsedBody = gjson.ParseBytes(body)
 		}
-		searchResult := f.parsedBody.Get(q)
+		parsedBody := f.parsedBody
+		f.mu.Unlock()
+		searchResult := parsedBody.Get(q)
 		if searchResult.Type == gjson.Null {
 			return nil, errors.New("invalid gjson query or no results found")
 		}
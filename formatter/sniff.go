@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// GENERIC_CONTENT_TYPES are the media types which do not tell how to
+// display the body, their bodies are sniffed
+var GENERIC_CONTENT_TYPES = map[string]bool{
+	"":                         true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/unknown":      true,
+}
+
+// isGenericContentType returns whether the content type is missing or
+// generic
+func isGenericContentType(contentType string) bool {
+	ctype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType == ""
+	}
+	return GENERIC_CONTENT_TYPES[ctype]
+}
+
+// SniffContentType returns the media type detected from the content of the
+// body: JSON, XML, HTML, images, archives or plain text, "" if it is unknown
+func SniffContentType(body []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return ""
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+	ctype, _, _ := mime.ParseMediaType(http.DetectContentType(body))
+	if ctype == "application/octet-stream" {
+		return ""
+	}
+	return ctype
+}
+
+// sniffedFormatter is the formatter of the media type detected from the body
+type sniffedFormatter struct {
+	ResponseFormatter
+	contentType string
+}
+
+func (f *sniffedFormatter) Title() string {
+	return f.ResponseFormatter.Title() + " [sniffed " + f.contentType + "]"
+}
@@ -0,0 +1,112 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type xmlFormatter struct {
+	TextFormatter
+}
+
+func (f *xmlFormatter) Format(writer io.Writer, data []byte) error {
+	indented, err := indentXML(data)
+	if err != nil {
+		return fmt.Errorf("xml formatter error: %v", err)
+	}
+	_, err = writer.Write(indented)
+	return err
+}
+
+func (f *xmlFormatter) Title() string {
+	return "[xml]"
+}
+
+// isXMLContentType reports whether the media type is an XML document, e.g.
+// text/xml or application/soap+xml
+func isXMLContentType(ctype string) bool {
+	return ctype == "application/xml" || ctype == "text/xml" || strings.HasSuffix(ctype, "+xml")
+}
+
+type xmlToken struct {
+	token xml.Token
+	raw   string
+}
+
+// xmlTag colors the raw start or end tag
+func xmlTag(raw string) string {
+	return "\x1b[0;36m" + raw + "\x1b[0;0m"
+}
+
+// indentXML writes every element on its own line, indented by its depth,
+// as they are written in data. Elements containing only text are kept on one
+// line.
+func indentXML(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var tokens []xmlToken
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, xmlToken{xml.CopyToken(token), string(data[start:decoder.InputOffset()])})
+	}
+
+	out := &bytes.Buffer{}
+	depth := 0
+	line := func(s string) {
+		out.WriteString(strings.Repeat("  ", depth))
+		out.WriteString(s)
+		out.WriteByte('\n')
+	}
+	isEnd := func(i int) bool {
+		_, ok := tokens[i].token.(xml.EndElement)
+		return ok
+	}
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.token.(type) {
+		case xml.StartElement:
+			if i+1 < len(tokens) && isEnd(i+1) && tokens[i+1].raw == "" {
+				// self-closing element
+				line(xmlTag(t.raw))
+				i++
+				continue
+			}
+			if i+2 < len(tokens) && isEnd(i+2) {
+				if text, ok := tokens[i+1].token.(xml.CharData); ok && !strings.Contains(strings.TrimSpace(string(text)), "\n") {
+					line(xmlTag(t.raw) + strings.TrimSpace(tokens[i+1].raw) + xmlTag(tokens[i+2].raw))
+					i += 2
+					continue
+				}
+			}
+			if i+1 < len(tokens) && isEnd(i+1) {
+				line(xmlTag(t.raw) + xmlTag(tokens[i+1].raw))
+				i++
+				continue
+			}
+			line(xmlTag(t.raw))
+			depth++
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+			}
+			line(xmlTag(t.raw))
+		case xml.CharData:
+			if text := strings.TrimSpace(t.raw); text != "" {
+				line(text)
+			}
+		default:
+			line(strings.TrimSpace(t.raw))
+		}
+	}
+	return out.Bytes(), nil
+}
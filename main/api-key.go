@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// addAPIKey sends the API key in its header, query parameter or cookie
+// unless the request already sets it
+func addAPIKey(req *http.Request, key *config.APIKey, variables map[string]string) error {
+	if key == nil {
+		return nil
+	}
+	value, err := expandDynamicVariables(expandVariables(key.Value, variables))
+	if err != nil {
+		return fmt.Errorf("API key: %v", err)
+	}
+	switch key.In {
+	case "query":
+		query := req.URL.Query()
+		if query.Has(key.Name) {
+			return nil
+		}
+		query.Set(key.Name, value)
+		req.URL.RawQuery = query.Encode()
+	case "cookie":
+		if _, err := req.Cookie(key.Name); err == nil {
+			return nil
+		}
+		req.AddCookie(&http.Cookie{Name: key.Name, Value: value})
+	default:
+		if req.Header.Get(key.Name) == "" {
+			req.Header.Set(key.Name, value)
+		}
+	}
+	return nil
+}
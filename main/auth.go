@@ -0,0 +1,350 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"github.com/jroimartin/gocui"
+)
+
+// AUTH_TYPES lists the authentication types of the auth view with their
+// fields, in the order they are cycled through
+var AUTH_TYPES = []struct {
+	name   string
+	fields []string
+}{
+	{"none", nil},
+	{"basic", []string{"username", "password"}},
+	{"bearer", []string{"token"}},
+	{"oauth2", []string{"token_url", "client_id", "client_secret", "scope"}},
+	{"aws", []string{"access_key", "secret_key", "session_token", "region", "service"}},
+	{"hmac", []string{"profile"}},
+}
+
+const DEFAULT_AUTH = "type: none"
+
+// parseAuth parses the "field: value" lines of the auth view
+func parseAuth(text string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("Invalid auth field: %v", line)
+		}
+		settings[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	settings["type"] = strings.ToLower(settings["type"])
+	if settings["type"] == "" {
+		settings["type"] = "none"
+	}
+	if authTypeIndex(settings["type"]) < 0 {
+		return nil, fmt.Errorf("Unknown auth type: %v", settings["type"])
+	}
+	return settings, nil
+}
+
+func authTypeIndex(name string) int {
+	for i, t := range AUTH_TYPES {
+		if t.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatAuth renders the fields of the auth type with the values found in
+// settings
+func formatAuth(authType string, settings map[string]string) string {
+	lines := []string{"type: " + authType}
+	for _, field := range AUTH_TYPES[authTypeIndex(authType)].fields {
+		lines = append(lines, field+": "+settings[field])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ToggleAuthView switches the request data pane between the request body
+// and the auth view
+func (a *App) ToggleAuthView(g *gocui.Gui, _ *gocui.View) error {
+	if VIEWS[a.viewIndex] == AUTH_VIEW {
+		return a.showRequestTab(g, REQUEST_DATA_VIEW)
+	}
+	return a.showRequestTab(g, AUTH_VIEW)
+}
+
+// showRequestTab shows and focuses either the request data or the auth view
+func (a *App) showRequestTab(g *gocui.Gui, name string) error {
+	for i, v := range VIEWS {
+		if v == REQUEST_DATA_VIEW || v == AUTH_VIEW {
+			VIEWS[i] = name
+			a.viewIndex = i
+		}
+	}
+	if _, err := g.SetViewOnTop(name); err != nil {
+		return err
+	}
+	return a.setView(g)
+}
+
+// CycleAuthType replaces the fields of the auth view with those of the next
+// auth type, keeping the values of the fields they share
+func (a *App) CycleAuthType(g *gocui.Gui, v *gocui.View) error {
+	settings, err := parseAuth(getViewValue(g, AUTH_VIEW))
+	if err != nil {
+		settings = map[string]string{"type": "none"}
+	}
+	next := AUTH_TYPES[(authTypeIndex(settings["type"])+1)%len(AUTH_TYPES)].name
+	setViewTextAndCursor(v, formatAuth(next, settings))
+	return nil
+}
+
+// addAuth sets the credentials described by the auth view, the request
+// headers take precedence
+func (a *App) addAuth(r *Request, req *http.Request) error {
+	if r.Auth == "" {
+		return nil
+	}
+	settings, err := parseAuth(r.Auth)
+	if err != nil {
+		return err
+	}
+	if settings["type"] == "none" || req.Header.Get("Authorization") != "" {
+		return nil
+	}
+	switch settings["type"] {
+	case "basic":
+		req.SetBasicAuth(settings["username"], settings["password"])
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+settings["token"])
+	case "oauth2":
+		token, err := OAUTH2_TOKENS.get(settings)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case "aws":
+		// signed by signRequest once the body is final
+		r.signAWS = true
+	}
+	return nil
+}
+
+// curlAuthArgs returns the curl options sending the credentials of the auth
+// view, or a comment when curl cannot compute them
+func curlAuthArgs(auth string) (args, comment string) {
+	if auth == "" {
+		return "", ""
+	}
+	settings, err := parseAuth(auth)
+	if err != nil {
+		return "", ""
+	}
+	switch settings["type"] {
+	case "basic":
+		return " -u " + shellescape.Quote(settings["username"]+":"+settings["password"]), ""
+	case "bearer":
+		return " -H " + shellescape.Quote("Authorization: Bearer "+settings["token"]), ""
+	case "oauth2":
+		return "", "# oauth2 auth: set the Authorization header to a token fetched from " + settings["token_url"] + "\n"
+	case "aws", "hmac":
+		return "", "# " + settings["type"] + " auth: the request must be signed, curl sends it unsigned\n"
+	}
+	return "", ""
+}
+
+type oauth2Token struct {
+	value   string
+	expires time.Time
+}
+
+// oauth2TokenCache keeps the tokens of the client credentials grant until
+// they expire
+type oauth2TokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]oauth2Token
+}
+
+var OAUTH2_TOKENS = &oauth2TokenCache{tokens: make(map[string]oauth2Token)}
+
+func (c *oauth2TokenCache) get(settings map[string]string) (string, error) {
+	key := strings.Join([]string{settings["token_url"], settings["client_id"], settings["client_secret"], settings["scope"]}, "\n")
+	c.mu.Lock()
+	token, found := c.tokens[key]
+	c.mu.Unlock()
+	if found && time.Now().Before(token.expires) {
+		return token.value, nil
+	}
+
+	token, err := fetchOAuth2Token(settings)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.tokens[key] = token
+	c.mu.Unlock()
+	return token.value, nil
+}
+
+// fetchOAuth2Token requests a token with the client credentials grant
+func fetchOAuth2Token(settings map[string]string) (oauth2Token, error) {
+	if settings["token_url"] == "" {
+		return oauth2Token{}, fmt.Errorf("OAuth2 error: token_url is not set")
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if settings["scope"] != "" {
+		form.Set("scope", settings["scope"])
+	}
+	req, err := http.NewRequest(http.MethodPost, settings["token_url"], strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("OAuth2 error: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(settings["client_id"]), url.QueryEscape(settings["client_secret"]))
+
+	response, err := CLIENT.Do(req)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("OAuth2 error: %v", err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if response.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("OAuth2 error: %v\n%s", response.Status, body)
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.AccessToken == "" {
+		return oauth2Token{}, fmt.Errorf("OAuth2 error: no access token in the response\n%s", body)
+	}
+	token := oauth2Token{value: result.AccessToken, expires: time.Now().Add(time.Hour)}
+	if result.ExpiresIn > 0 {
+		// renew the token slightly before it expires
+		token.expires = time.Now().Add(time.Duration(result.ExpiresIn)*time.Second - 10*time.Second)
+	}
+	return token, nil
+}
+
+// signAWSv4 signs req with AWS Signature Version 4. The payload of streamed
+// bodies is not signed.
+func signAWSv4(req *http.Request, settings map[string]string, now time.Time) error {
+	for _, field := range []string{"access_key", "secret_key", "region", "service"} {
+		if settings[field] == "" {
+			return fmt.Errorf("AWS auth error: %v is not set", field)
+		}
+	}
+	t := now.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := "UNSIGNED-PAYLOAD"
+	if req.Body == nil || req.Body == http.NoBody {
+		payloadHash = sha256Hex(nil)
+	} else if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		payloadHash = sha256Hex(content)
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if settings["service"] == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if settings["session_token"] != "" {
+		req.Header.Set("X-Amz-Security-Token", settings["session_token"])
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	canonicalHeaders := map[string]string{"host": host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			canonicalHeaders[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(canonicalHeaders))
+	for name := range canonicalHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headerLines strings.Builder
+	for _, name := range names {
+		headerLines.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		headerLines.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, settings["region"], settings["service"], "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+settings["secret_key"]), date)
+	for _, part := range []string{settings["region"], settings["service"], "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		settings["access_key"], scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalQuery sorts and encodes query parameters as required by AWS
+func canonicalQuery(query url.Values) string {
+	var params []string
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
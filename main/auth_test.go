@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestSignAWSv4(t *testing.T) {
+	// get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	err := signAWSv4(req, map[string]string{
+		"access_key": "AKIDEXAMPLE",
+		"secret_key": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		"region":     "us-east-1",
+		"service":    "service",
+	}, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != expected {
+		t.Errorf("unexpected signature %q", auth)
+	}
+}
+
+func TestSignAWSv4CompressedBody(t *testing.T) {
+	a := &App{config: &config.Config{General: config.GeneralOptions{GzipRequestBody: true}}}
+	r := &Request{
+		Url:    "https://bucket.s3.amazonaws.com/key",
+		Method: http.MethodPut,
+		Data:   "some content",
+		Auth:   "type: aws\naccess_key: AKID\nsecret_key: secret\nregion: us-east-1\nservice: s3",
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.addConfigHeaders(r, req, a.config); err != nil {
+		t.Fatal(err)
+	}
+	if err := finalizeRequest(r, req, a.config); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatal("expected a compressed body")
+	}
+	if hash := req.Header.Get("X-Amz-Content-Sha256"); hash != sha256Hex(body) {
+		t.Errorf("expected the hash of the compressed body, got %v", hash)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		t.Errorf("unexpected authorization %q", req.Header.Get("Authorization"))
+	}
+}
+
+func TestAddAuth(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		tokenRequests++
+		body, _ := io.ReadAll(req.Body)
+		if id, secret, _ := req.BasicAuth(); id != "client" || secret != "secret" || !strings.Contains(string(body), "grant_type=client_credentials") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, `{"access_token": "abc", "expires_in": 3600}`)
+	}))
+	defer server.Close()
+
+	a := &App{}
+	for _, tc := range []struct {
+		auth, header, expected string
+	}{
+		{"type: basic\nusername: alice\npassword: s3cret", "", "Basic YWxpY2U6czNjcmV0"},
+		{"type: bearer\ntoken: xyz", "", "Bearer xyz"},
+		{"type: bearer\ntoken: xyz", "Authorization: Custom", "Custom"},
+		{"type: oauth2\ntoken_url: " + server.URL + "\nclient_id: client\nclient_secret: secret", "", "Bearer abc"},
+		{"type: oauth2\ntoken_url: " + server.URL + "\nclient_id: client\nclient_secret: secret", "", "Bearer abc"},
+		{DEFAULT_AUTH, "", ""},
+	} {
+		r := &Request{Url: "http://example.com/", Method: http.MethodGet, Headers: tc.header, Auth: tc.auth}
+		req, err := r.newHTTPRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.addAuth(r, req); err != nil {
+			t.Fatal(err)
+		}
+		if auth := req.Header.Get("Authorization"); auth != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.auth, tc.expected, auth)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("expected the OAuth2 token to be requested once, got %d requests", tokenRequests)
+	}
+}
+
+func TestFormatAuth(t *testing.T) {
+	settings, err := parseAuth("type: Basic\nusername: alice\npassword: s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := formatAuth("aws", settings); auth != "type: aws\naccess_key: \nsecret_key: \nsession_token: \nregion: \nservice: " {
+		t.Errorf("unexpected fields %q", auth)
+	}
+	if _, err := parseAuth("type: kerberos"); err == nil {
+		t.Error("unknown auth type accepted")
+	}
+}
+
+func TestAuthVariables(t *testing.T) {
+	a := &App{config: &config.Config{}}
+	r := &Request{Url: "http://example.com/", Method: http.MethodGet, Auth: "type: bearer\ntoken: {{token}}"}
+	req, err := a.newEnvironmentRequest(r, map[string]string{"token": "abc"}, nil, a.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Bearer abc" {
+		t.Errorf("expected the variable in the authorization, got %q", auth)
+	}
+}
+
+func TestExportCurlAuth(t *testing.T) {
+	export := func(headers, auth string) string {
+		return string(exportCurl(Request{Url: "https://example.com/", Method: http.MethodGet, Headers: headers, Auth: auth}))
+	}
+	if command := export("", "type: basic\nusername: user\npassword: secret"); !strings.Contains(command, " -u user:secret ") {
+		t.Errorf("the basic credentials are not exported: %s", command)
+	}
+	if command := export("", "type: bearer\ntoken: abc"); !strings.Contains(command, " -H 'Authorization: Bearer abc' ") {
+		t.Errorf("the bearer token is not exported: %s", command)
+	}
+	if command := export("Authorization: Token xyz", "type: bearer\ntoken: abc"); strings.Contains(command, "Bearer") {
+		t.Errorf("the Authorization header does not take precedence: %s", command)
+	}
+	for _, auth := range []string{"type: aws\nregion: us-east-1", "type: hmac\nprofile: partner"} {
+		if command := export("", auth); !strings.HasPrefix(command, "# ") || !strings.Contains(command, "must be signed") {
+			t.Errorf("no comment about the signature: %s", command)
+		}
+	}
+	if command := export("", "type: oauth2\ntoken_url: https://example.com/token"); !strings.HasPrefix(command, "# oauth2 auth") {
+		t.Errorf("no comment about the OAuth2 token: %s", command)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hitstill/buzz/formatter"
+)
+
+// BODY_INDEX_MIN_SIZE is the size of the formatted response bodies from
+// which their lines are indexed
+const BODY_INDEX_MIN_SIZE = 1024 * 1024
+
+// bodyIndex keeps a large formatted response body split in lines, with the
+// results of the last search, so that searching again and jumping to a line
+// do not format and scan the whole body again
+type bodyIndex struct {
+	formatter formatter.ResponseFormatter
+	formatted []byte
+	plain     string // formatted body without ANSI escapes
+	starts    []int  // offset of the start of every line in plain
+	ends      []int  // offset of the end of every line in formatted
+
+	mu      sync.Mutex
+	matches *indexedMatches
+	results *indexedResults
+}
+
+// indexedMatches are the numbers of the lines matching q
+type indexedMatches struct {
+	q     string
+	lines []int
+}
+
+// indexedResults are the results of the search q by f
+type indexedResults struct {
+	f       formatter.ResponseFormatter
+	q       string
+	results []string
+}
+
+// newBodyIndex indexes the lines of the body formatted by f
+func newBodyIndex(f formatter.ResponseFormatter, formatted []byte) *bodyIndex {
+	index := &bodyIndex{
+		formatter: f,
+		formatted: formatted,
+		plain:     ANSI_ESCAPE_PATTERN.ReplaceAllString(string(formatted), ""),
+		starts:    []int{0},
+	}
+	for start := 0; ; {
+		next := strings.IndexByte(index.plain[start:], '\n')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+		index.starts = append(index.starts, start)
+	}
+	for end := 0; end < len(formatted); {
+		next := bytes.IndexByte(formatted[end:], '\n')
+		if next < 0 {
+			end = len(formatted)
+		} else {
+			end += next + 1
+		}
+		index.ends = append(index.ends, end)
+	}
+	return index
+}
+
+// lineEnd returns the offset of the end of the line in the formatted body
+func (i *bodyIndex) lineEnd(line int) int {
+	if line < 0 || line >= len(i.ends) {
+		return len(i.formatted)
+	}
+	return i.ends[line]
+}
+
+// line returns the text of the line without ANSI escapes
+func (i *bodyIndex) line(line int) string {
+	end := len(i.plain)
+	if line+1 < len(i.starts) {
+		end = i.starts[line+1] - 1
+	}
+	return i.plain[i.starts[line]:end]
+}
+
+// matchingLines returns the lines matching the regular expression q, the
+// lines of the last query are kept
+func (i *bodyIndex) matchingLines(q string) ([]searchMatch, error) {
+	i.mu.Lock()
+	matches := i.matches
+	i.mu.Unlock()
+	if matches == nil || matches.q != q {
+		re, err := regexp.Compile(q)
+		if err != nil {
+			return nil, err
+		}
+		matches = &indexedMatches{q: q}
+		for n := range i.starts {
+			if re.MatchString(i.line(n)) {
+				matches.lines = append(matches.lines, n)
+				if len(matches.lines) == SEARCH_MATCHES_LIMIT {
+					break
+				}
+			}
+		}
+		i.mu.Lock()
+		i.matches = matches
+		i.mu.Unlock()
+	}
+	found := make([]searchMatch, len(matches.lines))
+	for n, line := range matches.lines {
+		found[n] = searchMatch{line, i.line(line)}
+	}
+	return found, nil
+}
+
+// search returns the results of the search q of the body by f, the results
+// of the last search are kept
+func (i *bodyIndex) search(ctx context.Context, f formatter.ResponseFormatter, q string, body []byte) ([]string, error) {
+	i.mu.Lock()
+	last := i.results
+	i.mu.Unlock()
+	if last != nil && last.f == f && last.q == q {
+		return last.results, nil
+	}
+	results, err := f.Search(q, body)
+	if err != nil {
+		return nil, err
+	}
+	// the results of a cancelled search are not displayed
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	i.mu.Lock()
+	i.results = &indexedResults{f, q, results}
+	i.mu.Unlock()
+	return results, nil
+}
+
+// bodyIndexFor returns the index of the response body formatted by f, nil
+// if the body was not indexed with f
+func (r *Request) bodyIndexFor(f formatter.ResponseFormatter) *bodyIndex {
+	if r.index == nil || r.index.formatter != f {
+		return nil
+	}
+	return r.index
+}
+
+// searchBody returns the results of the search q of the body by f, kept in
+// the index of large bodies. Nothing is searched once ctx is done.
+func searchBody(ctx context.Context, index *bodyIndex, f formatter.ResponseFormatter, q string, body []byte) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return f.Search(q, body)
+	}
+	return index.search(ctx, f, q, body)
+}
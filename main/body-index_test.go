@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hitstill/buzz/formatter"
+)
+
+func TestBodyIndex(t *testing.T) {
+	formatted := []byte("\x1b[0;33mfirst\x1b[0;0m\nsecond\nthird match\nlast match")
+	index := newBodyIndex(DEFAULT_FORMATTER, formatted)
+
+	if end := index.lineEnd(0); end != len("\x1b[0;33mfirst\x1b[0;0m\n") {
+		t.Errorf("unexpected end of the first line %d", end)
+	}
+	if end := index.lineEnd(3); end != len(formatted) {
+		t.Errorf("unexpected end of the last line %d", end)
+	}
+
+	matches, err := index.matchingLines("^(first|.*match)$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 || matches[0].line != 0 || matches[2].text != "last match" {
+		t.Errorf("unexpected matches %v", matches)
+	}
+	index.matches = &indexedMatches{"cached", []int{1}}
+	if matches, _ := index.matchingLines("cached"); len(matches) != 1 || matches[0].text != "second" {
+		t.Errorf("expected the cached matches, got %v", matches)
+	}
+	if matches, _ := index.matchingLines("^first$"); len(matches) != 1 || index.matches.q != "^first$" {
+		t.Errorf("expected only the matches of the last query, got %v %v", matches, index.matches)
+	}
+
+	results, err := searchBody(context.Background(), index, &formatter.TextFormatter{}, "match", formatted)
+	if err != nil || len(results) != 2 {
+		t.Errorf("unexpected results %v %v", results, err)
+	}
+	if index.results == nil || index.results.q != "match" {
+		t.Errorf("expected the results to be kept")
+	}
+
+	req := &Request{index: index}
+	if req.bodyIndexFor(DEFAULT_FORMATTER) != index || req.bodyIndexFor(&formatter.TextFormatter{}) != nil {
+		t.Errorf("expected the index only for its formatter")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/jroimartin/gocui"
+)
+
+// browserCommand returns the command opening path in the default browser,
+// $BROWSER takes precedence
+func browserCommand(path string) *exec.Cmd {
+	if browser := os.Getenv("BROWSER"); browser != "" {
+		return exec.Command(browser, path)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	}
+	return exec.Command("xdg-open", path)
+}
+
+// OpenInBrowser writes the response body to a temporary file named after its
+// content type and opens it in the web browser. The file is kept since the
+// browser loads it asynchronously.
+func (a *App) OpenInBrowser(g *gocui.Gui, _ *gocui.View) error {
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil {
+		return a.OpenMessageView("Error: no response", g)
+	}
+	r := a.history[a.historyIndex]
+	file, err := os.CreateTemp("", "buzz-response-*"+extensionForContentType(r.ContentType))
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	_, err = file.Write(r.RawResponseBody)
+	file.Close()
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	cmd := browserCommand(file.Name())
+	if err := cmd.Start(); err != nil {
+		return a.OpenMessageView("Error: cannot open the browser: "+err.Error(), g)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -10,15 +10,16 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"mime/multipart"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"os"
-	"path"
+	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/proxy"
@@ -41,16 +42,83 @@ const (
 )
 
 type Request struct {
-	Url             string
-	Method          string
-	GetParams       string
-	Data            string
-	Headers         string
-	ResponseHeaders string
-	RawResponseBody []byte
-	ContentType     string
-	Duration        time.Duration
-	Formatter       formatter.ResponseFormatter
+	Url              string
+	Method           string
+	GetParams        string
+	Data             string
+	Headers          string
+	RawRequest       string // request sent as it is by the raw request editor
+	Auth             string // content of the auth view
+	Schema           string // location of the JSON Schema of the response body
+	SendBody         bool   // the body is sent although the method does not expect one
+	Notes            string // annotations of the request, e.g. the expected behavior
+	Transport        transportOptions
+	RequestHeader    http.Header
+	ResponseHeaders  string
+	ResponseHeader   http.Header
+	PreviousHeader   http.Header // response header of the previous request to the same URL
+	ResponseTrailer  http.Header
+	Proto            string
+	RawResponseBody  []byte
+	ContentType      string
+	StatusCode       int
+	TLSVersion       uint16
+	CertExpiry       time.Time // expiry of the TLS leaf certificate
+	Time             time.Time
+	Duration         time.Duration
+	DownloadDuration time.Duration // time spent reading the response body
+	TransferSize     int64         // body bytes received before decompression
+	CacheStatus      string        // outcome of the response cache lookup
+	Offline          bool          // the response was replayed in offline mode
+	RemoteAddr       string        // address of the connection used by the request
+	ConnReused       bool
+	ALPN             string // protocol negotiated with TLS ALPN
+	ExpectContinue   bool   // the request waited for a 100 Continue response
+	Got100Continue   bool
+	ContinueDelay    time.Duration // time until the 100 Continue response
+	UserinfoAuth     bool          // Authorization was generated from URL credentials
+	Pinned           bool
+	SchemaViolations []schemaViolation // values of the response body not matching Schema
+	SchemaError      error             // the schema could not be loaded or the body is not JSON
+	// differences between the response and the OpenAPI spec
+	ContractChecked    bool
+	ContractViolations []schemaViolation
+	ContractError      error
+	Formatter          formatter.ResponseFormatter
+	index              *bodyIndex // lines of the large formatted body, once displayed
+	signAWS            bool       // the auth view signs the request with AWS Signature Version 4
+}
+
+// Throughput returns the transfer speed of the response body in bytes per
+// second, measured over the whole request
+func (r *Request) Throughput() float64 {
+	total := r.Duration + r.DownloadDuration
+	if total <= 0 {
+		return 0
+	}
+	return float64(r.TransferSize) / total.Seconds()
+}
+
+// fullURL returns the request URL including the GET parameters
+func (r *Request) fullURL() string {
+	u, err := url.Parse(r.Url)
+	if err != nil || r.GetParams == "" {
+		return r.Url
+	}
+	u.RawQuery = r.GetParams
+	return u.String()
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
 }
 
 type App struct {
@@ -60,6 +128,89 @@ type App struct {
 	history      []*Request
 	config       *config.Config
 	statusLine   *StatusLine
+	undoStacks   map[string]*undoStack
+	proxyURL     string
+	socksProxy   bool
+	rawResponse  bool
+	// history popup filter and the history indices matching it
+	historyFilter  string
+	historyEntries []int
+	// local servers and the requests they received
+	servers      []localServer
+	incoming     []*incomingRequest
+	tunnel       *exec.Cmd
+	tunnelOutput []string
+	// parts listed by the multipart editor
+	multipartParts []multipartPart
+	// lines listed by the search jump list, searchJumped is set when the
+	// response body displays one of them instead of the search results
+	searchMatches []searchMatch
+	searchJumped  bool
+	// lists the values captured by the search instead of the matches
+	captureSearch bool
+	// bytes of the formatted response body displayed, 0 displays everything
+	bodyLimit int
+	// cancels the formatting of the response body in progress
+	cancelFormat context.CancelFunc
+	searchTimer  *time.Timer
+	// number of the latest submitted request, the previous ones are
+	// cancelled and not rendered
+	submission       int
+	cancelSubmission context.CancelFunc
+	// shell command the raw response body is piped through before display
+	pipeCommand string
+	// substring the response headers view is filtered by
+	responseHeadersFilter string
+	// values of the {{name}} placeholders extracted from responses or set by
+	// the environment of the workspace
+	variables map[string]string
+	// location of the JSON Schema the responses are validated against
+	schema string
+	// transport options of the edited request
+	transport transportOptions
+	// schema of the GraphQL endpoint completed in the data view, nil
+	// outside of the GraphQL mode
+	graphQL *graphQLSchema
+	// OpenAPI spec the responses are validated against
+	openAPISpec interface{}
+	// workspace opened with "buzz open FILE"
+	workspace     *config.Workspace
+	workspacePath string
+	// reachability of the host of the URL view and the view focused at the
+	// last layout, the host is checked when the URL view loses the focus
+	hostCheck   *hostCheck
+	focusedView string
+	// notes of the request being edited and the history entry displayed
+	// when they were opened
+	notes      string
+	notesEntry *Request
+	// workspace group of the loaded request
+	group string
+	// base URLs the groups of the workspace are switched to
+	groupBaseURLs map[string]string
+	environment   string
+	apiKey        *config.APIKey // API key of the environment
+	// credentials removed from the URL, indexed by host, the requests read
+	// them in the background
+	credentials   map[string]*url.Userinfo
+	credentialsMu sync.Mutex
+	// config file the changed keybindings are saved to
+	configPath string
+	// general options of the config file, those of the reloaded file which
+	// did not change keep their command line or toggled value
+	fileOptions config.GeneralOptions
+	// bindings listed by the keybinding editor and the one being changed
+	keyBindingList   []keyBinding
+	editedKeyBinding *keyBinding
+	// text the help is filtered by
+	helpFilter string
+	// WSDL the SOAP operations were listed from
+	wsdlLocation   string
+	wsdlOperations []soapOperation
+	// terminal size of the last layout and position of the current popup,
+	// used to redraw them once the terminal is resized
+	terminalSize  [2]int
+	popupPosition popupPosition
 }
 
 var METHODS = []string{
@@ -96,9 +247,9 @@ var CLIENT = &http.Client{
 	Timeout: time.Duration(TIMEOUT_DURATION * time.Second),
 }
 
-var TRANSPORT = &http.Transport{
+var TRANSPORT = newSharedTransport(&http.Transport{
 	Proxy: http.ProxyFromEnvironment,
-}
+})
 
 var TLS_VERSIONS = map[string]uint16{
 	"TLS1.0": tls.VersionTLS10,
@@ -108,150 +259,186 @@ var TLS_VERSIONS = map[string]uint16{
 }
 
 func init() {
-	TRANSPORT.DisableCompression = true
-	CLIENT.Transport = TRANSPORT
+	TRANSPORT.Load().DisableCompression = true
+	CLIENT.Transport = newCacheTransport(&wireLogTransport{&offlineTransport{&rateLimitTransport{&optionsTransport{TRANSPORT}}}})
 }
 
 func (a *App) SubmitRequest(g *gocui.Gui, _ *gocui.View) error {
-	vrb, _ := g.View(RESPONSE_BODY_VIEW)
-	vrb.Clear()
-	vrh, _ := g.View(RESPONSE_HEADERS_VIEW)
-	vrh.Clear()
-	popup(g, "Sending request..")
+	if a.currentPopup == RAW_REQUEST_VIEW {
+		return a.submitRawRequest(g)
+	}
+	if u, found := socketURL(strings.TrimSpace(getViewValue(g, URL_VIEW))); found {
+		return a.sendSocket(g, u, getViewValue(g, REQUEST_DATA_VIEW))
+	}
+	a.rememberURLCredentials(g)
+	method := getViewValue(g, REQUEST_METHOD_VIEW)
+	if methodHasBody(method) || getViewValue(g, REQUEST_DATA_VIEW) == "" {
+		return a.submitWithContentType(g, false)
+	}
+	if a.config.General.BodyOnAnyMethod {
+		return a.submitWithContentType(g, true)
+	}
+	return a.OpenConfirmView(fmt.Sprintf("Send the request body with %v?", method), g,
+		func(g *gocui.Gui, yes bool) error {
+			return a.submitWithContentType(g, yes)
+		})
+}
 
-	var r *Request = &Request{}
+// submitWithContentType sends the request, the body as well with methods
+// not expecting one if sendBody is set, after asking for the content type
+// of a body without Content-Type header
+func (a *App) submitWithContentType(g *gocui.Gui, sendBody bool) error {
+	submit := func(g *gocui.Gui) error {
+		variables, apiKey := copyVariables(a.variables), a.apiKey
+		return a.sendRequest(g, func(r *Request, conf *config.Config) (*http.Request, error) {
+			r.SendBody = sendBody
+			return a.buildRequest(g, r, variables, apiKey, conf)
+		})
+	}
+	contentType := a.missingContentType(g, sendBody)
+	if contentType == "" {
+		return submit(g)
+	}
+	if a.config.General.AutoContentType {
+		a.addContentTypeHeader(g, contentType)
+		return submit(g)
+	}
+	return a.OpenConfirmView(fmt.Sprintf("No Content-Type header is set, send the body as %v?", contentType), g,
+		func(g *gocui.Gui, yes bool) error {
+			if yes {
+				a.addContentTypeHeader(g, contentType)
+			}
+			return submit(g)
+		})
+}
 
-	go func(g *gocui.Gui, a *App, r *Request) error {
-		defer g.DeleteView(POPUP_VIEW)
-		// parse url
-		r.Url = getViewValue(g, URL_VIEW)
-		u, err := url.Parse(r.Url)
-		if err != nil {
-			g.Update(func(g *gocui.Gui) error {
-				vrb, _ := g.View(RESPONSE_BODY_VIEW)
-				fmt.Fprintf(vrb, "URL parse error: %v", err)
-				return nil
-			})
-			return nil
+// methodHasBody reports whether the request body is sent with method
+// without asking
+func methodHasBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// missingContentType returns the content type detected from the request
+// body if the request headers do not set one. The body of methods not
+// expecting one is only considered if sendBody is set.
+func (a *App) missingContentType(g *gocui.Gui, sendBody bool) string {
+	if !sendBody && !methodHasBody(getViewValue(g, REQUEST_METHOD_VIEW)) {
+		return ""
+	}
+	data := getViewValue(g, REQUEST_DATA_VIEW)
+	if _, found := bodyFilePath(data); found || a.hasHeader(g, "Content-Type") {
+		return ""
+	}
+	return detectContentType(data)
+}
+
+func (a *App) addContentTypeHeader(g *gocui.Gui, contentType string) {
+	v, _ := g.View(REQUEST_HEADERS_VIEW)
+	setViewTextAndCursor(v, addHeaderLine(getViewValue(g, REQUEST_HEADERS_VIEW), "Content-Type: "+contentType))
+}
+
+// ForceRefresh sends the request bypassing the response cache
+func (a *App) ForceRefresh(g *gocui.Gui, _ *gocui.View) error {
+	a.rememberURLCredentials(g)
+	variables, apiKey := copyVariables(a.variables), a.apiKey
+	return a.sendRequest(g, func(r *Request, conf *config.Config) (*http.Request, error) {
+		req, err := a.buildRequest(g, r, variables, apiKey, conf)
+		if err == nil {
+			req.Header.Set("Cache-Control", "no-cache")
 		}
+		return req, err
+	})
+}
 
-		q, err := url.ParseQuery(strings.Replace(getViewValue(g, URL_PARAMS_VIEW), "\n", "&", -1))
-		if err != nil {
-			g.Update(func(g *gocui.Gui) error {
-				vrb, _ := g.View(RESPONSE_BODY_VIEW)
-				fmt.Fprintf(vrb, "Invalid GET parameters: %v", err)
+// startSubmission cancels the request in progress and returns the context
+// of the new one, cancelled by the next submission, and render which updates
+// the views unless another request was submitted since. It runs on the UI
+// goroutine.
+func (a *App) startSubmission(g *gocui.Gui) (context.Context, context.CancelFunc, func(update func(g *gocui.Gui) error)) {
+	if a.cancelFormat != nil {
+		// do not display the previous response once formatted
+		a.cancelFormat()
+	}
+	if a.cancelSubmission != nil {
+		a.cancelSubmission()
+	}
+	a.submission++
+	submission := a.submission
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancelSubmission = cancel
+	render := func(update func(g *gocui.Gui) error) {
+		g.Update(func(g *gocui.Gui) error {
+			if a.submission != submission {
 				return nil
-			})
-			return nil
-		}
-		originalQuery := u.Query()
-		for k, v := range q {
-			for _, qp := range v {
-				originalQuery.Add(k, qp)
-			}
-		}
-		u.RawQuery = originalQuery.Encode()
-		r.GetParams = u.RawQuery
-
-		// parse method
-		r.Method = getViewValue(g, REQUEST_METHOD_VIEW)
-
-		// set headers
-		headers := http.Header{}
-		headers.Set("User-Agent", "")
-		r.Headers = getViewValue(g, REQUEST_HEADERS_VIEW)
-		for _, header := range strings.Split(r.Headers, "\n") {
-			if header != "" {
-				header_parts := strings.SplitN(header, ": ", 2)
-				if len(header_parts) != 2 {
-					g.Update(func(g *gocui.Gui) error {
-						vrb, _ := g.View(RESPONSE_BODY_VIEW)
-						fmt.Fprintf(vrb, "Invalid header: %v", header)
-						return nil
-					})
-					return nil
-				}
-				headers.Set(header_parts[0], header_parts[1])
 			}
-		}
+			return update(g)
+		})
+	}
+	// the previous response is displayed until replaced, for comparison
+	markResponseStale(g)
+	return ctx, cancel, render
+}
 
-		var body io.Reader
+// sendRequest performs the request created by build in the background and
+// renders the response when it arrives. A request still in progress is
+// cancelled, only the response of the latest request is rendered. The
+// request uses the config and the OpenAPI spec of the time it is sent, a
+// reload or a toggle does not change them meanwhile.
+func (a *App) sendRequest(g *gocui.Gui, build func(r *Request, conf *config.Config) (*http.Request, error)) error {
+	ctx, cancel, render := a.startSubmission(g)
+	progress := newRequestProgress()
+	go progress.run(g)
+	conf, spec := *a.config, a.openAPISpec
 
-		// parse POST/PUT/PATCH data
-		if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
-			bodyStr := getViewValue(g, REQUEST_DATA_VIEW)
-			r.Data = bodyStr
-			if headers.Get("Content-Type") != "multipart/form-data" {
-				if headers.Get("Content-Type") == "application/x-www-form-urlencoded" {
-					bodyStr = strings.Replace(bodyStr, "\n", "&", -1)
-				}
-				body = bytes.NewBufferString(bodyStr)
-			} else {
-				var bodyBytes bytes.Buffer
-				multiWriter := multipart.NewWriter(&bodyBytes)
-				defer multiWriter.Close()
-				postData, err := url.ParseQuery(strings.Replace(getViewValue(g, REQUEST_DATA_VIEW), "\n", "&", -1))
-				if err != nil {
-					return err
-				}
-				for postKey, postValues := range postData {
-					for i := range postValues {
-						if len([]rune(postValues[i])) > 0 && postValues[i][0] == '@' {
-							file, err := os.Open(postValues[i][1:])
-							if err != nil {
-								g.Update(func(g *gocui.Gui) error {
-									vrb, _ := g.View(RESPONSE_BODY_VIEW)
-									fmt.Fprintf(vrb, "Error: %v", err)
-									return nil
-								})
-								return err
-							}
-							defer file.Close()
-							fw, err := multiWriter.CreateFormFile(postKey, path.Base(postValues[i][1:]))
-							if err != nil {
-								return err
-							}
-							if _, err := io.Copy(fw, file); err != nil {
-								return err
-							}
-						} else {
-							fw, err := multiWriter.CreateFormField(postKey)
-							if err != nil {
-								return err
-							}
-							if _, err := fw.Write([]byte(postValues[i])); err != nil {
-								return err
-							}
-						}
-					}
-				}
-				body = bytes.NewReader(bodyBytes.Bytes())
-			}
-		}
+	var r *Request = &Request{}
+
+	go func(g *gocui.Gui, a *App, r *Request) error {
+		defer progress.stop(g)
+		defer cancel()
 
-		// create request
-		req, err := http.NewRequest(r.Method, u.String(), body)
+		req, err := build(r, &conf)
+		if err == nil && r.RawRequest == "" {
+			err = finalizeRequest(r, req, &conf)
+		}
 		if err != nil {
-			g.Update(func(g *gocui.Gui) error {
+			LOGGER.Error("invalid request", "error", err)
+			render(func(g *gocui.Gui) error {
+				a.clearStaleResponse(g)
 				vrb, _ := g.View(RESPONSE_BODY_VIEW)
-				fmt.Fprintf(vrb, "Request error: %v", err)
+				fmt.Fprint(vrb, err)
 				return nil
 			})
 			return nil
 		}
-		req.Header = headers
-
-		// set the `Host` header
-		if headers.Get("Host") != "" {
-			req.Host = headers.Get("Host")
+		req = req.WithContext(httptrace.WithClientTrace(ctx, progress.trace()))
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), r.connectionTrace()))
+		if conf.General.FreshConnect {
+			TRANSPORT.CloseIdleConnections()
+		}
+		if conf.General.Cache {
+			req = withCacheStatus(req, &r.CacheStatus)
 		}
+		req = withOfflineStatus(req, &r.Offline)
+		req = withTransportOptions(req, r.Transport)
 
 		// do request
-		start := time.Now()
-		response, err := CLIENT.Do(req)
-		r.Duration = time.Since(start)
+		r.Time = time.Now()
+		var response *http.Response
+		if r.RawRequest != "" {
+			transport := &wireLogTransport{&offlineTransport{&rateLimitTransport{&rawTransport{normalizeRawRequest(r.RawRequest)}}}}
+			response, err = transport.RoundTrip(req)
+		} else {
+			response, err = CLIENT.Do(req)
+		}
+		r.Duration = time.Since(r.Time)
+		if err != nil && ctx.Err() != nil {
+			LOGGER.Info("request cancelled by a newer one", "method", req.Method, "url", req.URL.String())
+			return nil
+		}
 		if err != nil {
-			g.Update(func(g *gocui.Gui) error {
+			LOGGER.Error("request failed", "method", req.Method, "url", req.URL.String(), "duration_ms", r.Duration.Milliseconds(), "error", err)
+			render(func(g *gocui.Gui) error {
+				a.clearStaleResponse(g)
 				vrb, _ := g.View(RESPONSE_BODY_VIEW)
 				fmt.Fprintf(vrb, "Response error: %v", err)
 				return nil
@@ -261,14 +448,34 @@ func (a *App) SubmitRequest(g *gocui.Gui, _ *gocui.View) error {
 		defer response.Body.Close()
 
 		// extract body
+		r.RequestHeader = req.Header
+		if r.UserinfoAuth {
+			// keep the password of the URL out of the history and exports
+			r.RequestHeader = req.Header.Clone()
+			r.RequestHeader.Set("Authorization", "Basic [hidden]")
+		}
+		r.Proto = response.Proto
+		r.ResponseHeader = response.Header
+		r.StatusCode = response.StatusCode
+		if response.TLS != nil {
+			r.TLSVersion = response.TLS.Version
+			if len(response.TLS.PeerCertificates) > 0 {
+				r.CertExpiry = response.TLS.PeerCertificates[0].NotAfter
+			}
+			r.ALPN = response.TLS.NegotiatedProtocol
+		}
 		r.ContentType = response.Header.Get("Content-Type")
+		transferred := &countingReader{Reader: response.Body}
+		var bodyReader io.Reader = transferred
 		if response.Header.Get("Content-Encoding") == "gzip" {
-			reader, err := gzip.NewReader(response.Body)
+			reader, err := gzip.NewReader(transferred)
 			if err == nil {
 				defer reader.Close()
-				response.Body = reader
+				bodyReader = reader
 			} else {
-				g.Update(func(g *gocui.Gui) error {
+				LOGGER.Error("cannot uncompress response", "url", req.URL.String(), "error", err)
+				render(func(g *gocui.Gui) error {
+					a.clearStaleResponse(g)
 					vrb, _ := g.View(RESPONSE_BODY_VIEW)
 					fmt.Fprintf(vrb, "Cannot uncompress response: %v", err)
 					return nil
@@ -277,50 +484,74 @@ func (a *App) SubmitRequest(g *gocui.Gui, _ *gocui.View) error {
 			}
 		}
 
-		bodyBytes, err := io.ReadAll(response.Body)
+		downloadStart := time.Now()
+		bodyBytes, err := io.ReadAll(bodyReader)
+		r.DownloadDuration = time.Since(downloadStart)
+		r.TransferSize = transferred.n
+		r.ResponseTrailer = response.Trailer
+		if ctx.Err() != nil {
+			LOGGER.Info("request cancelled by a newer one", "method", req.Method, "url", req.URL.String())
+			return nil
+		}
 		if err == nil {
 			r.RawResponseBody = bodyBytes
+		} else {
+			LOGGER.Error("cannot read response body", "url", req.URL.String(), "error", err)
 		}
 
-		r.Formatter = formatter.New(a.config, r.ContentType)
+		r.Formatter = formatter.NewForBody(&conf, r.ContentType, r.RawResponseBody)
+		if r.Schema != "" {
+			checkSchema(r)
+		}
+		checkOpenAPI(spec, r)
+		if token, found := extractCSRFToken(conf.CSRF, r); found {
+			CSRF_TOKENS.set(req.URL.Host, token)
+		}
 
-		// add to history
-		a.history = append(a.history, r)
-		a.historyIndex = len(a.history) - 1
+		logResponse(r)
+		if r.SchemaError != nil {
+			LOGGER.Error("schema validation failed", "schema", r.Schema, "error", r.SchemaError)
+		}
+		if r.ContractError != nil {
+			LOGGER.Error("OpenAPI validation failed", "spec", conf.General.OpenAPISpec, "error", r.ContractError)
+		}
 
-		// render response
-		g.Update(func(g *gocui.Gui) error {
-			vrh, _ := g.View(RESPONSE_HEADERS_VIEW)
+		if conf.General.AutoSave {
+			if err := autoSaveResponse(r, conf.General.AutoSaveDirectory); err != nil {
+				LOGGER.Error("auto save failed", "error", err)
+				g.Update(func(g *gocui.Gui) error {
+					return a.OpenMessageView("Auto save error: "+err.Error(), g)
+				})
+			}
+		}
 
-			a.PrintBody(g)
+		// render response
+		render(func(g *gocui.Gui) error {
+			a.addToHistory(r)
+			a.postResponseHook(g, r)
 
-			// print status code
-			status_color := 32
-			if response.StatusCode != 200 {
-				status_color = 31
+			if r.RawResponseBody == nil {
+				a.clearStaleResponse(g)
+			} else {
+				// PrintBody replaces the body once formatted
+				a.clearStaleHeaders(g)
 			}
-			header := &strings.Builder{}
-			fmt.Fprintf(
-				header,
-				"\x1b[0;%dmHTTP/1.1 %v %v\x1b[0;0m\n",
-				status_color,
-				response.StatusCode,
-				http.StatusText(response.StatusCode),
-			)
+			vrh, _ := g.View(RESPONSE_HEADERS_VIEW)
 
-			writeSortedHeaders(header, response.Header)
+			a.resetBodyLimit()
 
-			// According to the Go documentation, the Trailer maps trailer
-			// keys to values in the same format as Header
-			writeSortedHeaders(header, response.Trailer)
+			a.PrintBody(g)
 
-			r.ResponseHeaders = header.String()
+			r.ResponseHeaders = formatResponseHeaders(r, response)
 
-			fmt.Fprint(vrh, r.ResponseHeaders)
+			fmt.Fprint(vrh, a.responseHeadersText(r))
 			if _, err := vrh.Line(0); err != nil {
 				vrh.SetOrigin(0, 0)
 			}
 
+			if report := schemaReport(r); report != "" {
+				return a.OpenMessageView(report, g)
+			}
 			return nil
 		})
 		return nil
@@ -329,6 +560,282 @@ func (a *App) SubmitRequest(g *gocui.Gui, _ *gocui.View) error {
 	return nil
 }
 
+// formatResponseHeaders renders the connection details of r, the status
+// line, the headers and the trailers of response for the response headers
+// view
+func formatResponseHeaders(r *Request, response *http.Response) string {
+	// print status code
+	status_color := 32
+	if response.StatusCode != 200 {
+		status_color = 31
+	}
+	proto := response.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	header := &strings.Builder{}
+	writeConnectionInfo(header, r)
+	fmt.Fprintf(
+		header,
+		"\x1b[0;%dm%v %v %v\x1b[0;0m\n",
+		status_color,
+		proto,
+		response.StatusCode,
+		http.StatusText(response.StatusCode),
+	)
+
+	writeSortedHeaders(header, response.Header)
+
+	// According to the Go documentation, the Trailer maps trailer
+	// keys to values in the same format as Header
+	if hasHeaderValues(response.Trailer) {
+		fmt.Fprint(header, "\n\x1b[0;36mTrailers:\x1b[0;0m\n")
+		writeSortedHeaders(header, response.Trailer)
+	}
+
+	return header.String()
+}
+
+// hasHeaderValues reports whether h contains at least one value, announced
+// trailers are present without value until they are received
+func hasHeaderValues(h http.Header) bool {
+	for _, values := range h {
+		if len(values) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRequest creates the HTTP request from the content of the request
+// views with the variables and the API key of the environment and records
+// the used values in r. The requests built in the background are given a
+// copy of the variables of the app and the config they are sent with.
+func (a *App) buildRequest(g *gocui.Gui, r *Request, variables map[string]string, apiKey *config.APIKey, conf *config.Config) (*http.Request, error) {
+	r.Notes = a.notes
+	a.fillFromViews(g, r)
+	return a.newEnvironmentRequest(r, variables, apiKey, conf)
+}
+
+// fillFromViews sets the fields of r to the content of the request views,
+// it runs on the UI goroutine
+func (a *App) fillFromViews(g *gocui.Gui, r *Request) {
+	r.Url = getViewValue(g, URL_VIEW)
+	r.GetParams = getViewValue(g, URL_PARAMS_VIEW)
+	r.Method = getViewValue(g, REQUEST_METHOD_VIEW)
+	r.Headers = getViewValue(g, REQUEST_HEADERS_VIEW)
+	r.Data = getViewValue(g, REQUEST_DATA_VIEW)
+	r.Auth = getViewValue(g, AUTH_VIEW)
+	r.Schema = a.schema
+	r.Transport = a.transport
+}
+
+// newEnvironmentRequest creates the HTTP request described by r with the
+// variables and the API key of an environment
+func (a *App) newEnvironmentRequest(r *Request, variables map[string]string, apiKey *config.APIKey, conf *config.Config) (*http.Request, error) {
+	r.SendBody = r.SendBody || conf.General.BodyOnAnyMethod
+	for _, field := range []*string{&r.Url, &r.GetParams, &r.Headers, &r.Data, &r.Auth} {
+		expanded, err := expandDynamicVariables(expandVariables(*field, variables))
+		if err != nil {
+			return nil, err
+		}
+		*field = expanded
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		return nil, err
+	}
+	if err := addAPIKey(req, apiKey, variables); err != nil {
+		return nil, err
+	}
+	return req, a.addConfigHeaders(r, req, conf)
+}
+
+// finalizeRequest compresses the body of req, then computes the digest
+// headers and the signature of the final body
+func finalizeRequest(r *Request, req *http.Request, conf *config.Config) error {
+	if conf.General.GzipRequestBody {
+		if err := gzipRequestBody(req); err != nil {
+			return err
+		}
+	}
+	if err := addDigestHeaders(req); err != nil {
+		return err
+	}
+	return signRequest(r, req, conf)
+}
+
+// rememberCredentials removes the credentials of u, they are used by the
+// next requests sent to its host
+func (a *App) rememberCredentials(u *url.URL) bool {
+	if u.User == nil {
+		return false
+	}
+	a.credentialsMu.Lock()
+	defer a.credentialsMu.Unlock()
+	if a.credentials == nil {
+		a.credentials = make(map[string]*url.Userinfo)
+	}
+	a.credentials[u.Host] = u.User
+	u.User = nil
+	return true
+}
+
+// rememberURLCredentials removes the credentials of the URL view and
+// remembers them. It runs on the UI goroutine, before the request is built.
+func (a *App) rememberURLCredentials(g *gocui.Gui) {
+	u, err := url.Parse(getViewValue(g, URL_VIEW))
+	if err != nil || !a.rememberCredentials(u) {
+		return
+	}
+	vurl, _ := g.View(URL_VIEW)
+	setViewTextAndCursor(vurl, u.String())
+}
+
+// urlCredentials returns the remembered credentials of host
+func (a *App) urlCredentials(host string) (*url.Userinfo, bool) {
+	a.credentialsMu.Lock()
+	defer a.credentialsMu.Unlock()
+	userinfo, found := a.credentials[host]
+	return userinfo, found
+}
+
+// addConfigHeaders adds the remembered URL credentials, the credentials of
+// the auth view, the CSRF token, the default headers and the .netrc
+// credentials configured for req
+func (a *App) addConfigHeaders(r *Request, req *http.Request, conf *config.Config) error {
+	if userinfo, found := a.urlCredentials(req.URL.Host); found && req.Header.Get("Authorization") == "" {
+		password, _ := userinfo.Password()
+		req.SetBasicAuth(userinfo.Username(), password)
+		r.UserinfoAuth = true
+	}
+	if err := a.addAuth(r, req); err != nil {
+		return err
+	}
+	addCSRFToken(conf.CSRF, req)
+	addDefaultHeaders(conf, req)
+	return addNetrcCredentials(conf, req)
+}
+
+// newHTTPRequest creates the HTTP request described by r. The query of the
+// URL is moved to GetParams, so that sending the result again is identical.
+func (r *Request) newHTTPRequest() (*http.Request, error) {
+	// parse url
+	u, err := url.Parse(r.Url)
+	if err != nil {
+		return nil, fmt.Errorf("URL parse error: %v", err)
+	}
+
+	q, err := url.ParseQuery(strings.Replace(r.GetParams, "\n", "&", -1))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid GET parameters: %v", err)
+	}
+	originalQuery := u.Query()
+	for k, v := range q {
+		for _, qp := range v {
+			originalQuery.Add(k, qp)
+		}
+	}
+	u.RawQuery = ""
+	userinfo := u.User
+	u.User = nil
+	r.Url = u.String()
+	u.RawQuery = originalQuery.Encode()
+	r.GetParams = u.RawQuery
+
+	// set headers
+	headersText, trailersText := splitTrailers(r.Headers)
+	headers, err := parseRequestHeaders(headersText)
+	if err != nil {
+		return nil, err
+	}
+	trailers, err := parseRequestHeaders(trailersText)
+	if err != nil {
+		return nil, err
+	}
+	trailers.Del("User-Agent")
+
+	var body io.Reader
+	var contentLength int64
+	chunked := isChunked(headers.Get("Transfer-Encoding"))
+	headers.Del("Transfer-Encoding")
+
+	// parse POST/PUT/PATCH data, and the data of other methods if asked
+	if methodHasBody(r.Method) || r.SendBody {
+		bodyStr := r.Data
+		if path, found := bodyFilePath(bodyStr); found && chunked {
+			// stream the file without buffering it
+			file, err := openBodyFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("Error: %v", err)
+			}
+			body = file
+		} else if found && headers.Get("Content-Type") != config.ContentTypes["multipart"] {
+			// send the file as it is
+			body, contentLength, err = readBodyFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("Error: %v", err)
+			}
+		} else if headers.Get("Content-Type") != config.ContentTypes["multipart"] {
+			if headers.Get("Content-Type") == "application/x-www-form-urlencoded" {
+				bodyStr = strings.Replace(bodyStr, "\n", "&", -1)
+			}
+			body = bytes.NewBufferString(bodyStr)
+		} else {
+			parts, err := parseMultipartParts(bodyStr)
+			if err != nil {
+				return nil, err
+			}
+			var contentType string
+			body, contentType, contentLength, err = newMultipartBody(parts)
+			if err != nil {
+				return nil, fmt.Errorf("Error: %v", err)
+			}
+			headers.Set("Content-Type", contentType)
+		}
+	} else {
+		r.Data = ""
+	}
+
+	// trailers are only sent with chunked bodies, hide the length of the
+	// body to enforce chunked transfer encoding
+	if len(trailers) > 0 {
+		if body == nil {
+			body = strings.NewReader("")
+		}
+		if _, streamed := body.(io.ReadCloser); !streamed {
+			body = struct{ io.Reader }{body}
+		}
+	}
+
+	// create request
+	req, err := http.NewRequest(r.Method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("Request error: %v", err)
+	}
+	req.Header = headers
+	if userinfo != nil && headers.Get("Authorization") == "" {
+		password, _ := userinfo.Password()
+		req.SetBasicAuth(userinfo.Username(), password)
+		r.UserinfoAuth = true
+	}
+	if len(trailers) > 0 {
+		req.Trailer = trailers
+	}
+	if chunked && body != nil {
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+	} else if contentLength > 0 {
+		req.ContentLength = contentLength
+	}
+
+	// set the `Host` header
+	if headers.Get("Host") != "" {
+		req.Host = headers.Get("Host")
+	}
+	return req, nil
+}
+
 func (a *App) LoadRequest(g *gocui.Gui, loadLocation string) (err error) {
 	requestJson, ioErr := os.ReadFile(loadLocation)
 	if ioErr != nil {
@@ -353,6 +860,18 @@ func (a *App) LoadRequest(g *gocui.Gui, loadLocation string) (err error) {
 		return nil
 	}
 
+	if placeholders := findPlaceholders(requestMap); len(placeholders) > 0 {
+		return a.OpenTemplateForm(g, placeholders, func(g *gocui.Gui, values map[string]string) {
+			fillPlaceholders(requestMap, values)
+			a.setRequestViews(g, requestMap)
+		})
+	}
+	a.setRequestViews(g, requestMap)
+	return nil
+}
+
+// setRequestViews sets the content of the request views found in requestMap
+func (a *App) setRequestViews(g *gocui.Gui, requestMap map[string]string) {
 	var v *gocui.View
 	url, exists := requestMap[URL_VIEW]
 	if exists {
@@ -387,7 +906,17 @@ func (a *App) LoadRequest(g *gocui.Gui, loadLocation string) (err error) {
 		v, _ = g.View(REQUEST_HEADERS_VIEW)
 		setViewTextAndCursor(v, headers)
 	}
-	return nil
+
+	v, _ = g.View(AUTH_VIEW)
+	if auth, exists := requestMap[AUTH_VIEW]; exists {
+		setViewTextAndCursor(v, auth)
+	} else {
+		setViewTextAndCursor(v, DEFAULT_AUTH)
+	}
+	a.schema = requestMap[SCHEMA_VIEW]
+	a.notes = requestMap[NOTES_VIEW]
+	a.transport, _ = parseTransportOptions(requestMap[TRANSPORT_OPTIONS_VIEW])
+	a.group = requestMap[WORKSPACE_GROUP_KEY]
 }
 
 func (a *App) LoadConfig(configPath string) error {
@@ -395,6 +924,7 @@ func (a *App) LoadConfig(configPath string) error {
 		// Load config from default path
 		configPath, _ = config.GetDefaultConfigLocation()
 	}
+	a.configPath = configPath
 
 	// If the config file doesn't exist, load the default config
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -481,6 +1011,19 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 			set_data = true
 			vdata, _ := g.View(REQUEST_DATA_VIEW)
 			setViewTextAndCursor(vdata, json_str)
+		case "--upload-file":
+			if arg_index == args_len-1 {
+				return errors.New("no file to upload specified")
+			}
+			arg_index++
+			set_data = true
+			body_data = append(body_data, "@"+args[arg_index])
+			fmt.Fprintln(vheader, "Transfer-Encoding: chunked")
+			if !set_method {
+				set_method = true
+				vmethod, _ := g.View(REQUEST_METHOD_VIEW)
+				setViewTextAndCursor(vmethod, http.MethodPut)
+			}
 		case "-X", "--request":
 			if arg_index == args_len-1 {
 				return errors.New("no HTTP method specified")
@@ -488,70 +1031,16 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 			arg_index++
 			set_method = true
 			method := args[arg_index]
-			if content_type == "" && (method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch) {
+			if content_type == "" && methodHasBody(method) {
 				content_type = "form"
 			}
 			vmethod, _ := g.View(REQUEST_METHOD_VIEW)
 			setViewTextAndCursor(vmethod, method)
-		case "-t", "--timeout":
-			if arg_index == args_len-1 {
-				return errors.New("no timeout value specified")
-			}
-			arg_index += 1
-			timeout, err := strconv.Atoi(args[arg_index])
-			if err != nil || timeout <= 0 {
-				return errors.New("invalid timeout value")
-			}
-			a.config.General.Timeout = config.Duration{Duration: time.Duration(timeout) * time.Millisecond}
 		case "--compressed":
 			vh, _ := g.View(REQUEST_HEADERS_VIEW)
 			if !strings.Contains(getViewValue(g, REQUEST_HEADERS_VIEW), "Accept-Encoding") {
 				fmt.Fprintln(vh, "Accept-Encoding: gzip, deflate")
 			}
-		case "-e", "--editor":
-			if arg_index == args_len-1 {
-				return errors.New("no timeout value specified")
-			}
-			arg_index += 1
-			a.config.General.Editor = args[arg_index]
-		case "-k", "--insecure":
-			a.config.General.Insecure = true
-		case "-R", "--disable-redirects":
-			a.config.General.FollowRedirects = false
-		case "--tlsv1.0":
-			a.config.General.TLSVersionMin = tls.VersionTLS10
-			a.config.General.TLSVersionMax = tls.VersionTLS10
-		case "--tlsv1.1":
-			a.config.General.TLSVersionMin = tls.VersionTLS11
-			a.config.General.TLSVersionMax = tls.VersionTLS11
-		case "--tlsv1.2":
-			a.config.General.TLSVersionMin = tls.VersionTLS12
-			a.config.General.TLSVersionMax = tls.VersionTLS12
-		case "--tlsv1.3":
-			a.config.General.TLSVersionMin = tls.VersionTLS13
-			a.config.General.TLSVersionMax = tls.VersionTLS13
-		case "-T", "--tls":
-			if arg_index >= args_len-1 {
-				return errors.New("missing TLS version range: MIN,MAX")
-			}
-			arg_index++
-			arg := args[arg_index]
-			v := strings.Split(arg, ",")
-			min := v[0]
-			max := min
-			if len(v) > 1 {
-				max = v[1]
-			}
-			minV, minFound := TLS_VERSIONS[min]
-			if !minFound {
-				return errors.New("Minimum TLS version not found: " + min)
-			}
-			maxV, maxFound := TLS_VERSIONS[max]
-			if !maxFound {
-				return errors.New("Maximum TLS version not found: " + max)
-			}
-			a.config.General.TLSVersionMin = minV
-			a.config.General.TLSVersionMax = maxV
 		case "-x", "--proxy":
 			if arg_index == args_len-1 {
 				return errors.New("missing proxy URL")
@@ -561,17 +1050,19 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 			if err != nil {
 				return fmt.Errorf("invalid proxy URL: %v", err)
 			}
+			a.proxyURL = u.Redacted()
 			switch u.Scheme {
 			case "", "http", "https":
-				TRANSPORT.Proxy = http.ProxyURL(u)
+				TRANSPORT.Load().Proxy = http.ProxyURL(u)
 			case "socks5h", "socks5":
 				dialer, err := proxy.FromURL(u, proxy.Direct)
 				if err != nil {
 					return fmt.Errorf("can't connect to proxy: %v", err)
 				}
-				TRANSPORT.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				TRANSPORT.Load().DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
 					return dialer.Dial(network, addr)
 				}
+				a.socksProxy = true
 			default:
 				return errors.New("unknown proxy protocol")
 			}
@@ -593,7 +1084,21 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 			arg_index += 1
 			loadLocation := args[arg_index]
 			a.LoadRequest(g, loadLocation)
+		case "--session":
+			if arg_index == args_len-1 {
+				return errors.New("--session requires a file path be provided as an argument")
+			}
+			arg_index += 1
+			if err := a.LoadSession(g, args[arg_index]); err != nil {
+				return fmt.Errorf("session: %v", err)
+			}
 		default:
+			if last, found, err := parseConfigArg(&a.config.General, args, arg_index); err != nil {
+				return err
+			} else if found {
+				arg_index = last
+				break
+			}
 			u := args[arg_index]
 			if strings.Index(u, "http://") != 0 && strings.Index(u, "https://") != 0 {
 				u = fmt.Sprintf("%v://%v", a.config.General.DefaultURLScheme, u)
@@ -605,6 +1110,7 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 			if parsed_url.Path == "" {
 				parsed_url.Path = "/"
 			}
+			a.rememberCredentials(parsed_url)
 			vurl, _ := g.View(URL_VIEW)
 			vurl.Clear()
 			for k, v := range parsed_url.Query() {
@@ -631,14 +1137,12 @@ func (a *App) ParseArgs(g *gocui.Gui, args []string) error {
 		fmt.Fprintf(vheader, "Accept: %v\n", strings.Join(accept_types, ","))
 	}
 
-	var merged_body_data string
-	if set_data && !set_binary_data {
-		merged_body_data = strings.Join(body_data, "&")
+	// -j and -F set the data view directly
+	if len(body_data) > 0 {
+		vdata, _ := g.View(REQUEST_DATA_VIEW)
+		setViewTextAndCursor(vdata, strings.Join(body_data, "&"))
 	}
 
-	vdata, _ := g.View(REQUEST_DATA_VIEW)
-	setViewTextAndCursor(vdata, merged_body_data)
-
 	return nil
 }
 
@@ -647,25 +1151,152 @@ func (a *App) hasHeader(g *gocui.Gui, h string) bool {
 		if header == "" {
 			continue
 		}
-		header_parts := strings.SplitN(header, ": ", 2)
+		header_parts := strings.SplitN(header, ":", 2)
 		if len(header_parts) != 2 {
 			continue
 		}
-		if header_parts[0] == h {
+		if strings.EqualFold(strings.TrimSpace(header_parts[0]), h) {
 			return true
 		}
 	}
 	return false
 }
 
+// parseConfigArg applies the command line option args[arg_index] to the
+// config options. It returns the index of its last argument and false for
+// the other arguments.
+func parseConfigArg(conf *config.GeneralOptions, args []string, arg_index int) (int, bool, error) {
+	args_len := len(args)
+	switch args[arg_index] {
+	case "-t", "--timeout":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no timeout value specified")
+		}
+		arg_index += 1
+		timeout, err := strconv.Atoi(args[arg_index])
+		if err != nil || timeout <= 0 {
+			return arg_index, true, errors.New("invalid timeout value")
+		}
+		conf.Timeout = config.Duration{Duration: time.Duration(timeout) * time.Millisecond}
+	case "-e", "--editor":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no timeout value specified")
+		}
+		arg_index += 1
+		conf.Editor = args[arg_index]
+	case "-4", "--ipv4":
+		conf.IPVersion = 4
+	case "-6", "--ipv6":
+		conf.IPVersion = 6
+	case "--interface", "--local-addr":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no interface or local address specified")
+		}
+		arg_index += 1
+		conf.LocalAddr = args[arg_index]
+	case "--gzip-body":
+		conf.GzipRequestBody = true
+	case "--log-file":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no log file specified")
+		}
+		arg_index += 1
+		conf.LogFile = args[arg_index]
+	case "--no-keepalive":
+		conf.DisableKeepAlives = true
+	case "--netrc":
+		conf.Netrc = true
+	case "--netrc-file":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no netrc file specified")
+		}
+		arg_index += 1
+		conf.Netrc = true
+		conf.NetrcFile = args[arg_index]
+	case "--openapi":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no OpenAPI spec specified")
+		}
+		arg_index += 1
+		conf.OpenAPISpec = args[arg_index]
+	case "--fresh-connect":
+		conf.FreshConnect = true
+	case "--offline":
+		conf.Offline = true
+	case "--offline-har":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no HAR file specified")
+		}
+		arg_index += 1
+		conf.Offline = true
+		conf.OfflineHAR = args[arg_index]
+	case "--rate-limit":
+		if arg_index == args_len-1 {
+			return arg_index, true, errors.New("no rate limit specified")
+		}
+		arg_index += 1
+		rate, err := strconv.ParseFloat(args[arg_index], 64)
+		if err != nil || rate < 0 {
+			return arg_index, true, errors.New("invalid rate limit")
+		}
+		conf.RateLimit = rate
+	case "-k", "--insecure":
+		conf.Insecure = true
+	case "-R", "--disable-redirects":
+		conf.FollowRedirects = false
+	case "--tlsv1.0":
+		conf.TLSVersionMin = tls.VersionTLS10
+		conf.TLSVersionMax = tls.VersionTLS10
+	case "--tlsv1.1":
+		conf.TLSVersionMin = tls.VersionTLS11
+		conf.TLSVersionMax = tls.VersionTLS11
+	case "--tlsv1.2":
+		conf.TLSVersionMin = tls.VersionTLS12
+		conf.TLSVersionMax = tls.VersionTLS12
+	case "--tlsv1.3":
+		conf.TLSVersionMin = tls.VersionTLS13
+		conf.TLSVersionMax = tls.VersionTLS13
+	case "-T", "--tls":
+		if arg_index >= args_len-1 {
+			return arg_index, true, errors.New("missing TLS version range: MIN,MAX")
+		}
+		arg_index++
+		arg := args[arg_index]
+		v := strings.Split(arg, ",")
+		min := v[0]
+		max := min
+		if len(v) > 1 {
+			max = v[1]
+		}
+		minV, minFound := TLS_VERSIONS[min]
+		if !minFound {
+			return arg_index, true, errors.New("Minimum TLS version not found: " + min)
+		}
+		maxV, maxFound := TLS_VERSIONS[max]
+		if !maxFound {
+			return arg_index, true, errors.New("Maximum TLS version not found: " + max)
+		}
+		conf.TLSVersionMin = minV
+		conf.TLSVersionMax = maxV
+	default:
+		return arg_index, false, nil
+	}
+	return arg_index, true, nil
+}
+
 // Apply startup config values. This is run after a.ParseArgs, so that
 // args can override the provided config values
-func (a *App) InitConfig() {
-	CLIENT.Timeout = a.config.General.Timeout.Duration
-	TRANSPORT.TLSClientConfig = &tls.Config{
-		InsecureSkipVerify: a.config.General.Insecure,
-		MinVersion:         a.config.General.TLSVersionMin,
-		MaxVersion:         a.config.General.TLSVersionMax,
+func (a *App) InitConfig() error {
+	if err := a.applyClientConfig(); err != nil {
+		return err
+	}
+	if a.config.General.LogFile != "" {
+		if err := openLogFile(a.config.General.LogFile); err != nil {
+			return fmt.Errorf("log file: %v", err)
+		}
+	}
+	if a.config.General.FakeSeed != 0 {
+		RANDOM.seed(a.config.General.FakeSeed)
 	}
 	CLIENT.CheckRedirect = func(_ *http.Request, _ []*http.Request) error {
 		if a.config.General.FollowRedirects {
@@ -673,23 +1304,111 @@ func (a *App) InitConfig() {
 		}
 		return http.ErrUseLastResponse
 	}
+	return nil
+}
+
+// applyClientConfig applies the config values of the HTTP client, also
+// when the config is reloaded
+func (a *App) applyClientConfig() error {
+	CLIENT.Timeout = a.config.General.Timeout.Duration
+	RATE_LIMITER.setRate(a.config.General.RateLimit)
+	OFFLINE_REPLAY.setEnabled(a.config.General.Offline)
+	OFFLINE_REPLAY.setHAR(nil)
+	if a.config.General.OfflineHAR != "" {
+		responses, err := loadHAR(a.config.General.OfflineHAR)
+		if err != nil {
+			return fmt.Errorf("offline HAR: %v", err)
+		}
+		OFFLINE_REPLAY.setHAR(responses)
+	}
+	PINS.set(a.config.Pins)
+	var dialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	if a.socksProxy {
+		// SOCKS proxies set their own dialer
+		if a.config.General.IPVersion != 0 || a.config.General.LocalAddr != "" {
+			return errors.New("the IP version and the local address cannot be set with a SOCKS proxy")
+		}
+	} else {
+		var err error
+		if dialContext, err = newDialContext(a.config.General); err != nil {
+			return err
+		}
+	}
+	TRANSPORT.update(func(t *http.Transport) {
+		if dialContext != nil {
+			t.DialContext = dialContext
+		}
+		t.DisableKeepAlives = a.config.General.DisableKeepAlives
+		t.ExpectContinueTimeout = a.config.General.ExpectContinueTimeout.Duration
+		t.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: a.config.General.Insecure,
+			MinVersion:         a.config.General.TLSVersionMin,
+			MaxVersion:         a.config.General.TLSVersionMax,
+		}
+		if len(a.config.Pins) > 0 {
+			t.TLSClientConfig.VerifyConnection = verifyPins("")
+		}
+	})
+	a.openAPISpec = nil
+	if a.config.General.OpenAPISpec != "" {
+		spec, err := loadSchema(a.config.General.OpenAPISpec)
+		if err != nil {
+			return fmt.Errorf("OpenAPI spec: %v", err)
+		}
+		a.openAPISpec = spec
+	}
+	return nil
 }
 
 func help() {
 	fmt.Println(`buzz - Interactive cli tool for HTTP inspection
 
 Usage: buzz [-H|--header HEADER]... [-d|--data|--data-binary DATA] [-X|--request METHOD] [-t|--timeout MSECS] [URL]
+       buzz mock|listen|echo|capture [ADDR] [OPTIONS] [URL]
+       buzz open WORKSPACE [--env NAME] [OPTIONS] [URL]
+       buzz config [OPTIONS]
+
+Commands:
+  open WORKSPACE           Load the requests, variables and settings of a workspace file,
+                           --env selects one of its environments, ctrl+g lists its requests
+  mock [ADDR]              Serve the [[mock]] responses of the configuration file on ADDR
+                           (default: localhost:8080), received requests are shown by alt+l
+  listen [ADDR]            Accept every request on ADDR to inspect webhooks and callbacks, the
+                           [listen] section of the configuration file sets the response and
+                           an optional tunnel command
+  echo [ADDR]              Answer every request on ADDR with its method, headers and body as JSON
+  capture [ADDR]           Run a HTTP proxy on ADDR adding the proxied requests to the history,
+                           HTTPS connections are tunneled without being recorded
+  config                   Print the configuration resulting from the defaults, the configuration
+                           file and the options, and list its invalid values
 
 Other command line options:
+  -4, --ipv4               Only connect over IPv4
+  -6, --ipv6               Only connect over IPv6
   -c, --config PATH        Specify custom configuration file
   -e, --editor EDITOR      Specify external editor command
   -f, --file REQUEST       Load a previous request
   -F, --form DATA          Add multipart form request data and set related request headers
-                           If the value starts with @ it will be handled as a file path for upload
+                           If the value starts with @ it will be handled as a file path for upload,
+                           ;type=CONTENT-TYPE sets the content type of the part
+  --fresh-connect          Open a new connection for every request
+  --gzip-body              Compress the request bodies with gzip
   -h, --help               Show this
+  --log-file PATH          Append a JSON log of the requests, responses and errors to PATH
+  --netrc                  Send the credentials of ~/.netrc ($NETRC) to the matching hosts
+  --netrc-file PATH        Like --netrc with another file
+  --no-keepalive           Close the connection after every request
+  --offline                Replay the responses of the history instead of sending the requests
+  --offline-har PATH       Like --offline, replaying the responses of the HAR file PATH as well
+  --openapi SPEC           Validate the responses against the JSON OpenAPI 3 spec file or URL SPEC
+  --interface, --local-addr ADDR
+                           Connect from the IP address ADDR or from the address of the
+                           network interface ADDR
   -j, --json JSON          Add JSON request data and set related request headers
   -k, --insecure           Allow insecure SSL certs
   -R, --disable-redirects  Do not follow HTTP redirects
+  --rate-limit N           Send at most N requests per second, e.g. 0.5 for one every 2 seconds
+  --session PATH           Restore the session saved to PATH
   -T, --tls MIN,MAX        Restrict allowed TLS versions (values: TLS1.0,TLS1.1,TLS1.2,TLS1.3)
                            Examples: wuzz -T TLS1.1        (TLS1.1 only)
                                      wuzz -T TLS1.0,TLS1.1 (from TLS1.0 up to TLS1.1)
@@ -697,6 +1416,8 @@ Other command line options:
   --tlsv1.1                Forces TLS1.1 only
   --tlsv1.2                Forces TLS1.2 only
   --tlsv1.3                Forces TLS1.3 only
+  --upload-file PATH       Stream the file as request body with chunked transfer encoding,
+                           "-" reads the standard input
   -v, --version            Display version number
   -x, --proxy URL          Set HTTP(S) or SOCKS5 proxy
 
@@ -705,9 +1426,12 @@ Key bindings:
   ctrl+s              Save response
   ctrl+e              Save request
   ctrl+f              Load request
+  ctrl+p              Preview request
   tab, ctrl+j         Next window
   shift+tab, ctrl+k   Previous window
   alt+h               Show history
+  alt+l               Show requests received by the local server
+  alt+a               Switch between the request data and the auth view
   pageUp              Scroll up the current window
   pageDown            Scroll down the current window`,
 	)
@@ -732,8 +1456,15 @@ func main() {
 			}
 		}
 	}
+	if len(args) > 1 && args[1] == "config" {
+		os.Exit(runConfigCommand(os.Stdout, os.Stderr, configPath, args[2:]))
+	}
+	workspacePath, environment, args, err := parseWorkspaceCommand(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+	serverCommand, serverAddr, args := parseServerCommand(args)
 	var g *gocui.Gui
-	var err error
 	for _, outputMode := range []gocui.OutputMode{gocui.Output256, gocui.OutputNormal, gocui.OutputMode(termbox.OutputGrayscale)} {
 		g, err = gocui.NewGui(outputMode)
 		if err == nil {
@@ -765,16 +1496,51 @@ func main() {
 		g.Close()
 		log.Fatalf("Error loading config file: %v", err)
 	}
+	// the options of the file only, the workspace and the command line
+	// override them
+	app.fileOptions = app.config.General
+
+	if workspacePath != "" {
+		if err := app.openWorkspace(workspacePath, environment); err != nil {
+			g.Close()
+			log.Fatal(err)
+		}
+		g.Update(func(g *gocui.Gui) error {
+			return app.ToggleWorkspace(g, nil)
+		})
+	}
+
+	if serverCommand != "" {
+		err = app.startServer(g, serverCommand, serverAddr, SERVER_COMMANDS[serverCommand](app, g))
+		if err != nil {
+			g.Close()
+			fmt.Println("Error!", err)
+			os.Exit(1)
+		}
+		if serverCommand == "listen" && app.config.Listen.TunnelCommand != "" {
+			err = app.startTunnel(g, app.config.Listen.TunnelCommand, app.servers[0].addr)
+			if err != nil {
+				g.Close()
+				fmt.Println("Error!", err)
+				os.Exit(1)
+			}
+			defer app.stopTunnel()
+		}
+	}
 
 	err = app.ParseArgs(g, args)
 
 	// Some of the values in the config need to have some startup
 	// behavior associated with them. This is run after ParseArgs so
 	// that command-line arguments can override configuration values.
-	app.InitConfig()
+	if initErr := app.InitConfig(); err == nil {
+		err = initErr
+	}
 
+	// os.Exit skips the deferred calls
 	if err != nil {
 		g.Close()
+		app.stopTunnel()
 		fmt.Println("Error!", err)
 		os.Exit(1)
 	}
@@ -782,12 +1548,15 @@ func main() {
 	err = app.SetKeys(g)
 	if err != nil {
 		g.Close()
+		app.stopTunnel()
 		fmt.Println("Error!", err)
 		os.Exit(1)
 	}
 
 	defer g.Close()
 
+	go app.watchConfig(g)
+
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
 		log.Panicln(err)
 	}
@@ -801,6 +1570,18 @@ func exportJSON(r Request) []byte {
 		REQUEST_DATA_VIEW:    r.Data,
 		REQUEST_HEADERS_VIEW: r.Headers,
 	}
+	if r.Auth != "" && r.Auth != DEFAULT_AUTH {
+		requestMap[AUTH_VIEW] = r.Auth
+	}
+	if r.Schema != "" {
+		requestMap[SCHEMA_VIEW] = r.Schema
+	}
+	if r.Notes != "" {
+		requestMap[NOTES_VIEW] = r.Notes
+	}
+	if options := r.Transport.String(); options != "" {
+		requestMap[TRANSPORT_OPTIONS_VIEW] = options
+	}
 
 	request, err := json.Marshal(requestMap)
 	if err != nil {
@@ -809,16 +1590,29 @@ func exportJSON(r Request) []byte {
 	return request
 }
 
+// exportCurl returns the curl command of r, curl cannot send its trailers
+// nor sign the request so they are noted in a comment
 func exportCurl(r Request) []byte {
-	var headers, params string
-	for _, header := range strings.Split(r.Headers, "\n") {
+	var comment, headers, params string
+	headersText, trailersText := splitTrailers(r.Headers)
+	// the request headers take precedence over the auth view
+	if h, _ := parseRequestHeaders(headersText); h.Get("Authorization") == "" {
+		headers, comment = curlAuthArgs(r.Auth)
+	}
+	for _, header := range strings.Split(headersText, "\n") {
 		if header == "" {
 			continue
 		}
 		headers = fmt.Sprintf("%s -H %s", headers, shellescape.Quote(header))
 	}
+	if strings.TrimSpace(trailersText) != "" {
+		comment += "# trailers, not sent by curl:\n" + commentLines(trailersText) + "\n"
+	}
 	if r.GetParams != "" {
 		params = fmt.Sprintf("?%s", r.GetParams)
 	}
-	return []byte(fmt.Sprintf("curl %s -X %s -d %s %s\n", headers, r.Method, shellescape.Quote(r.Data), shellescape.Quote(r.Url+params)))
+	for _, arg := range r.Transport.curlArgs() {
+		headers += " " + arg
+	}
+	return []byte(fmt.Sprintf("%scurl %s -X %s -d %s %s\n", comment, headers, r.Method, shellescape.Quote(r.Data), shellescape.Quote(r.Url+params)))
 }
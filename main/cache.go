@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache statuses displayed by the status line
+const (
+	CACHE_HIT         = "HIT"
+	CACHE_MISS        = "MISS"
+	CACHE_REVALIDATED = "REVALIDATED"
+	CACHE_BYPASS      = "BYPASS"
+)
+
+// CACHE_LIMIT is the maximum number of cached responses
+const CACHE_LIMIT = 100
+
+// statuses which can be cached without explicit freshness information
+var HEURISTICALLY_CACHEABLE = map[int]bool{
+	200: true, 203: true, 204: true, 206: true, 300: true, 301: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+type cacheStatusKey struct{}
+
+// withCacheStatus enables the cache for req, the outcome of the lookup is
+// stored in status
+func withCacheStatus(req *http.Request, status *string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), cacheStatusKey{}, status))
+}
+
+type cacheEntry struct {
+	status       int
+	proto        string
+	header       http.Header
+	body         []byte
+	vary         http.Header
+	requestTime  time.Time
+	responseTime time.Time
+}
+
+// cacheTransport is a private HTTP cache following RFC 7234. It only handles
+// GET requests made with withCacheStatus, others are passed to next as is.
+type cacheTransport struct {
+	next    http.RoundTripper
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+func newCacheTransport(next http.RoundTripper) *cacheTransport {
+	return &cacheTransport{next: next, entries: make(map[string]*cacheEntry)}
+}
+
+func (c *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status, enabled := req.Context().Value(cacheStatusKey{}).(*string)
+	if !enabled || req.Method != http.MethodGet {
+		return c.next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	reqCC := parseCacheControl(req.Header.Values("Cache-Control"))
+	_, noCache := reqCC["no-cache"]
+	if noCache || strings.Contains(req.Header.Get("Pragma"), "no-cache") {
+		*status = CACHE_BYPASS
+		resp, _, err := c.fetch(req, key, nil)
+		return resp, err
+	}
+
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+	if entry == nil || !entry.matches(req) {
+		*status = CACHE_MISS
+		resp, _, err := c.fetch(req, key, nil)
+		return resp, err
+	}
+
+	now := time.Now()
+	if entry.fresh(now, reqCC) {
+		*status = CACHE_HIT
+		return entry.response(req), nil
+	}
+
+	etag := entry.header.Get("ETag")
+	lastModified := entry.header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		*status = CACHE_MISS
+		resp, _, err := c.fetch(req, key, nil)
+		return resp, err
+	}
+	conditional := req.Clone(req.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+	*status = CACHE_MISS
+	resp, refreshed, err := c.fetch(conditional, key, entry)
+	if err == nil && refreshed != nil {
+		*status = CACHE_REVALIDATED
+		resp.Body.Close()
+		return refreshed.response(req), nil
+	}
+	return resp, err
+}
+
+// fetch sends req and stores the response if it can be cached. A 304
+// response replaces stale by a refreshed copy, which is returned. The
+// stored entries are never changed as other requests read them.
+func (c *cacheTransport) fetch(req *http.Request, key string, stale *cacheEntry) (*http.Response, *cacheEntry, error) {
+	requestTime := time.Now()
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	responseTime := time.Now()
+
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		refreshed := *stale
+		refreshed.header = stale.header.Clone()
+		for name, values := range resp.Header {
+			refreshed.header[name] = values
+		}
+		refreshed.requestTime = requestTime
+		refreshed.responseTime = responseTime
+		c.mu.Lock()
+		c.entries[key] = &refreshed
+		c.mu.Unlock()
+		return resp, &refreshed, nil
+	}
+
+	if !cacheable(req, resp) {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return resp, nil, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{
+		status:       resp.StatusCode,
+		proto:        resp.Proto,
+		header:       resp.Header.Clone(),
+		body:         body,
+		vary:         http.Header{},
+		requestTime:  requestTime,
+		responseTime: responseTime,
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		entry.vary[name] = req.Header.Values(name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, found := c.entries[key]; !found && len(c.entries) >= CACHE_LIMIT {
+		c.evictOldest()
+	}
+	c.entries[key] = entry
+	return resp, nil, nil
+}
+
+// CACHEABLE_STATUSES are the statuses of the responses stored when they have
+// an explicit freshness, the cache can replay them
+var CACHEABLE_STATUSES = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 302: true, 307: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+func (c *cacheTransport) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.responseTime.Before(oldest) {
+			oldestKey, oldest = key, entry.responseTime
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// cacheable reports whether resp may be stored by a private cache
+func cacheable(req *http.Request, resp *http.Response) bool {
+	if _, found := parseCacheControl(req.Header.Values("Cache-Control"))["no-store"]; found {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Values("Cache-Control"))
+	if _, found := cc["no-store"]; found {
+		return false
+	}
+	for _, name := range varyHeaders(resp.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	if _, found := cc["max-age"]; found {
+		return CACHEABLE_STATUSES[resp.StatusCode]
+	}
+	if resp.Header.Get("Expires") != "" {
+		return CACHEABLE_STATUSES[resp.StatusCode]
+	}
+	return HEURISTICALLY_CACHEABLE[resp.StatusCode] &&
+		resp.StatusCode != http.StatusPartialContent &&
+		(resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "")
+}
+
+// matches reports whether the headers selected by Vary are the same in req
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, values := range e.vary {
+		if strings.Join(values, ",") != strings.Join(req.Header.Values(name), ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// fresh reports whether the entry can be served without revalidation
+func (e *cacheEntry) fresh(now time.Time, reqCC map[string]string) bool {
+	cc := parseCacheControl(e.header.Values("Cache-Control"))
+	if _, found := cc["no-cache"]; found {
+		return false
+	}
+	lifetime := e.freshnessLifetime(cc)
+	age := e.currentAge(now)
+	if maxAge, found := reqCC["max-age"]; found {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && time.Duration(seconds)*time.Second < lifetime {
+			lifetime = time.Duration(seconds) * time.Second
+		}
+	}
+	if minFresh, found := reqCC["min-fresh"]; found {
+		if seconds, err := strconv.Atoi(minFresh); err == nil {
+			age += time.Duration(seconds) * time.Second
+		}
+	}
+	return age < lifetime
+}
+
+func (e *cacheEntry) freshnessLifetime(cc map[string]string) time.Duration {
+	if maxAge, found := cc["max-age"]; found {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(e.header.Get("Date"))
+	if err != nil {
+		date = e.responseTime
+	}
+	if expiresHeader := e.header.Get("Expires"); expiresHeader != "" {
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil {
+			return 0
+		}
+		return expires.Sub(date)
+	}
+	// heuristic freshness: 10% of the time since the last modification
+	if lastModified, err := http.ParseTime(e.header.Get("Last-Modified")); err == nil && date.After(lastModified) {
+		return date.Sub(lastModified) / 10
+	}
+	return 0
+}
+
+func (e *cacheEntry) currentAge(now time.Time) time.Duration {
+	var age time.Duration
+	if seconds, err := strconv.Atoi(e.header.Get("Age")); err == nil {
+		age = time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(e.header.Get("Date")); err == nil && e.responseTime.After(date) {
+		if apparent := e.responseTime.Sub(date); apparent > age {
+			age = apparent
+		}
+	}
+	age += e.responseTime.Sub(e.requestTime)
+	return age + now.Sub(e.responseTime)
+}
+
+// response creates a response to req from the cached entry
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := e.header.Clone()
+	header.Set("Age", strconv.Itoa(int(e.currentAge(time.Now()).Seconds())))
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         e.proto,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// parseCacheControl returns the directives of Cache-Control header values,
+// directives without argument have an empty value
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
+
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
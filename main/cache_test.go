@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCacheTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "no-cache")
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		case "/conditional":
+			w.Header().Set("Cache-Control", "max-age=60")
+			if req.Header.Get("If-None-Match") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		io.WriteString(w, "body of "+req.URL.Path)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newCacheTransport(http.DefaultTransport)}
+	get := func(path string, header ...string) (string, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		var status string
+		resp, err := client.Do(withCacheStatus(req, &status))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return status, string(body)
+	}
+
+	for _, tc := range []struct {
+		path     string
+		header   []string
+		status   string
+		requests int
+	}{
+		{"/fresh", nil, CACHE_MISS, 1},
+		{"/fresh", nil, CACHE_HIT, 1},
+		{"/fresh", []string{"Cache-Control", "max-age=0"}, CACHE_MISS, 2},
+		{"/fresh", []string{"Cache-Control", "no-cache"}, CACHE_BYPASS, 3},
+		{"/etag", nil, CACHE_MISS, 4},
+		{"/etag", nil, CACHE_REVALIDATED, 5},
+		{"/no-store", nil, CACHE_MISS, 6},
+		{"/no-store", nil, CACHE_MISS, 7},
+	} {
+		status, body := get(tc.path, tc.header...)
+		if status != tc.status || requests != tc.requests {
+			t.Errorf("%s %v: expected %s after %d requests, got %s after %d", tc.path, tc.header, tc.status, tc.requests, status, requests)
+		}
+		if body != "body of "+tc.path {
+			t.Errorf("%s: unexpected body %q", tc.path, body)
+		}
+	}
+
+	// the 304 response of a conditional request is not stored
+	if status, body := get("/conditional", "If-None-Match", `"v1"`); status != CACHE_MISS || body != "" {
+		t.Errorf("expected an empty miss, got %s %q", status, body)
+	}
+	if status, body := get("/conditional"); status != CACHE_MISS || body != "body of /conditional" || requests != 9 {
+		t.Errorf("expected the response to be fetched, got %s %q after %d requests", status, body, requests)
+	}
+}
+
+func TestCacheTransportConcurrentRevalidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, "body")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newCacheTransport(http.DefaultTransport)}
+	get := func() string {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		var status string
+		resp, err := client.Do(withCacheStatus(req, &status))
+		if err != nil {
+			t.Error(err)
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	get()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body := get(); body != "body" {
+				t.Errorf("unexpected revalidated body %q", body)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// captureProxy is a forward proxy adding the proxied requests to the
+// history. HTTPS requests are tunneled without being recorded as their
+// content is encrypted.
+type captureProxy struct {
+	app *App
+	g   *gocui.Gui
+}
+
+func (p *captureProxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		p.tunnel(w, req)
+		return
+	}
+	if !req.URL.IsAbs() {
+		http.Error(w, "buzz capture proxy: only proxy requests are accepted", http.StatusBadRequest)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	r := &Request{
+		Method: req.Method,
+		Data:   string(body),
+		Time:   time.Now(),
+	}
+	u := *req.URL
+	r.GetParams = u.RawQuery
+	u.RawQuery = ""
+	r.Url = u.String()
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(out *http.Request) {
+			// do not add X-Forwarded-For
+			out.Header["X-Forwarded-For"] = nil
+			out.Header.Del("Proxy-Authorization")
+		},
+		Transport: TRANSPORT,
+		ModifyResponse: func(response *http.Response) error {
+			return p.record(r, response)
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// record reads the response body, adds the exchange to the history and
+// passes the body on to the client
+func (p *captureProxy) record(r *Request, response *http.Response) error {
+	r.Duration = time.Since(r.Time)
+	r.Headers = formatRequestHeaders(response.Request.Header)
+	r.RequestHeader = response.Request.Header
+	r.Proto = response.Proto
+	r.ResponseHeader = response.Header
+	r.StatusCode = response.StatusCode
+	if response.TLS != nil {
+		r.TLSVersion = response.TLS.Version
+		if len(response.TLS.PeerCertificates) > 0 {
+			r.CertExpiry = response.TLS.PeerCertificates[0].NotAfter
+		}
+	}
+	r.ContentType = response.Header.Get("Content-Type")
+
+	downloadStart := time.Now()
+	raw, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	r.DownloadDuration = time.Since(downloadStart)
+	r.TransferSize = int64(len(raw))
+	r.RawResponseBody = raw
+	if response.Header.Get("Content-Encoding") == "gzip" {
+		if reader, err := gzip.NewReader(bytes.NewReader(raw)); err == nil {
+			if body, err := io.ReadAll(reader); err == nil {
+				r.RawResponseBody = body
+			}
+		}
+	}
+	r.ResponseTrailer = response.Trailer
+	r.ResponseHeaders = formatResponseHeaders(r, response)
+	r.Formatter = formatter.NewForBody(p.app.config, r.ContentType, r.RawResponseBody)
+
+	p.g.Update(func(g *gocui.Gui) error {
+		// keep the displayed request selected
+		index := p.app.historyIndex
+		p.app.addToHistory(r)
+		if len(p.app.history) > 1 {
+			p.app.historyIndex = index
+		}
+		refreshStatusLine(p.app, g)
+		return nil
+	})
+	return nil
+}
+
+// tunnel connects the client to the requested host and copies the data in
+// both directions
+func (p *captureProxy) tunnel(w http.ResponseWriter, req *http.Request) {
+	dest, err := net.DialTimeout("tcp", req.Host, p.app.config.General.Timeout.Duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer dest.Close()
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be tunneled", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	done := make(chan struct{}, 2)
+	go copyAndSignal(dest, buf.Reader, done)
+	go copyAndSignal(conn, dest, done)
+	<-done
+}
+
+func copyAndSignal(dst io.Writer, src io.Reader, done chan<- struct{}) {
+	io.Copy(dst, src)
+	done <- struct{}{}
+}
+
+// captureHint is shown in the server log of the capture proxy
+func captureHint(addr string) string {
+	host, port, _ := net.SplitHostPort(addr)
+	if host == "" || strings.Trim(host, ":0.") == "" {
+		host = "localhost"
+	}
+	return "set http://" + net.JoinHostPort(host, port) + " as HTTP proxy, HTTPS connections are tunneled without being recorded"
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"unicode"
 
+	"github.com/hitstill/buzz/formatter"
 	"github.com/jroimartin/gocui"
 	"github.com/nsf/termbox-go"
 )
@@ -17,36 +19,15 @@ var COMMANDS map[string]func(string, *App) CommandFunc = map[string]func(string,
 	"submit": func(_ string, a *App) CommandFunc {
 		return a.SubmitRequest
 	},
+	"forceRefresh": func(_ string, a *App) CommandFunc {
+		return a.ForceRefresh
+	},
 	"saveResponse": func(_ string, a *App) CommandFunc {
-		return func(g *gocui.Gui, _ *gocui.View) error {
-			return a.OpenSaveDialog(VIEW_TITLES[SAVE_RESPONSE_DIALOG_VIEW], g,
-				func(g *gocui.Gui, _ *gocui.View) error {
-					saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
-
-					if len(a.history) == 0 {
-						return nil
-					}
-					req := a.history[a.historyIndex]
-					if req.RawResponseBody == nil {
-						return nil
-					}
-
-					err := os.WriteFile(saveLocation, req.RawResponseBody, 0o644)
-
-					var saveResult string
-					if err == nil {
-						saveResult = "Response saved successfully."
-					} else {
-						saveResult = "Error saving response: " + err.Error()
-					}
-					viewErr := a.OpenSaveResultView(saveResult, g)
-					return viewErr
-				})
-		}
+		return a.SaveResponse
 	},
 	"loadRequest": func(_ string, a *App) CommandFunc {
 		return func(g *gocui.Gui, _ *gocui.View) error {
-			return a.OpenSaveDialog(VIEW_TITLES[LOAD_REQUEST_DIALOG_VIEW], g,
+			return a.OpenSaveDialog(VIEW_TITLES[LOAD_REQUEST_DIALOG_VIEW], "", g,
 				func(g *gocui.Gui, _ *gocui.View) error {
 					defer a.closePopup(g, SAVE_DIALOG_VIEW)
 					loadLocation := getViewValue(g, SAVE_DIALOG_VIEW)
@@ -54,12 +35,96 @@ var COMMANDS map[string]func(string, *App) CommandFunc = map[string]func(string,
 				})
 		}
 	},
+	"saveSession": func(_ string, a *App) CommandFunc {
+		return a.SaveSession
+	},
+	"loadSession": func(_ string, a *App) CommandFunc {
+		return a.OpenLoadSessionDialog
+	},
+	"previewRequest": func(_ string, a *App) CommandFunc {
+		return a.PreviewRequest
+	},
 	"saveRequest": func(_ string, a *App) CommandFunc {
 		return a.SaveRequest
 	},
 	"history": func(_ string, a *App) CommandFunc {
 		return a.ToggleHistory
 	},
+	"loadMoreBody": func(_ string, a *App) CommandFunc {
+		return a.LoadMoreBody
+	},
+	"responseSchema": func(_ string, a *App) CommandFunc {
+		return a.OpenSchemaDialog
+	},
+	"graphQL": func(_ string, a *App) CommandFunc {
+		return a.ToggleGraphQL
+	},
+	"soapOperations": func(_ string, a *App) CommandFunc {
+		return a.OpenWSDLDialog
+	},
+	"workspace": func(_ string, a *App) CommandFunc {
+		return a.ToggleWorkspace
+	},
+	"extractJSONPath": func(_ string, a *App) CommandFunc {
+		return a.OpenExtractDialog
+	},
+	"openInBrowser": func(_ string, a *App) CommandFunc {
+		return a.OpenInBrowser
+	},
+	"saveResponseBody": func(_ string, a *App) CommandFunc {
+		return a.SaveResponseBody
+	},
+	"compareEnvironments": func(_ string, a *App) CommandFunc {
+		return a.OpenCompareDialog
+	},
+	"pipeResponse": func(_ string, a *App) CommandFunc {
+		return a.OpenPipeDialog
+	},
+	"filterResponseHeaders": func(_ string, a *App) CommandFunc {
+		return a.OpenResponseHeadersFilter
+	},
+	"copyHeaderValue": func(_ string, a *App) CommandFunc {
+		return a.CopyHeaderValue
+	},
+	"copyHeadersAsCurl": func(_ string, a *App) CommandFunc {
+		return a.CopyHeadersAsCurl
+	},
+	"duplicateRequest": func(_ string, a *App) CommandFunc {
+		return a.OpenDuplicateDialog
+	},
+	"notes": func(_ string, a *App) CommandFunc {
+		return a.ToggleNotes
+	},
+	"transportOptions": func(_ string, a *App) CommandFunc {
+		return a.ToggleTransportOptions
+	},
+	"exportHistory": func(_ string, a *App) CommandFunc {
+		return a.ExportHistory
+	},
+	"userAgent": func(_ string, a *App) CommandFunc {
+		return a.ToggleUserAgentList
+	},
+	"searchMatches": func(_ string, a *App) CommandFunc {
+		return a.ToggleSearchMatches
+	},
+	"copyJSONPath": func(_ string, a *App) CommandFunc {
+		return a.CopyJSONPath
+	},
+	"copyJSONValue": func(_ string, a *App) CommandFunc {
+		return a.CopyJSONValue
+	},
+	"toggleAuth": func(_ string, a *App) CommandFunc {
+		return a.ToggleAuthView
+	},
+	"cycleAuthType": func(_ string, a *App) CommandFunc {
+		return a.CycleAuthType
+	},
+	"wireLog": func(_ string, a *App) CommandFunc {
+		return a.ToggleWireLog
+	},
+	"serverLog": func(_ string, a *App) CommandFunc {
+		return a.ToggleServerLog
+	},
 	"quit": func(_ string, _ *App) CommandFunc {
 		return quit
 	},
@@ -86,16 +151,46 @@ var COMMANDS map[string]func(string, *App) CommandFunc = map[string]func(string,
 	"pageUp": func(_ string, _ *App) CommandFunc {
 		return pageUp
 	},
-	"deleteLine": func(_ string, _ *App) CommandFunc {
-		return deleteLine
+	"halfPageDown": func(_ string, _ *App) CommandFunc {
+		return halfPageDown
 	},
-	"deleteWord": func(_ string, _ *App) CommandFunc {
-		return deleteWord
+	"halfPageUp": func(_ string, _ *App) CommandFunc {
+		return halfPageUp
+	},
+	"scrollTop": func(_ string, _ *App) CommandFunc {
+		return scrollTop
+	},
+	"scrollBottom": func(_ string, _ *App) CommandFunc {
+		return scrollBottom
+	},
+	"scrollLeft": func(_ string, _ *App) CommandFunc {
+		return scrollViewLeft
+	},
+	"scrollRight": func(_ string, _ *App) CommandFunc {
+		return scrollViewRight
+	},
+	"toggleWrap": func(_ string, _ *App) CommandFunc {
+		return toggleWrap
+	},
+	"deleteLine": func(_ string, a *App) CommandFunc {
+		return a.undoable(deleteLine)
+	},
+	"deleteWord": func(_ string, a *App) CommandFunc {
+		return a.undoable(deleteWord)
+	},
+	"undo": func(_ string, a *App) CommandFunc {
+		return a.Undo
+	},
+	"redo": func(_ string, a *App) CommandFunc {
+		return a.Redo
 	},
 	"openEditor": func(_ string, a *App) CommandFunc {
-		return func(g *gocui.Gui, v *gocui.View) error {
+		return a.undoable(func(g *gocui.Gui, v *gocui.View) error {
+			if !isTextEditable(v) {
+				return nil
+			}
 			return openEditor(g, v, a.config.General.Editor)
-		}
+		})
 	},
 	"toggleContextSpecificSearch": func(_ string, a *App) CommandFunc {
 		return func(g *gocui.Gui, _ *gocui.View) error {
@@ -104,14 +199,119 @@ var COMMANDS map[string]func(string, *App) CommandFunc = map[string]func(string,
 			return nil
 		}
 	},
+	"toggleCaptureSearch": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			a.captureSearch = !a.captureSearch
+			refreshStatusLine(a, g)
+			a.PrintBody(g)
+			return nil
+		}
+	},
+	"toggleHTMLFormat": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			next := 1 // an empty format is raw
+			for i, format := range formatter.HTML_FORMATS {
+				if format == a.config.General.HTMLFormat {
+					next = (i + 1) % len(formatter.HTML_FORMATS)
+				}
+			}
+			a.config.General.HTMLFormat = formatter.HTML_FORMATS[next]
+			for _, r := range a.history {
+				if strings.Contains(r.ContentType, "text/html") {
+					r.Formatter = formatter.NewForBody(a.config, r.ContentType, r.RawResponseBody)
+				}
+			}
+			a.PrintBody(g)
+			return nil
+		}
+	},
+	"toggleRawResponse": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			a.rawResponse = !a.rawResponse
+			a.PrintBody(g)
+			return nil
+		}
+	},
 	"clearHistory": func(_ string, a *App) CommandFunc {
 		return func(g *gocui.Gui, _ *gocui.View) error {
-			a.history = make([]*Request, 0, 31)
-			a.historyIndex = 0
+			a.clearHistory(g)
 			a.Layout(g)
 			return nil
 		}
 	},
+	"deleteHistoryEntry": func(_ string, a *App) CommandFunc {
+		return a.deleteHistoryEntry
+	},
+	"replayHistoryEntry": func(_ string, a *App) CommandFunc {
+		return a.replayHistoryEntry
+	},
+	"pinHistoryEntry": func(_ string, a *App) CommandFunc {
+		return a.togglePinHistoryEntry
+	},
+	"multipartEditor": func(_ string, a *App) CommandFunc {
+		return a.ToggleMultipartEditor
+	},
+	"editMultipartPart": func(_ string, a *App) CommandFunc {
+		return a.editMultipartPart
+	},
+	"addMultipartText": func(_ string, a *App) CommandFunc {
+		return a.addMultipartText
+	},
+	"addMultipartFile": func(_ string, a *App) CommandFunc {
+		return a.addMultipartFile
+	},
+	"deleteMultipartPart": func(_ string, a *App) CommandFunc {
+		return a.deleteMultipartPart
+	},
+	"prettifyJSON": func(_ string, a *App) CommandFunc {
+		return a.undoable(func(g *gocui.Gui, _ *gocui.View) error {
+			return a.reformatRequestData(g, true)
+		})
+	},
+	"minifyJSON": func(_ string, a *App) CommandFunc {
+		return a.undoable(func(g *gocui.Gui, _ *gocui.View) error {
+			return a.reformatRequestData(g, false)
+		})
+	},
+	"toggleAutoSave": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			if !a.config.General.AutoSave && a.config.General.AutoSaveDirectory == "" {
+				return a.OpenMessageView("Set autoSaveDirectory in the config file to enable auto saving", g)
+			}
+			a.config.General.AutoSave = !a.config.General.AutoSave
+			refreshStatusLine(a, g)
+			return nil
+		}
+	},
+	"toggleGzipBody": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			a.config.General.GzipRequestBody = !a.config.General.GzipRequestBody
+			refreshStatusLine(a, g)
+			return nil
+		}
+	},
+	"toggleKeepAlive": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			a.config.General.DisableKeepAlives = !a.config.General.DisableKeepAlives
+			disabled := a.config.General.DisableKeepAlives
+			TRANSPORT.update(func(t *http.Transport) {
+				t.DisableKeepAlives = disabled
+			})
+			refreshStatusLine(a, g)
+			return nil
+		}
+	},
+	"toggleOffline": func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			a.config.General.Offline = !a.config.General.Offline
+			OFFLINE_REPLAY.setEnabled(a.config.General.Offline)
+			refreshStatusLine(a, g)
+			return nil
+		}
+	},
+	"rawRequest": func(_ string, a *App) CommandFunc {
+		return a.ToggleRawRequest
+	},
 	"redirectRestriction": func(_ string, a *App) CommandFunc {
 		return func(g *gocui.Gui, _ *gocui.View) error {
 			a.config.General.FollowRedirects = !a.config.General.FollowRedirects
@@ -120,6 +320,86 @@ var COMMANDS map[string]func(string, *App) CommandFunc = map[string]func(string,
 	},
 }
 
+// COMMAND_DESCRIPTIONS are the one-line descriptions of the COMMANDS shown
+// by the help
+var COMMAND_DESCRIPTIONS = map[string]string{
+	"submit":                      "Send the request",
+	"forceRefresh":                "Send the request bypassing the response cache",
+	"saveResponse":                "Save the response to a file",
+	"loadRequest":                 "Load a saved request",
+	"saveSession":                 "Save the request views, the history and the environment to a file",
+	"loadSession":                 "Restore a saved session",
+	"previewRequest":              "Preview the request as it will be sent",
+	"saveRequest":                 "Save the request as JSON or curl command",
+	"history":                     "Show the request history",
+	"loadMoreBody":                "Display the next part of a large response body",
+	"responseSchema":              "Set the JSON Schema the responses are validated against",
+	"workspace":                   "List the requests of the workspace",
+	"extractJSONPath":             "Copy or store in a variable the result of a JSONPath",
+	"openInBrowser":               "Open the response in the browser",
+	"saveResponseBody":            "Save the raw response body to a file",
+	"compareEnvironments":         "Compare the responses of the request in two environments",
+	"pipeResponse":                "Pipe the response body through a shell command",
+	"filterResponseHeaders":       "Show only the response headers matching a filter",
+	"copyHeaderValue":             "Copy the value of the response header under the cursor",
+	"copyHeadersAsCurl":           "Copy the displayed response headers as curl -H arguments",
+	"duplicateRequest":            "Save a copy of the edited request in the workspace under a new name",
+	"notes":                       "Edit the notes of the request",
+	"transportOptions":            "Change the HTTP version, TLS renegotiation and compression of the request",
+	"exportHistory":               "Export the history as a shell script of curl commands",
+	"userAgent":                   "Pick the User-Agent header among common browsers and tools",
+	"searchMatches":               "List the search matches of the response body",
+	"copyJSONPath":                "Copy the JSONPath of the selected line",
+	"copyJSONValue":               "Copy the JSON value of the selected line",
+	"toggleAuth":                  "Switch between the request data and the auth view",
+	"cycleAuthType":               "Switch to the next auth type",
+	"wireLog":                     "Show the connections, requests and responses as sent",
+	"serverLog":                   "Show the requests received by the local server",
+	"quit":                        "Quit",
+	"focus":                       "Focus the view given as argument, e.g. focus url",
+	"nextView":                    "Focus the next view",
+	"prevView":                    "Focus the previous view",
+	"scrollDown":                  "Scroll down one line",
+	"scrollUp":                    "Scroll up one line",
+	"pageDown":                    "Scroll down a page",
+	"pageUp":                      "Scroll up a page",
+	"halfPageDown":                "Scroll down half a page",
+	"halfPageUp":                  "Scroll up half a page",
+	"scrollTop":                   "Scroll to the top",
+	"scrollBottom":                "Scroll to the bottom",
+	"scrollLeft":                  "Scroll left",
+	"scrollRight":                 "Scroll right",
+	"toggleWrap":                  "Toggle the wrapping of long lines",
+	"deleteLine":                  "Delete the line",
+	"deleteWord":                  "Delete the word before the cursor",
+	"undo":                        "Undo the last change",
+	"redo":                        "Redo the last undone change",
+	"openEditor":                  "Edit the view in the external editor",
+	"toggleContextSpecificSearch": "Switch between regex and response specific search",
+	"toggleCaptureSearch":         "Toggle showing only the regex capture groups",
+	"toggleHTMLFormat":            "Switch between raw, indented and text HTML",
+	"toggleRawResponse":           "Toggle the raw response body",
+	"clearHistory":                "Clear the history",
+	"deleteHistoryEntry":          "Delete the selected history entry",
+	"replayHistoryEntry":          "Send the selected history entry again",
+	"pinHistoryEntry":             "Pin or unpin the selected history entry",
+	"multipartEditor":             "Edit the multipart form parts",
+	"editMultipartPart":           "Edit the selected part",
+	"addMultipartText":            "Add a text part",
+	"addMultipartFile":            "Add a file part",
+	"deleteMultipartPart":         "Delete the selected part",
+	"prettifyJSON":                "Indent the JSON request body",
+	"minifyJSON":                  "Minify the JSON request body",
+	"toggleAutoSave":              "Toggle saving every response body",
+	"toggleGzipBody":              "Toggle compressing the request body with gzip",
+	"graphQL":                     "Toggle completing the GraphQL query with the schema of the endpoint",
+	"soapOperations":              "List the SOAP operations of a WSDL and fill the request with one of them",
+	"toggleKeepAlive":             "Toggle closing the connection after every request",
+	"toggleOffline":               "Toggle replaying the recorded responses instead of sending the requests",
+	"redirectRestriction":         "Toggle following redirects",
+	"rawRequest":                  "Edit the whole request and send it as it is",
+}
+
 func scrollView(v *gocui.View, dy int) error {
 	v.Autoscroll = false
 	ox, oy := v.Origin()
@@ -153,8 +433,100 @@ func pageDown(_ *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
+func halfPageUp(_ *gocui.Gui, v *gocui.View) error {
+	_, height := v.Size()
+	return scrollView(v, -height/2)
+}
+
+func halfPageDown(_ *gocui.Gui, v *gocui.View) error {
+	_, height := v.Size()
+	return scrollView(v, height/2)
+}
+
+func scrollTop(_ *gocui.Gui, v *gocui.View) error {
+	v.Autoscroll = false
+	ox, _ := v.Origin()
+	v.SetOrigin(ox, 0)
+	return nil
+}
+
+func scrollBottom(_ *gocui.Gui, v *gocui.View) error {
+	v.Autoscroll = false
+	ox, _ := v.Origin()
+	_, height := v.Size()
+	oy := len(v.ViewBufferLines()) - height
+	if oy < 0 {
+		oy = 0
+	}
+	v.SetOrigin(ox, oy)
+	return nil
+}
+
+// scrollViewHorizontal moves the origin of an unwrapped view by dx columns,
+// stopping at the end of the longest visible line
+func scrollViewHorizontal(v *gocui.View, dx int) error {
+	if v.Wrap {
+		return nil
+	}
+	ox, oy := v.Origin()
+	width, height := v.Size()
+	maxX := 0
+	for y := 0; y < height; y++ {
+		line, err := v.Line(y)
+		if err != nil {
+			break
+		}
+		if len(line) > maxX {
+			maxX = len(line)
+		}
+	}
+	ox += dx
+	if ox > maxX-width {
+		ox = maxX - width
+	}
+	if ox < 0 {
+		ox = 0
+	}
+	v.SetOrigin(ox, oy)
+	return nil
+}
+
+func scrollViewLeft(_ *gocui.Gui, v *gocui.View) error {
+	width, _ := v.Size()
+	return scrollViewHorizontal(v, -width/4)
+}
+
+func scrollViewRight(_ *gocui.Gui, v *gocui.View) error {
+	width, _ := v.Size()
+	return scrollViewHorizontal(v, width/4)
+}
+
+func toggleWrap(g *gocui.Gui, _ *gocui.View) error {
+	v, err := g.View(RESPONSE_BODY_VIEW)
+	if err != nil {
+		return nil
+	}
+	v.Wrap = !v.Wrap
+	_, oy := v.Origin()
+	v.SetOrigin(0, oy)
+	return nil
+}
+
+// READ_ONLY_VIEWS are editable only to receive key events, their content
+// must not be changed by the editing commands
+var READ_ONLY_VIEWS = map[string]bool{
+	RESPONSE_HEADERS_VIEW: true,
+	RESPONSE_BODY_VIEW:    true,
+	HISTORY_VIEW:          true,
+	HELP_VIEW:             true,
+}
+
+func isTextEditable(v *gocui.View) bool {
+	return v != nil && v.Editable && !READ_ONLY_VIEWS[v.Name()]
+}
+
 func deleteLine(_ *gocui.Gui, v *gocui.View) error {
-	if !v.Editable {
+	if !isTextEditable(v) {
 		return nil
 	}
 	_, curY := v.Cursor()
@@ -175,6 +547,9 @@ func deleteLine(_ *gocui.Gui, v *gocui.View) error {
 }
 
 func deleteWord(_ *gocui.Gui, v *gocui.View) error {
+	if !isTextEditable(v) {
+		return nil
+	}
 	cX, cY := v.Cursor()
 	oX, _ := v.Origin()
 	cX = cX - 1 + oX
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hitstill/buzz/config"
+	"github.com/hitstill/buzz/formatter"
+)
+
+// CSRF_SOURCES are the places the CSRF token can be taken from
+var CSRF_SOURCES = []string{"", "cookie", "header", "meta", "json"}
+
+// runConfigCommand prints the configuration resulting from the defaults,
+// the config file and the command line options of "buzz config" and the
+// problems found in it. It returns the exit status.
+func runConfigCommand(stdout, stderr io.Writer, configPath string, args []string) int {
+	conf, source, err := loadEffectiveConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error loading config file: %v\n", err)
+		return 1
+	}
+	for i := 0; i < len(args); i++ {
+		last, found, err := parseConfigArg(&conf.General, args, i)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error!", err)
+			return 1
+		}
+		if !found {
+			fmt.Fprintf(stderr, "Error! %v is not a configuration option\n", args[i])
+			return 1
+		}
+		i = last
+	}
+
+	fmt.Fprintf(stdout, "# %v\n", source)
+	if err := toml.NewEncoder(stdout).Encode(conf); err != nil {
+		fmt.Fprintln(stderr, "Error!", err)
+		return 1
+	}
+	problems := validateConfig(conf)
+	for _, problem := range problems {
+		fmt.Fprintln(stderr, "invalid:", problem)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadEffectiveConfig loads the config file like App.LoadConfig and
+// describes where the values come from
+func loadEffectiveConfig(configPath string) (*config.Config, string, error) {
+	if configPath == "" {
+		configPath, _ = config.GetDefaultConfigLocation()
+	}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		conf := config.DefaultConfig
+		conf.Keys = config.DefaultKeys
+		return &conf, "defaults, " + configPath + " does not exist", nil
+	}
+	conf, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return conf, "defaults and " + configPath, nil
+}
+
+// validateConfig returns the problems of the config which are otherwise
+// only found when the values are used
+func validateConfig(conf *config.Config) []string {
+	var problems []string
+	general := conf.General
+	if _, err := NewStatusLine(general.StatusLine); err != nil {
+		problems = append(problems, "general.statusLine: "+err.Error())
+	}
+	if general.HTMLFormat != "" && !slices.Contains(formatter.HTML_FORMATS, general.HTMLFormat) {
+		problems = append(problems, fmt.Sprintf("general.htmlFormat: %q is not one of %v", general.HTMLFormat, strings.Join(formatter.HTML_FORMATS, ", ")))
+	}
+	if general.DefaultURLScheme != "http" && general.DefaultURLScheme != "https" {
+		problems = append(problems, fmt.Sprintf("general.defaultURLScheme: %q is not http or https", general.DefaultURLScheme))
+	}
+	if general.IPVersion != 0 && general.IPVersion != 4 && general.IPVersion != 6 {
+		problems = append(problems, fmt.Sprintf("general.ipVersion: %v is not 0, 4 or 6", general.IPVersion))
+	}
+	if general.TLSVersionMin != 0 && general.TLSVersionMax != 0 && general.TLSVersionMin > general.TLSVersionMax {
+		problems = append(problems, "general.tlsVersionMin is greater than general.tlsVersionMax")
+	}
+	if _, err := newDialContext(general); err != nil {
+		problems = append(problems, "general.localAddr: "+err.Error())
+	}
+	if general.RateLimit < 0 {
+		problems = append(problems, fmt.Sprintf("general.rateLimit: %v is negative", general.RateLimit))
+	}
+
+	for _, category := range sortedCategories(conf.Keys) {
+		for _, key := range slices.Sorted(maps.Keys(conf.Keys[category])) {
+			command := conf.Keys[category][key]
+			if command == "" {
+				continue
+			}
+			if conflict := keyConflict(conf.Keys, category, key, command); conflict != "" {
+				problems = append(problems, fmt.Sprintf("keys.%v.%v = %q: %v", category, key, command, conflict))
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(conf.Signing)) {
+		profile := conf.Signing[name]
+		if _, found := SIGNING_ALGORITHMS[strings.ToLower(profile.Algorithm)]; !found {
+			problems = append(problems, fmt.Sprintf("signing.%v.algorithm: unknown algorithm %q", name, profile.Algorithm))
+		}
+		if _, err := decodeSigningKey(profile); err != nil {
+			problems = append(problems, fmt.Sprintf("signing.%v.key: %v", name, err))
+		}
+		if profile.Header == "" {
+			problems = append(problems, fmt.Sprintf("signing.%v.header is not set", name))
+		}
+		if _, err := template.New("stringToSign").Parse(profile.StringToSign); err != nil {
+			problems = append(problems, fmt.Sprintf("signing.%v.stringToSign: %v", name, err))
+		}
+		if _, err := template.New("value").Parse(profile.Value); err != nil {
+			problems = append(problems, fmt.Sprintf("signing.%v.value: %v", name, err))
+		}
+	}
+
+	for _, class := range slices.Sorted(maps.Keys(conf.StatusColors)) {
+		if len(class) != 3 || class[0] < '1' || class[0] > '5' || class[1:] != "xx" {
+			problems = append(problems, fmt.Sprintf("status_colors.%v: not a status class, e.g. 4xx", class))
+		}
+		if _, found := FRAME_COLORS[strings.ToLower(conf.StatusColors[class])]; !found {
+			problems = append(problems, fmt.Sprintf("status_colors.%v: unknown color %q", class, conf.StatusColors[class]))
+		}
+	}
+
+	if !slices.Contains(CSRF_SOURCES, conf.CSRF.Source) {
+		problems = append(problems, fmt.Sprintf("csrf.source: %q is not one of %v", conf.CSRF.Source, strings.Join(CSRF_SOURCES[1:], ", ")))
+	} else if conf.CSRF.Source != "" && conf.CSRF.Name == "" {
+		problems = append(problems, "csrf.name is not set")
+	}
+	return problems
+}
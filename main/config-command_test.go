@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	conf := config.DefaultConfig
+	conf.Keys = config.DefaultKeys
+	if problems := validateConfig(&conf); len(problems) > 0 {
+		t.Fatalf("the default config is invalid: %v", problems)
+	}
+
+	conf.General.HTMLFormat = "pretty"
+	conf.General.StatusLine = "{{.Version"
+	conf.Keys = map[string]map[string]string{
+		"global":        {"AltT": "history"},
+		"response-body": {"AltT": "toggleHTMLFormat", "AltY": "unknown"},
+	}
+	conf.Signing = map[string]config.SigningProfile{"api": {Algorithm: "sha3", Header: "Authorization"}}
+	conf.CSRF = config.CSRFOptions{Source: "body"}
+	conf.StatusColors = map[string]string{"2xx": "pink", "error": "red"}
+	problems := strings.Join(validateConfig(&conf), "\n")
+	for _, expected := range []string{
+		"general.htmlFormat",
+		"general.statusLine",
+		"keys.global.AltT = \"history\": also bound to toggleHTMLFormat in response-body",
+		"keys.response-body.AltY = \"unknown\": unknown command: unknown",
+		"signing.api.algorithm",
+		"csrf.source",
+		"status_colors.2xx: unknown color \"pink\"",
+		"status_colors.error: not a status class",
+	} {
+		if !strings.Contains(problems, expected) {
+			t.Errorf("%q not found in the problems:\n%v", expected, problems)
+		}
+	}
+}
+
+func TestRunConfigCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[general]\ntimeout = \"5s\"\neditor = \"nano\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if status := runConfigCommand(&stdout, &stderr, path, []string{"-t", "250", "-k"}); status != 0 {
+		t.Fatalf("unexpected status %v: %v", status, stderr.String())
+	}
+	for _, expected := range []string{`Timeout = "250ms"`, `Editor = "nano"`, "Insecure = true", `CtrlR = "submit"`} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("%q not found in the output:\n%v", expected, stdout.String())
+		}
+	}
+
+	stderr.Reset()
+	if status := runConfigCommand(&stdout, &stderr, path, []string{"https://example.com"}); status != 1 || !strings.Contains(stderr.String(), "not a configuration option") {
+		t.Errorf("unexpected status %v: %v", status, stderr.String())
+	}
+}
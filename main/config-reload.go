@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// CONFIG_WATCH_INTERVAL is how often the config file is checked for changes
+const CONFIG_WATCH_INTERVAL = time.Second
+
+func init() {
+	// registered here as reloading sets the keybindings of COMMANDS
+	COMMANDS["reloadConfig"] = func(_ string, a *App) CommandFunc {
+		return func(g *gocui.Gui, _ *gocui.View) error {
+			return a.ReloadConfig(g, true)
+		}
+	}
+	COMMAND_DESCRIPTIONS["reloadConfig"] = "Reload the configuration file"
+}
+
+// watchConfig reloads the config when the modification time of the config
+// file changes
+func (a *App) watchConfig(g *gocui.Gui) {
+	var modTime time.Time
+	if info, err := os.Stat(a.configPath); err == nil {
+		modTime = info.ModTime()
+	}
+	for range time.Tick(CONFIG_WATCH_INTERVAL) {
+		info, err := os.Stat(a.configPath)
+		if err != nil || info.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = info.ModTime()
+		g.Update(func(g *gocui.Gui) error {
+			return a.ReloadConfig(g, false)
+		})
+	}
+}
+
+// keepChangedOptions sets the options of conf which are the same as in
+// the previous config file to their current value, set by the command line
+// options or toggled. Both conf and previous include the workspace
+// settings.
+func keepChangedOptions(conf *config.GeneralOptions, previous, current config.GeneralOptions) {
+	confValue := reflect.ValueOf(conf).Elem()
+	previousValue, currentValue := reflect.ValueOf(previous), reflect.ValueOf(current)
+	for i := 0; i < confValue.NumField(); i++ {
+		if reflect.DeepEqual(confValue.Field(i).Interface(), previousValue.Field(i).Interface()) {
+			confValue.Field(i).Set(currentValue.Field(i))
+		}
+	}
+}
+
+// ReloadConfig applies the keys, HTTP client, status line and formatter
+// settings of the config file, the general options only where the file
+// changed. The config is kept if the file is invalid.
+// Unless force is set nothing is reported when the config did not change.
+func (a *App) ReloadConfig(g *gocui.Gui, force bool) error {
+	if _, err := os.Stat(a.configPath); err != nil {
+		return a.OpenMessageView("Config not reloaded: "+err.Error(), g)
+	}
+	conf, err := config.LoadConfig(a.configPath)
+	if err != nil {
+		LOGGER.Error("config reload failed", "path", a.configPath, "error", err)
+		return a.OpenMessageView("Config not reloaded: "+err.Error(), g)
+	}
+	fileOptions := conf.General
+	previousOptions := a.fileOptions
+	if a.workspacePath != "" {
+		// the workspace settings override the file, as when it was opened
+		if err := config.LoadWorkspaceOptions(a.workspacePath, &conf.General); err != nil {
+			return a.OpenMessageView("Config not reloaded: workspace: "+err.Error(), g)
+		}
+		if err := config.LoadWorkspaceOptions(a.workspacePath, &previousOptions); err != nil {
+			return a.OpenMessageView("Config not reloaded: workspace: "+err.Error(), g)
+		}
+	}
+	keepChangedOptions(&conf.General, previousOptions, a.config.General)
+	statusLine, err := NewStatusLine(conf.General.StatusLine)
+	if err != nil {
+		return a.OpenMessageView("Config not reloaded: status line: "+err.Error(), g)
+	}
+	a.fileOptions = fileOptions
+	if !force && reflect.DeepEqual(*conf, *a.config) {
+		return nil
+	}
+
+	// the requests in progress keep the previous config
+	previous := a.config
+	a.config = conf
+	a.statusLine = statusLine
+	invalid := a.rebindChangedKeys(g, previous.Keys)
+	if err := a.applyClientConfig(); err != nil {
+		invalid = append(invalid, err.Error())
+	}
+	if conf.General.LogFile != previous.General.LogFile && conf.General.LogFile != "" {
+		if err := openLogFile(conf.General.LogFile); err != nil {
+			invalid = append(invalid, "log file: "+err.Error())
+		}
+	}
+	TRANSPORT.CloseIdleConnections()
+
+	for _, r := range a.history {
+		if r.RawResponseBody != nil {
+			r.Formatter = formatter.NewForBody(a.config, r.ContentType, r.RawResponseBody)
+		}
+	}
+	a.PrintBody(g)
+	refreshStatusLine(a, g)
+	LOGGER.Info("config reloaded", "path", a.configPath)
+
+	if len(invalid) > 0 {
+		return a.OpenMessageView("Config reloaded with errors:\n"+strings.Join(invalid, "\n"), g)
+	}
+	return a.OpenMessageView("Config reloaded from "+a.configPath, g)
+}
+
+// rebindChangedKeys replaces the bindings which differ from previous and
+// returns the invalid ones
+func (a *App) rebindChangedKeys(g *gocui.Gui, previous map[string]map[string]string) []string {
+	current := a.config.Keys
+	var invalid []string
+	merged := mergeKeys(previous, current)
+	for _, category := range sortedCategories(merged) {
+		for key := range merged[category] {
+			command := current[category][key]
+			if command == previous[category][key] {
+				continue
+			}
+			if current[category] == nil {
+				current[category] = make(map[string]string)
+			}
+			if err := a.rebindKey(g, category, key, command); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%v %v: %v", category, key, err))
+			}
+		}
+	}
+	sort.Strings(invalid)
+	return invalid
+}
+
+// mergeKeys returns the bindings of both keybinding maps, those of b take
+// precedence
+func mergeKeys(a, b map[string]map[string]string) map[string]map[string]string {
+	merged := make(map[string]map[string]string)
+	for _, keys := range []map[string]map[string]string{a, b} {
+		for category, bindings := range keys {
+			if merged[category] == nil {
+				merged[category] = make(map[string]string)
+			}
+			for key, command := range bindings {
+				merged[category][key] = command
+			}
+		}
+	}
+	return merged
+}
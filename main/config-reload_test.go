@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestKeepChangedOptions(t *testing.T) {
+	previous := config.GeneralOptions{Insecure: false, Editor: "vim", Timeout: config.Duration{Duration: time.Minute}}
+	// --insecure on the command line and auto save toggled
+	current := previous
+	current.Insecure = true
+	current.AutoSave = true
+	// the file changes the timeout and enables auto save
+	conf := previous
+	conf.Timeout = config.Duration{Duration: time.Second}
+	conf.AutoSave = true
+	conf.Editor = "nano"
+
+	keepChangedOptions(&conf, previous, current)
+	if !conf.Insecure {
+		t.Error("the command line option was not kept")
+	}
+	if conf.Timeout.Duration != time.Second || conf.Editor != "nano" || !conf.AutoSave {
+		t.Errorf("the changed options were not applied: %+v", conf)
+	}
+}
+
+func TestMergeKeys(t *testing.T) {
+	merged := mergeKeys(
+		map[string]map[string]string{"global": {"CtrlR": "submit", "AltY": "history"}},
+		map[string]map[string]string{"global": {"CtrlR": "quit"}, "url": {"Enter": "submit"}},
+	)
+	if merged["global"]["CtrlR"] != "quit" || merged["global"]["AltY"] != "history" || merged["url"]["Enter"] != "submit" {
+		t.Errorf("unexpected merged keys %v", merged)
+	}
+}
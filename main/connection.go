@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"time"
+)
+
+// connectionTrace records the details of the connection used by r
+func (r *Request) connectionTrace() *httptrace.ClientTrace {
+	var waitStart time.Time
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.ConnReused = info.Reused
+			if info.Conn != nil {
+				r.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		Wait100Continue: func() {
+			r.ExpectContinue = true
+			waitStart = time.Now()
+		},
+		Got100Continue: func() {
+			r.Got100Continue = true
+			r.ContinueDelay = time.Since(waitStart)
+		},
+	}
+}
+
+// writeConnectionInfo writes the connection details of r in the style of
+// curl's verbose output
+func writeConnectionInfo(w io.Writer, r *Request) {
+	if r.Offline {
+		fmt.Fprint(w, "\x1b[0;36m* Offline: recorded response\x1b[0;0m\n")
+		return
+	}
+	if r.RemoteAddr == "" {
+		return
+	}
+	reuse := "new connection"
+	if r.ConnReused {
+		reuse = "reused connection"
+	}
+	fmt.Fprintf(w, "\x1b[0;36m* Connected to %s (%s)", r.RemoteAddr, reuse)
+	if r.ALPN != "" {
+		fmt.Fprintf(w, ", ALPN: %s", r.ALPN)
+	}
+	fmt.Fprint(w, "\x1b[0;0m\n")
+	if r.Got100Continue {
+		fmt.Fprintf(w, "\x1b[0;36m* 100 Continue received after %s\x1b[0;0m\n", r.ContinueDelay)
+	} else if r.ExpectContinue {
+		fmt.Fprint(w, "\x1b[0;36m* No 100 Continue received\x1b[0;0m\n")
+	}
+}
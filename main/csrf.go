@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hitstill/buzz/config"
+)
+
+// CSRF_METHODS are the state-changing methods the CSRF token is sent with
+var CSRF_METHODS = map[string]bool{
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// csrfTokenCache keeps the last CSRF token received from every host
+type csrfTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+var CSRF_TOKENS = &csrfTokenCache{tokens: make(map[string]string)}
+
+func (c *csrfTokenCache) get(host string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token, found := c.tokens[host]
+	return token, found
+}
+
+func (c *csrfTokenCache) set(host, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[host] = token
+}
+
+// extractCSRFToken returns the CSRF token found in the response of r, from
+// a cookie, a response header, a meta tag or a JSON field
+func extractCSRFToken(options config.CSRFOptions, r *Request) (string, bool) {
+	switch options.Source {
+	case "cookie":
+		for _, cookie := range (&http.Response{Header: r.ResponseHeader}).Cookies() {
+			if cookie.Name == options.Name && cookie.Value != "" {
+				return cookie.Value, true
+			}
+		}
+	case "header":
+		if value := r.ResponseHeader.Get(options.Name); value != "" {
+			return value, true
+		}
+	case "meta":
+		if !strings.Contains(r.ContentType, "html") {
+			return "", false
+		}
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(r.RawResponseBody))
+		if err != nil {
+			return "", false
+		}
+		if value, found := doc.Find(fmt.Sprintf("meta[name=%q]", options.Name)).Attr("content"); found && value != "" {
+			return value, true
+		}
+	case "json":
+		values, err := evalJSONPath(options.Name, r.RawResponseBody)
+		if err == nil && len(values) == 1 {
+			if value := formatJSONValues(values); value != "" {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// addCSRFToken sends the last CSRF token received from the host with the
+// state-changing requests which do not set it
+func addCSRFToken(options config.CSRFOptions, req *http.Request) {
+	if options.Source == "" || !CSRF_METHODS[req.Method] || req.Header.Get(options.Header) != "" {
+		return
+	}
+	if token, found := CSRF_TOKENS.get(req.URL.Host); found {
+		req.Header.Set(options.Header, token)
+	}
+}
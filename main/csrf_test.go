@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestExtractCSRFToken(t *testing.T) {
+	r := &Request{
+		ResponseHeader: http.Header{
+			"Set-Cookie":   {"session=abc; Path=/", "csrftoken=c00kie; Path=/"},
+			"X-Csrf-Token": {"h3ader"},
+		},
+		ContentType:     "text/html; charset=utf-8",
+		RawResponseBody: []byte(`<html><head><meta name="csrf-token" content="m3ta"></head></html>`),
+	}
+	for _, tc := range []struct {
+		source, name, expected string
+	}{
+		{"cookie", "csrftoken", "c00kie"},
+		{"header", "X-CSRF-Token", "h3ader"},
+		{"meta", "csrf-token", "m3ta"},
+	} {
+		token, found := extractCSRFToken(config.CSRFOptions{Source: tc.source, Name: tc.name}, r)
+		if !found || token != tc.expected {
+			t.Errorf("unexpected %s token %q", tc.source, token)
+		}
+	}
+
+	r = &Request{ContentType: "application/json", RawResponseBody: []byte(`{"data": {"csrf": "js0n"}}`)}
+	options := config.CSRFOptions{Source: "json", Name: "$.data.csrf", Header: "X-CSRF-Token"}
+	token, found := extractCSRFToken(options, r)
+	if !found || token != "js0n" {
+		t.Errorf("unexpected json token %q", token)
+	}
+	CSRF_TOKENS.set("example.com", token)
+
+	post, _ := http.NewRequest(http.MethodPost, "https://example.com/items", nil)
+	addCSRFToken(options, post)
+	get, _ := http.NewRequest(http.MethodGet, "https://example.com/items", nil)
+	addCSRFToken(options, get)
+	if post.Header.Get("X-CSRF-Token") != "js0n" || get.Header.Get("X-CSRF-Token") != "" {
+		t.Errorf("unexpected CSRF headers %v %v", post.Header, get.Header)
+	}
+}
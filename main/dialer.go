@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// dialNetwork restricts network to the IP version of the configuration, or
+// to the IP version of the local address
+func dialNetwork(network string, conf config.GeneralOptions, local net.IP) string {
+	if network != "tcp" {
+		return network
+	}
+	switch {
+	case conf.IPVersion == 4:
+		return "tcp4"
+	case conf.IPVersion == 6:
+		return "tcp6"
+	case local != nil && local.To4() != nil:
+		return "tcp4"
+	case local != nil:
+		return "tcp6"
+	}
+	return network
+}
+
+// localIP returns the IP address of the local address of the configuration,
+// either an IP address or the name of a network interface
+func localIP(conf config.GeneralOptions) (net.IP, error) {
+	if conf.LocalAddr == "" {
+		return nil, nil
+	}
+	if ip := net.ParseIP(conf.LocalAddr); ip != nil {
+		return ip, nil
+	}
+	iface, err := net.InterfaceByName(conf.LocalAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid local address %q: %v", conf.LocalAddr, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var found net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		isIPv4 := ipNet.IP.To4() != nil
+		if (conf.IPVersion == 4 && !isIPv4) || (conf.IPVersion == 6 && isIPv4) {
+			continue
+		}
+		// prefer IPv4 addresses when both are allowed
+		if found == nil || (isIPv4 && found.To4() == nil) {
+			found = ipNet.IP
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("interface %s has no usable address", conf.LocalAddr)
+	}
+	return found, nil
+}
+
+// newDialContext returns the DialContext function of the transport which
+// applies the address family and the local address of the configuration
+func newDialContext(conf config.GeneralOptions) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	local, err := localIP(conf)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if local != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: local}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, dialNetwork(network, conf, local), addr)
+	}, nil
+}
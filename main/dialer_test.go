@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestLocalAddr(t *testing.T) {
+	remote := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		remote <- req.RemoteAddr
+	}))
+	defer server.Close()
+
+	dialContext, err := newDialContext(config.GeneralOptions{LocalAddr: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{DialContext: dialContext}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if host, _, _ := net.SplitHostPort(<-remote); host != "127.0.0.1" {
+		t.Errorf("unexpected source address %s", host)
+	}
+
+	if _, err := newDialContext(config.GeneralOptions{LocalAddr: "no-such-interface0"}); err == nil {
+		t.Error("unknown interface accepted")
+	}
+	if network := dialNetwork("tcp", config.GeneralOptions{}, net.ParseIP("::1")); network != "tcp6" {
+		t.Errorf("unexpected network %s for an IPv6 local address", network)
+	}
+}
+
+func TestDialerOptionsWithSOCKSProxy(t *testing.T) {
+	a := &App{config: &config.Config{General: config.GeneralOptions{IPVersion: 4}}, socksProxy: true}
+	if err := a.applyClientConfig(); err == nil {
+		t.Error("the IP version accepted with a SOCKS proxy")
+	}
+}
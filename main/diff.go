@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MAX_DIFF_CELLS limits the size of the table of the line diff, larger
+// inputs are shown as entirely replaced after their common lines
+const MAX_DIFF_CELLS = 4 << 20
+
+// diffLine is a line of a diff, op is ' ' for a common line, '-' for a line
+// only in the first text and '+' for a line only in the second one
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the shortest edit turning the lines a into the lines b,
+// computed from their longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var result []diffLine
+	for _, line := range a[:prefix] {
+		result = append(result, diffLine{' ', line})
+	}
+	result = append(result, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', line})
+	}
+	return result
+}
+
+func diffMiddle(a, b []string) []diffLine {
+	var result []diffLine
+	if (len(a)+1)*(len(b)+1) > MAX_DIFF_CELLS {
+		for _, line := range a {
+			result = append(result, diffLine{'-', line})
+		}
+		for _, line := range b {
+			result = append(result, diffLine{'+', line})
+		}
+		return result
+	}
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', a[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{'+', b[j]})
+	}
+	return result
+}
+
+// formatDiff renders the changed lines in color with context common lines
+// around them, the other common lines are summarized
+func formatDiff(lines []diffLine, context int) string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(lines)-1, i+context); j++ {
+			keep[j] = true
+		}
+	}
+	var sb strings.Builder
+	skipped := 0
+	for i, line := range lines {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			fmt.Fprintf(&sb, "\x1b[0;36m@@ %d identical lines @@\x1b[0;0m\n", skipped)
+			skipped = 0
+		}
+		switch line.op {
+		case '-':
+			fmt.Fprintf(&sb, "\x1b[0;31m- %s\x1b[0;0m\n", line.text)
+		case '+':
+			fmt.Fprintf(&sb, "\x1b[0;32m+ %s\x1b[0;0m\n", line.text)
+		default:
+			fmt.Fprintf(&sb, "  %s\n", line.text)
+		}
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "\x1b[0;36m@@ %d identical lines @@\x1b[0;0m\n", skipped)
+	}
+	return sb.String()
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("HTTP/1.1 200 OK\nContent-Type: application/json\n\n{\n  \"id\": 1,\n  \"env\": \"staging\"\n}", "\n")
+	b := strings.Split("HTTP/1.1 200 OK\nContent-Type: application/json\n\n{\n  \"id\": 1,\n  \"env\": \"production\",\n  \"beta\": false\n}", "\n")
+	var ops strings.Builder
+	for _, line := range diffLines(a, b) {
+		ops.WriteByte(line.op)
+	}
+	if ops.String() != "     -++ " {
+		t.Errorf("unexpected diff %q", ops.String())
+	}
+
+	formatted := formatDiff(diffLines(a, b), 1)
+	expected := "\x1b[0;36m@@ 4 identical lines @@\x1b[0;0m\n" +
+		"  " + `  "id": 1,` + "\n" +
+		"\x1b[0;31m- " + `  "env": "staging"` + "\x1b[0;0m\n" +
+		"\x1b[0;32m+ " + `  "env": "production",` + "\x1b[0;0m\n" +
+		"\x1b[0;32m+ " + `  "beta": false` + "\x1b[0;0m\n" +
+		"  }\n"
+	if formatted != expected {
+		t.Errorf("unexpected formatted diff %q", formatted)
+	}
+}
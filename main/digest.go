@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DIGEST_ALGORITHMS are the hash functions of the digest headers, indexed
+// by their lower case name
+var DIGEST_ALGORITHMS = map[string]func() hash.Hash{
+	"md5":     md5.New,
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// digestHeaderAlgorithms returns the algorithms of a digest header value
+// naming the algorithms to compute, e.g. "sha-256, sha-512". "auto" selects
+// SHA-256, the only value computing Content-MD5.
+func digestHeaderAlgorithms(header, value string) ([]string, bool) {
+	if header == "Content-Md5" {
+		return []string{"md5"}, strings.TrimSpace(value) == "auto"
+	}
+	var algorithms []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "auto" {
+			name = "sha-256"
+		}
+		if _, found := DIGEST_ALGORITHMS[name]; !found {
+			return nil, false
+		}
+		algorithms = append(algorithms, name)
+	}
+	return algorithms, true
+}
+
+// addDigestHeaders replaces the values of the Content-MD5, Digest and
+// Content-Digest headers naming an algorithm by the digest of the final
+// request body, e.g. "Content-Digest: sha-256" is sent as
+// "Content-Digest: sha-256=:BASE64:"
+func addDigestHeaders(req *http.Request) error {
+	type digestHeader struct {
+		name       string
+		algorithms []string
+	}
+	var headers []digestHeader
+	for _, name := range []string{"Content-Md5", "Digest", "Content-Digest"} {
+		if values := req.Header.Values(name); len(values) == 1 {
+			if algorithms, found := digestHeaderAlgorithms(name, values[0]); found {
+				headers = append(headers, digestHeader{name, algorithms})
+			}
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	body, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("cannot compute the digest of the request body: %v", err)
+	}
+	for _, h := range headers {
+		digests := make([]string, 0, len(h.algorithms))
+		for _, algorithm := range h.algorithms {
+			sum := DIGEST_ALGORITHMS[algorithm]()
+			sum.Write(body)
+			encoded := base64.StdEncoding.EncodeToString(sum.Sum(nil))
+			switch h.name {
+			case "Content-Md5":
+				digests = append(digests, encoded)
+			case "Digest":
+				digests = append(digests, strings.ToUpper(algorithm)+"="+encoded)
+			default:
+				digests = append(digests, algorithm+"=:"+encoded+":")
+			}
+		}
+		req.Header.Set(h.name, strings.Join(digests, ", "))
+	}
+	return nil
+}
+
+// requestBody returns the body of req, which is read again when it is sent
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	// streamed bodies are read in memory
+	defer req.Body.Close()
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAddDigestHeaders(t *testing.T) {
+	r := &Request{
+		Url:    "http://localhost/upload",
+		Method: http.MethodPut,
+		Data:   "hello",
+		Headers: "Content-MD5: auto\n" +
+			"Digest: SHA-256, md5\n" +
+			"Content-Digest: sha-512\n" +
+			"X-Other: sha-256",
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addDigestHeaders(req); err != nil {
+		t.Fatal(err)
+	}
+	for header, expected := range map[string]string{
+		"Content-MD5":    "XUFAKrxLKna5cZ2REBfFkg==",
+		"Digest":         "SHA-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=, MD5=XUFAKrxLKna5cZ2REBfFkg==",
+		"Content-Digest": "sha-512=:m3HSJL1i83hdltRq0+o9czGb+8KJDKra4t/3JRlnPKcjI8PZm6XBHXx6zG4UuMXaDEZjR1wuXDre9G9zvN7AQw==:",
+		"X-Other":        "sha-256",
+	} {
+		if value := req.Header.Get(header); value != expected {
+			t.Errorf("unexpected %s: %s", header, value)
+		}
+	}
+	body, _ := requestBody(req)
+	if string(body) != "hello" {
+		t.Errorf("request body modified: %q", body)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// editedWorkspaceRequest returns the content of the request views as a
+// workspace request named name
+func (a *App) editedWorkspaceRequest(g *gocui.Gui, name string) config.WorkspaceRequest {
+	r := config.WorkspaceRequest{
+		Name:      name,
+		Method:    getViewValue(g, REQUEST_METHOD_VIEW),
+		URL:       getViewValue(g, URL_VIEW),
+		Params:    getViewValue(g, URL_PARAMS_VIEW),
+		Headers:   getViewValue(g, REQUEST_HEADERS_VIEW),
+		Body:      getViewValue(g, REQUEST_DATA_VIEW),
+		Schema:    a.schema,
+		Notes:     a.notes,
+		Transport: a.transport.String(),
+	}
+	if auth := getViewValue(g, AUTH_VIEW); auth != DEFAULT_AUTH {
+		r.Auth = auth
+	}
+	if a.workspace != nil && a.group != "" {
+		// the copy follows the base URL the group is switched to
+		if path, found := relativeToBaseURL(a.groupBaseURL(a.group), r.URL); found {
+			r.Group, r.URL = a.group, path
+		}
+	}
+	return r
+}
+
+// relativeToBaseURL returns the path of rawURL relative to base, false if
+// rawURL is not below base
+func relativeToBaseURL(base, rawURL string) (string, bool) {
+	base = strings.TrimRight(base, "/")
+	if base == "" {
+		return "", false
+	}
+	if rawURL == base {
+		return "", true
+	}
+	if path, found := strings.CutPrefix(rawURL, base+"/"); found {
+		return "/" + path, true
+	}
+	return "", false
+}
+
+// requestFilename returns the file name of a saved request named name, its
+// path separators and the characters invalid in file names replaced
+func requestFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name) + ".json"
+}
+
+// workspaceRequestTOML renders r as a [[requests]] table of a workspace
+// file, without its empty fields
+func workspaceRequestTOML(r config.WorkspaceRequest) string {
+	output := &strings.Builder{}
+	output.WriteString("\n[[requests]]\n")
+	for _, field := range []struct{ key, value string }{
+		{"name", r.Name},
+		{"method", r.Method},
+		{"url", r.URL},
+		{"params", r.Params},
+		{"headers", r.Headers},
+		{"body", r.Body},
+		{"auth", r.Auth},
+		{"schema", r.Schema},
+		{"notes", r.Notes},
+		{"transport", r.Transport},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(output, "%v = %v\n", field.key, strconv.Quote(field.value))
+		}
+	}
+	return output.String()
+}
+
+// suggestedRequestName returns a name of the duplicate of the edited
+// request, from its method and URL path
+func suggestedRequestName(method, rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	return fmt.Sprintf("%v %v copy", method, path)
+}
+
+// OpenDuplicateDialog prompts for the name of the copy of the edited
+// request
+func (a *App) OpenDuplicateDialog(g *gocui.Gui, _ *gocui.View) error {
+	dialog, err := a.CreatePopupView(DUPLICATE_DIALOG_VIEW, 60, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[DUPLICATE_DIALOG_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, suggestedRequestName(getViewValue(g, REQUEST_METHOD_VIEW), getViewValue(g, URL_VIEW)))
+	g.SetViewOnTop(DUPLICATE_DIALOG_VIEW)
+	g.SetCurrentView(DUPLICATE_DIALOG_VIEW)
+	return nil
+}
+
+// submitDuplicate adds the edited request to the workspace under the
+// entered name, or saves it as NAME.json without workspace
+func (a *App) submitDuplicate(g *gocui.Gui, _ *gocui.View) error {
+	name := strings.TrimSpace(getViewValue(g, DUPLICATE_DIALOG_VIEW))
+	if name == "" {
+		return nil
+	}
+	a.closePopup(g, DUPLICATE_DIALOG_VIEW)
+	r := a.editedWorkspaceRequest(g, name)
+	if a.workspace == nil {
+		path := requestFilename(name)
+		request := exportJSON(Request{
+			Url:       r.URL,
+			Method:    r.Method,
+			GetParams: r.Params,
+			Data:      r.Body,
+			Headers:   r.Headers,
+			Auth:      r.Auth,
+			Schema:    r.Schema,
+			Notes:     r.Notes,
+			Transport: a.transport,
+		})
+		if err := os.WriteFile(path, request, 0o644); err != nil {
+			return a.OpenMessageView("Error: "+err.Error(), g)
+		}
+		return a.OpenMessageView("Request duplicated to "+path+", ctrl+f loads it", g)
+	}
+	file, err := os.OpenFile(a.workspacePath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	_, err = file.WriteString(workspaceRequestTOML(r))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	a.workspace.Requests = append(a.workspace.Requests, r)
+	return a.OpenMessageView(fmt.Sprintf("Request duplicated to %q in %v, ctrl+g lists it", name, a.workspacePath), g)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestWorkspaceRequestTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.toml")
+	original := "[[requests]]\nname = \"List users\"\nurl = \"https://example.com/users\"\n"
+	r := config.WorkspaceRequest{
+		Name:    "Create user copy",
+		Method:  "POST",
+		URL:     "https://example.com/users",
+		Headers: "Content-Type: application/json\nAccept: */*",
+		Body:    `{"name": "a \"quoted\" name"}`,
+		Notes:   "variant without email",
+	}
+	if err := os.WriteFile(path, []byte(original+workspaceRequestTOML(r)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf := config.DefaultConfig
+	workspace, err := config.LoadWorkspace(path, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workspace.Requests) != 2 || !reflect.DeepEqual(workspace.Requests[1], r) {
+		t.Errorf("unexpected requests %+v", workspace.Requests)
+	}
+}
+
+func TestSuggestedRequestName(t *testing.T) {
+	if name := suggestedRequestName("GET", "https://example.com/users?page=2"); name != "GET /users copy" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
+
+func TestRequestFilename(t *testing.T) {
+	name := suggestedRequestName("GET", "https://example.com/api/users")
+	if filename := requestFilename(name); filename != "GET _api_users copy.json" {
+		t.Errorf("unexpected file name %q", filename)
+	}
+}
+
+func TestRelativeToBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		base, url, path string
+		found           bool
+	}{
+		{"https://staging.example.com/", "https://staging.example.com/users/1", "/users/1", true},
+		{"https://staging.example.com", "https://staging.example.com", "", true},
+		{"https://staging.example.com", "https://staging.example.community/users", "", false},
+		{"https://staging.example.com", "https://prod.example.com/users", "", false},
+	} {
+		if path, found := relativeToBaseURL(tc.base, tc.url); path != tc.path || found != tc.found {
+			t.Errorf("%v %v: expected %q %v, got %q %v", tc.base, tc.url, tc.path, tc.found, path, found)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DYNAMIC_VARIABLES are the functions of the {{name args...}} placeholders
+// expanded each time a request is sent
+var DYNAMIC_VARIABLES = map[string]func(args []string) (string, error){
+	"uuid":      dynamicUUID,
+	"now":       dynamicNow,
+	"timestamp": dynamicTimestamp,
+	"randInt":   dynamicRandInt,
+}
+
+// DYNAMIC_VARIABLE_PATTERN matches {{name}} and {{name arg "quoted arg"}}
+var DYNAMIC_VARIABLE_PATTERN = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.]*)((?:\s+(?:"[^"]*"|[^\s"}]+))*)\s*\}\}`)
+
+var DYNAMIC_ARGUMENT_PATTERN = regexp.MustCompile(`"[^"]*"|[^\s"]+`)
+
+// isDynamicVariable reports whether the placeholder name is expanded when
+// the request is sent instead of being prompted for
+func isDynamicVariable(name string) bool {
+	_, found := DYNAMIC_VARIABLES[name]
+	return found
+}
+
+// expandDynamicVariables replaces the dynamic variables of text by their
+// value, other placeholders are kept
+func expandDynamicVariables(text string) (string, error) {
+	var err error
+	expanded := DYNAMIC_VARIABLE_PATTERN.ReplaceAllStringFunc(text, func(placeholder string) string {
+		m := DYNAMIC_VARIABLE_PATTERN.FindStringSubmatch(placeholder)
+		variable, found := DYNAMIC_VARIABLES[m[1]]
+		if !found || err != nil {
+			return placeholder
+		}
+		var args []string
+		for _, arg := range DYNAMIC_ARGUMENT_PATTERN.FindAllString(m[2], -1) {
+			args = append(args, strings.Trim(arg, `"`))
+		}
+		value, verr := variable(args)
+		if verr != nil {
+			err = fmt.Errorf("Invalid dynamic variable %v: %v", placeholder, verr)
+			return placeholder
+		}
+		return value
+	})
+	return expanded, err
+}
+
+func dynamicUUID(args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("no argument expected")
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// dynamicNow formats the current time with the Go layout of its argument,
+// RFC 3339 by default
+func dynamicNow(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return time.Now().Format(time.RFC3339), nil
+	case 1:
+		return time.Now().Format(args[0]), nil
+	}
+	return "", fmt.Errorf("expected an optional layout")
+}
+
+// dynamicTimestamp returns the current Unix time in seconds
+func dynamicTimestamp(args []string) (string, error) {
+	if len(args) != 0 {
+		return "", fmt.Errorf("no argument expected")
+	}
+	return strconv.FormatInt(time.Now().Unix(), 10), nil
+}
+
+// dynamicRandInt returns a random integer between its arguments, both
+// included
+func dynamicRandInt(args []string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("expected a minimum and a maximum")
+	}
+	min, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return "", err
+	}
+	max, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", err
+	}
+	if max < min {
+		return "", fmt.Errorf("the maximum is lower than the minimum")
+	}
+	// the width of the range may not fit in an int64, it is drawn in
+	// uint64 where the subtraction and the addition wrap as expected
+	offset := RANDOM.uint64Max(uint64(max) - uint64(min))
+	return strconv.FormatInt(int64(uint64(min)+offset), 10), nil
+}
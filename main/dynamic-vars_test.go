@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestExpandDynamicVariables(t *testing.T) {
+	text := `{"id": "{{uuid}}", "day": "{{ now "2006-01-02" }}", "n": {{randInt 1 3}}, "user": "{{user}}", "at": {{timestamp}}}`
+	expanded, err := expandDynamicVariables(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pattern := regexp.MustCompile(`^\{"id": "[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}", "day": "(\d{4}-\d\d-\d\d)", "n": ([123]), "user": "\{\{user\}\}", "at": (\d+)\}$`)
+	m := pattern.FindStringSubmatch(expanded)
+	if m == nil {
+		t.Fatalf("unexpected expansion %q", expanded)
+	}
+	if m[1] != time.Now().Format("2006-01-02") {
+		t.Errorf("unexpected date %v", m[1])
+	}
+	if at, _ := strconv.ParseInt(m[3], 10, 64); time.Since(time.Unix(at, 0)) > time.Minute {
+		t.Errorf("unexpected timestamp %v", m[3])
+	}
+
+	for _, invalid := range []string{"{{randInt 5 1}}", "{{randInt one 2}}", "{{uuid 4}}"} {
+		if _, err := expandDynamicVariables(invalid); err == nil {
+			t.Errorf("%v: expected an error", invalid)
+		}
+	}
+}
+
+func TestDynamicRandIntBounds(t *testing.T) {
+	for _, bounds := range [][2]int64{
+		{0, math.MaxInt64},
+		{-1, math.MaxInt64},
+		{math.MinInt64, math.MaxInt64},
+		{math.MinInt64, math.MinInt64},
+		{math.MaxInt64, math.MaxInt64},
+		{-3, -1},
+	} {
+		min, max := strconv.FormatInt(bounds[0], 10), strconv.FormatInt(bounds[1], 10)
+		for i := 0; i < 100; i++ {
+			value, err := dynamicRandInt([]string{min, max})
+			if err != nil {
+				t.Fatalf("%v %v: %v", min, max, err)
+			}
+			if n, _ := strconv.ParseInt(value, 10, 64); n < bounds[0] || n > bounds[1] {
+				t.Fatalf("%v %v: %v out of range", min, max, value)
+			}
+		}
+	}
+}
+
+func TestFindPlaceholdersSkipsDynamicVariables(t *testing.T) {
+	names := findPlaceholders(map[string]string{
+		URL_VIEW:          "https://{{host}}/items/{{uuid}}",
+		REQUEST_DATA_VIEW: `{"at": "{{now "15:04"}}", "token": "{{token}}"}`,
+	})
+	if len(names) != 2 || names[0] != "host" || names[1] != "token" {
+		t.Errorf("unexpected placeholders %v", names)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// DIFF_IGNORED_HEADERS are the response headers left out of the comparison
+// of environments, they differ between any two responses
+var DIFF_IGNORED_HEADERS = map[string]bool{
+	"Date": true,
+}
+
+// OpenCompareDialog prompts for the two environments of the workspace the
+// current request is sent to, their responses are compared
+func (a *App) OpenCompareDialog(g *gocui.Gui, _ *gocui.View) error {
+	if a.workspace == nil || len(a.workspace.Environments) == 0 {
+		return a.OpenMessageView("Error: open a workspace with environments to compare them", g)
+	}
+	names := a.environments()[1:]
+	first, second := a.environment, names[0]
+	if first == "" || first == second {
+		first = names[0]
+		if len(names) > 1 {
+			second = names[1]
+		}
+	}
+	dialog, err := a.CreatePopupView(COMPARE_VIEW, 60, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[COMPARE_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, first+" "+second)
+	g.SetViewOnTop(COMPARE_VIEW)
+	g.SetCurrentView(COMPARE_VIEW)
+	return nil
+}
+
+type environmentResponse struct {
+	environment string
+	text        string
+	err         error
+}
+
+func (a *App) submitCompare(g *gocui.Gui, _ *gocui.View) error {
+	names := strings.Fields(getViewValue(g, COMPARE_VIEW))
+	a.closePopup(g, COMPARE_VIEW)
+	if len(names) != 2 {
+		return a.OpenMessageView("Error: enter the names of two environments", g)
+	}
+
+	a.rememberURLCredentials(g)
+	// the views, the environments and the config are read on the UI
+	// goroutine, the requests are built in the background
+	var views Request
+	a.fillFromViews(g, &views)
+	conf := *a.config
+	responses := make([]environmentResponse, len(names))
+	variables := make([]map[string]string, len(names))
+	apiKeys := make([]*config.APIKey, len(names))
+	for i, name := range names {
+		environment, err := a.workspace.EnvironmentVariables(name)
+		if err != nil {
+			return a.OpenMessageView("Error: "+err.Error(), g)
+		}
+		variables[i], apiKeys[i] = environment, a.workspace.EnvironmentAPIKey(name)
+		responses[i].environment = name
+	}
+
+	vrb, _ := g.View(RESPONSE_BODY_VIEW)
+	vrb.Title = VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title + " comparing " + strings.Join(names, " and ") + "…"
+	go func() {
+		var wg sync.WaitGroup
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				r := views
+				req, err := a.newEnvironmentRequest(&r, variables[i], apiKeys[i], &conf)
+				if err == nil {
+					err = finalizeRequest(&r, req, &conf)
+				}
+				if err != nil {
+					responses[i].err = err
+					return
+				}
+				responses[i].text, responses[i].err = fetchComparableResponse(req, &conf)
+			}(i)
+		}
+		wg.Wait()
+		g.Update(func(g *gocui.Gui) error {
+			vrb, _ := g.View(RESPONSE_BODY_VIEW)
+			vrb.Title = VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title
+			for _, response := range responses {
+				if response.err != nil {
+					return a.OpenMessageView(fmt.Sprintf("Error: %v: %v", response.environment, response.err), g)
+				}
+			}
+			return a.openDiffView(g, responses[0], responses[1])
+		})
+	}()
+	return nil
+}
+
+// fetchComparableResponse sends req and renders its response as the status
+// line, the sorted headers and the body formatted with conf
+func fetchComparableResponse(req *http.Request, conf *config.Config) (string, error) {
+	response, err := CLIENT.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	var body io.Reader = response.Body
+	if response.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(response.Body)
+		if err != nil {
+			return "", fmt.Errorf("cannot uncompress response: %v", err)
+		}
+		defer reader.Close()
+		body = reader
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", response.Proto, response.Status)
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		if !DIFF_IGNORED_HEADERS[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range response.Header[name] {
+			fmt.Fprintf(&sb, "%s: %s\n", name, value)
+		}
+	}
+	sb.WriteString("\n")
+	formatted := &bytes.Buffer{}
+	if err := formatter.NewForBody(conf, response.Header.Get("Content-Type"), data).Format(formatted, data); err != nil {
+		formatted.Reset()
+		formatted.Write(data)
+	}
+	sb.Write(ANSI_ESCAPE_PATTERN.ReplaceAll(formatted.Bytes(), nil))
+	return sb.String(), nil
+}
+
+func (a *App) openDiffView(g *gocui.Gui, first, second environmentResponse) error {
+	maxX, maxY := g.Size()
+	view, err := a.CreatePopupView(DIFF_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	view.Title = fmt.Sprintf("- %v + %v %v", first.environment, second.environment, VIEW_TITLES[DIFF_VIEW])
+	view.Highlight = false
+	view.Wrap = false
+	if first.text == second.text {
+		fmt.Fprint(view, "The responses are identical\n")
+	} else {
+		fmt.Fprint(view, formatDiff(diffLines(strings.Split(first.text, "\n"), strings.Split(second.text, "\n")), 3))
+	}
+	g.SetViewOnTop(DIFF_VIEW)
+	g.SetCurrentView(DIFF_VIEW)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"sync"
+)
+
+// lockedRand is a random number generator safe for concurrent use
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// RANDOM generates the dynamic variables, it is seeded by the fakeSeed
+// option to generate the same values on every run
+var RANDOM = &lockedRand{r: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+
+func (l *lockedRand) seed(seed uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.r = rand.New(rand.NewPCG(seed, seed))
+}
+
+// intN returns an integer in [0, n)
+func (l *lockedRand) intN(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int64N(n)
+}
+
+// uint64Max returns an integer in [0, max]
+func (l *lockedRand) uint64Max(max uint64) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max == math.MaxUint64 {
+		return l.r.Uint64()
+	}
+	return l.r.Uint64N(max + 1)
+}
+
+func (l *lockedRand) pick(values []string) string {
+	return values[l.intN(int64(len(values)))]
+}
+
+var (
+	FAKE_FIRST_NAMES = []string{"Alice", "Bob", "Carol", "David", "Emma", "Farid", "Grace", "Hiro", "Ines", "Jonas", "Kofi", "Lena", "Mateo", "Nadia", "Oscar", "Priya", "Quentin", "Rosa", "Sven", "Yuki"}
+	FAKE_LAST_NAMES  = []string{"Anderson", "Bianchi", "Chen", "Dubois", "Evans", "Fischer", "Garcia", "Haddad", "Ivanova", "Jensen", "Kim", "Lopez", "Martin", "Nakamura", "Okafor", "Petrov", "Rossi", "Silva", "Tanaka", "Weber"}
+	FAKE_COMPANIES   = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries", "Wayne Enterprises", "Soylent", "Vandelay Industries", "Wonka"}
+	FAKE_CITIES      = []string{"Amsterdam", "Berlin", "Buenos Aires", "Cairo", "Lagos", "Lisbon", "Montreal", "Mumbai", "Osaka", "Paris", "Seoul", "Sydney", "Toronto", "Vienna"}
+	FAKE_COUNTRIES   = []string{"Argentina", "Australia", "Austria", "Canada", "Egypt", "France", "Germany", "India", "Japan", "Netherlands", "Nigeria", "Portugal", "South Korea"}
+	FAKE_STREETS     = []string{"Main Street", "Oak Avenue", "Park Road", "Station Road", "Church Lane", "High Street", "Maple Drive", "River Road"}
+	FAKE_DOMAINS     = []string{"example.com", "example.net", "example.org"}
+	FAKE_WORDS       = []string{"alpha", "bravo", "cloud", "delta", "echo", "falcon", "garden", "harbor", "island", "jungle", "kernel", "lemon", "meadow", "nebula", "orbit", "pepper", "quartz", "river", "summit", "timber"}
+)
+
+// FAKE_DATA generates the values of the {{fake.name}} dynamic variables
+var FAKE_DATA = map[string]func() string{
+	"firstName": func() string { return RANDOM.pick(FAKE_FIRST_NAMES) },
+	"lastName":  func() string { return RANDOM.pick(FAKE_LAST_NAMES) },
+	"name":      func() string { return RANDOM.pick(FAKE_FIRST_NAMES) + " " + RANDOM.pick(FAKE_LAST_NAMES) },
+	"username":  fakeUsername,
+	"email": func() string {
+		return fakeUsername() + "@" + RANDOM.pick(FAKE_DOMAINS)
+	},
+	"phone": func() string {
+		return fmt.Sprintf("+1-555-%03d-%04d", RANDOM.intN(1000), RANDOM.intN(10000))
+	},
+	"company": func() string { return RANDOM.pick(FAKE_COMPANIES) },
+	"street": func() string {
+		return fmt.Sprintf("%d %s", 1+RANDOM.intN(999), RANDOM.pick(FAKE_STREETS))
+	},
+	"city":    func() string { return RANDOM.pick(FAKE_CITIES) },
+	"country": func() string { return RANDOM.pick(FAKE_COUNTRIES) },
+	"zip":     func() string { return fmt.Sprintf("%05d", RANDOM.intN(100000)) },
+	"word":    func() string { return RANDOM.pick(FAKE_WORDS) },
+	"sentence": func() string {
+		words := make([]string, 4+RANDOM.intN(5))
+		for i := range words {
+			words[i] = RANDOM.pick(FAKE_WORDS)
+		}
+		return strings.ToUpper(words[0][:1]) + strings.Join(words, " ")[1:] + "."
+	},
+	"ipv4": func() string {
+		return fmt.Sprintf("%d.%d.%d.%d", 1+RANDOM.intN(223), RANDOM.intN(256), RANDOM.intN(256), 1+RANDOM.intN(254))
+	},
+}
+
+func fakeUsername() string {
+	return fmt.Sprintf("%s.%s%d", strings.ToLower(RANDOM.pick(FAKE_FIRST_NAMES)), strings.ToLower(RANDOM.pick(FAKE_LAST_NAMES)), RANDOM.intN(100))
+}
+
+func init() {
+	for name, generate := range FAKE_DATA {
+		generate := generate
+		DYNAMIC_VARIABLES["fake."+name] = func(args []string) (string, error) {
+			if len(args) != 0 {
+				return "", fmt.Errorf("no argument expected")
+			}
+			return generate(), nil
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFakeData(t *testing.T) {
+	expand := func() string {
+		expanded, err := expandDynamicVariables("{{fake.name}} <{{fake.email}}> {{randInt 1 1000000}} {{ fake.city }}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return expanded
+	}
+	RANDOM.seed(42)
+	first := expand()
+	RANDOM.seed(42)
+	if second := expand(); first != second {
+		t.Errorf("the same seed generated %q and %q", first, second)
+	}
+	if !regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+ <[a-z]+\.[a-z]+\d+@example\.(com|net|org)> \d+ [A-Z][a-z ]+$`).MatchString(first) {
+		t.Errorf("unexpected fake data %q", first)
+	}
+	if _, err := expandDynamicVariables("{{fake.name 2}}"); err == nil {
+		t.Error("expected an error for an argument")
+	}
+	if isDynamicVariable("fake.unknown") {
+		t.Error("unknown fake data is not a dynamic variable")
+	}
+}
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// GRAPHQL_INTROSPECTION_QUERY fetches the types of a GraphQL schema with
+// their fields and arguments
+const GRAPHQL_INTROSPECTION_QUERY = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) { name args { name type { ...TypeRef } } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// GRAPHQL_KEYWORDS are completed outside of the selection sets
+var GRAPHQL_KEYWORDS = []string{"query", "mutation", "subscription", "fragment"}
+
+// graphQLTypeRef is the type of a field or an argument, the named type
+// wrapped in lists and non null types
+type graphQLTypeRef struct {
+	Kind   string
+	Name   string
+	OfType *graphQLTypeRef
+}
+
+// named returns the name of the wrapped type
+func (t *graphQLTypeRef) named() string {
+	for t != nil && t.Name == "" {
+		t = t.OfType
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name
+}
+
+type graphQLField struct {
+	Name string
+	Args []graphQLField
+	Type *graphQLTypeRef
+}
+
+type graphQLType struct {
+	Kind        string
+	Name        string
+	Fields      []graphQLField
+	InputFields []graphQLField
+}
+
+// graphQLSchema is the result of the introspection of a GraphQL endpoint
+type graphQLSchema struct {
+	query, mutation, subscription string
+	types                         map[string]*graphQLType
+}
+
+// parseIntrospection returns the schema of the response to
+// GRAPHQL_INTROSPECTION_QUERY
+func parseIntrospection(body []byte) (*graphQLSchema, error) {
+	type rootType struct{ Name string }
+	var response struct {
+		Data struct {
+			Schema *struct {
+				QueryType        *rootType
+				MutationType     *rootType
+				SubscriptionType *rootType
+				Types            []*graphQLType
+			} `json:"__schema"`
+		}
+		Errors []struct {
+			Message string
+		}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %v", err)
+	}
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("introspection failed: %v", response.Errors[0].Message)
+	}
+	if response.Data.Schema == nil {
+		return nil, fmt.Errorf("the response has no schema")
+	}
+	s := &graphQLSchema{types: make(map[string]*graphQLType)}
+	for _, t := range response.Data.Schema.Types {
+		s.types[t.Name] = t
+	}
+	for _, root := range []struct {
+		name  *string
+		value *rootType
+	}{
+		{&s.query, response.Data.Schema.QueryType},
+		{&s.mutation, response.Data.Schema.MutationType},
+		{&s.subscription, response.Data.Schema.SubscriptionType},
+	} {
+		if root.value != nil {
+			*root.name = root.value.Name
+		}
+	}
+	return s, nil
+}
+
+// field returns the field name of the type typeName
+func (s *graphQLSchema) field(typeName, name string) *graphQLField {
+	t := s.types[typeName]
+	if t == nil {
+		return nil
+	}
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// typeNames returns the sorted names of the types of the kinds
+func (s *graphQLSchema) typeNames(kinds ...string) []string {
+	var names []string
+	for name, t := range s.types {
+		for _, kind := range kinds {
+			if t.Kind == kind && !strings.HasPrefix(name, "__") {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// graphQLTokens splits a GraphQL document into names, variables,
+// punctuators and values, the comments are dropped
+func graphQLTokens(text string) []string {
+	var tokens []string
+	runes := []rune(text)
+	isName := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == '"':
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case r == '.' && i+2 < len(runes) && runes[i+1] == '.' && runes[i+2] == '.':
+			i += 2
+		case r == '$' || r == '-' || isName(r):
+			for i+1 < len(runes) && isName(runes[i+1]) {
+				i++
+			}
+		}
+		tokens = append(tokens, string(runes[start:minInt(i+1, len(runes))]))
+	}
+	return tokens
+}
+
+func isGraphQLName(token string) bool {
+	return token != "" && (token[0] == '_' || unicode.IsLetter(rune(token[0])))
+}
+
+// graphQLPosition is what can be typed at the end of a GraphQL document
+type graphQLPosition struct {
+	// type of the selection set, empty outside of the selection sets
+	selection string
+	// field whose arguments are typed and the arguments already typed
+	arguments      *graphQLField
+	typedArguments map[string]bool
+	// type condition of a fragment or type of a variable
+	typeCondition, variableType bool
+}
+
+// graphQLCursorPosition parses the document typed before the cursor
+func (s *graphQLSchema) graphQLCursorPosition(text string) graphQLPosition {
+	var stack []string
+	var root, pendingType string
+	var lastField *graphQLField
+	var expectType bool
+	// arguments of a field or of a directive, or variables of an operation
+	var parenDepth, listDepth int
+	var parenField *graphQLField
+	var variables, argumentValue bool
+	typedArguments := make(map[string]bool)
+	previous, beforePrevious := "", ""
+
+	for _, token := range graphQLTokens(text) {
+		if parenDepth > 0 {
+			switch token {
+			case "(", "{":
+				parenDepth++
+			case ")", "}":
+				parenDepth--
+			case "[":
+				listDepth++
+			case "]":
+				listDepth--
+			}
+			if parenDepth == 1 && listDepth == 0 {
+				// the value of an argument ends with its first token
+				argumentValue = token == ":"
+				if argumentValue {
+					typedArguments[previous] = true
+				}
+			}
+			previous, beforePrevious = token, previous
+			continue
+		}
+		switch {
+		case expectType:
+			pendingType = token
+			expectType = false
+		case token == "on" && (previous == "..." || len(stack) == 0):
+			expectType = true
+		case len(stack) == 0 && (token == "query" || token == "mutation" || token == "subscription"):
+			root = map[string]string{"query": s.query, "mutation": s.mutation, "subscription": s.subscription}[token]
+		case token == "(":
+			parenDepth, listDepth, argumentValue = 1, 0, false
+			variables, parenField = len(stack) == 0, nil
+			typedArguments = make(map[string]bool)
+			if !variables && isGraphQLName(previous) && beforePrevious != "@" {
+				parenField = lastField
+			}
+		case token == "{":
+			switch {
+			case pendingType != "":
+				stack = append(stack, pendingType)
+			case len(stack) == 0 && root != "":
+				stack = append(stack, root)
+			case len(stack) == 0:
+				stack = append(stack, s.query)
+			case lastField != nil:
+				stack = append(stack, lastField.Type.named())
+			default:
+				stack = append(stack, "")
+			}
+			root, pendingType, lastField = "", "", nil
+		case token == "}":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			lastField = nil
+		case len(stack) > 0 && isGraphQLName(token) && previous != "@":
+			lastField = s.field(stack[len(stack)-1], token)
+		}
+		previous, beforePrevious = token, previous
+	}
+
+	switch {
+	case expectType:
+		return graphQLPosition{typeCondition: true}
+	case parenDepth > 0:
+		if variables {
+			return graphQLPosition{variableType: previous == ":" || previous == "["}
+		}
+		if parenDepth > 1 || listDepth > 0 || argumentValue || parenField == nil {
+			return graphQLPosition{}
+		}
+		return graphQLPosition{arguments: parenField, typedArguments: typedArguments}
+	case len(stack) > 0:
+		return graphQLPosition{selection: stack[len(stack)-1]}
+	}
+	return graphQLPosition{}
+}
+
+// completions returns the names which can complete symbol at the end of the
+// document text: fields of the selection set, arguments, types or keywords
+func (s *graphQLSchema) completions(text, symbol string) []string {
+	position := s.graphQLCursorPosition(strings.TrimSuffix(text, symbol))
+	var names []string
+	switch {
+	case position.typeCondition:
+		names = s.typeNames("OBJECT", "INTERFACE", "UNION")
+	case position.variableType:
+		names = s.typeNames("SCALAR", "ENUM", "INPUT_OBJECT")
+	case position.arguments != nil:
+		for _, arg := range position.arguments.Args {
+			if !position.typedArguments[arg.Name] {
+				names = append(names, arg.Name)
+			}
+		}
+	case position.selection != "":
+		if t := s.types[position.selection]; t != nil {
+			for _, field := range t.Fields {
+				names = append(names, field.Name)
+			}
+		}
+		names = append(names, "__typename")
+	default:
+		if tokens := graphQLTokens(text); len(tokens) <= 1 || tokens[len(tokens)-2] == "}" {
+			names = GRAPHQL_KEYWORDS
+		}
+	}
+	return completeFromSlice(symbol, names)
+}
+
+var graphQLQueryPattern = regexp.MustCompile(`"query"\s*:\s*"`)
+
+// graphQLDocument returns the GraphQL document of a request body, either
+// the body itself or the "query" string of a JSON body
+func graphQLDocument(body string) string {
+	matches := graphQLQueryPattern.FindAllStringIndex(body, -1)
+	if len(matches) == 0 {
+		return body
+	}
+	query := body[matches[len(matches)-1][1]:]
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(query)
+}
+
+// textBeforeCursor returns the content of the unwrapped view v up to the
+// cursor
+func textBeforeCursor(v *gocui.View) string {
+	cx, cy := v.Cursor()
+	ox, oy := v.Origin()
+	lines := v.BufferLines()
+	if cy+oy >= len(lines) {
+		return strings.Join(lines, "\n")
+	}
+	line := []rune(lines[cy+oy])
+	return strings.Join(append(lines[:cy+oy:cy+oy], string(line[:minInt(cx+ox, len(line))])), "\n")
+}
+
+// graphQLCompletions completes the field, argument and type names of the
+// query of the data view once the GraphQL schema is loaded
+func (a *App) graphQLCompletions(v *gocui.View, symbol string) []string {
+	if a == nil || a.graphQL == nil {
+		return nil
+	}
+	return a.graphQL.completions(graphQLDocument(textBeforeCursor(v)), symbol)
+}
+
+// introspectGraphQL sends the introspection query to the URL of the request
+// views, with their headers and authentication, the variables and API key
+// of the environment and conf
+func (a *App) introspectGraphQL(r *Request, variables map[string]string, apiKey *config.APIKey, conf *config.Config) (*graphQLSchema, error) {
+	query, _ := json.Marshal(map[string]string{"query": GRAPHQL_INTROSPECTION_QUERY})
+	r.Method = http.MethodPost
+	r.Data = string(query)
+	r.Headers = setHeaderLine(r.Headers, "Content-Type", "application/json")
+	req, err := a.newEnvironmentRequest(r, variables, apiKey, conf)
+	if err == nil {
+		err = finalizeRequest(r, req, conf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response, err := CLIENT.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	LOGGER.Info("GraphQL schema introspected", "url", req.URL.String(), "status", response.StatusCode)
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("%v: %v", req.URL, response.Status)
+	}
+	return parseIntrospection(body)
+}
+
+// ToggleGraphQL loads the schema of the GraphQL endpoint of the URL view,
+// its fields and types are then completed in the data view, or leaves the
+// GraphQL mode
+func (a *App) ToggleGraphQL(g *gocui.Gui, _ *gocui.View) error {
+	if a.graphQL != nil {
+		a.graphQL = nil
+		refreshStatusLine(a, g)
+		return nil
+	}
+	r := &Request{
+		Url:       getViewValue(g, URL_VIEW),
+		GetParams: getViewValue(g, URL_PARAMS_VIEW),
+		Headers:   getViewValue(g, REQUEST_HEADERS_VIEW),
+		Auth:      getViewValue(g, AUTH_VIEW),
+	}
+	// copied on the UI goroutine which changes them
+	variables, apiKey, conf := copyVariables(a.variables), a.apiKey, *a.config
+	go func() {
+		schema, err := a.introspectGraphQL(r, variables, apiKey, &conf)
+		g.Update(func(g *gocui.Gui) error {
+			if err != nil {
+				return a.OpenMessageView("GraphQL schema not loaded: "+err.Error(), g)
+			}
+			a.graphQL = schema
+			refreshStatusLine(a, g)
+			return nil
+		})
+	}()
+	return nil
+}
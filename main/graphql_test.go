@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testIntrospection = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": null,
+  "types": [
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+       "type": {"kind": "OBJECT", "name": "User"}},
+      {"name": "users", "args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}}, {"name": "filter", "type": {"kind": "INPUT_OBJECT", "name": "UserFilter"}}],
+       "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User"}}}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "updateUser", "args": [], "type": {"kind": "OBJECT", "name": "User"}}
+    ]},
+    {"kind": "OBJECT", "name": "User", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}},
+      {"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+      {"name": "nickname", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+      {"name": "friends", "args": [], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User"}}}
+    ]},
+    {"kind": "INPUT_OBJECT", "name": "UserFilter", "inputFields": [{"name": "name", "type": {"kind": "SCALAR", "name": "String"}}]},
+    {"kind": "SCALAR", "name": "ID"},
+    {"kind": "SCALAR", "name": "Int"},
+    {"kind": "SCALAR", "name": "String"},
+    {"kind": "OBJECT", "name": "__Type", "fields": []}
+  ]
+}}}`
+
+func TestGraphQLCompletions(t *testing.T) {
+	schema, err := parseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		text, symbol string
+		expected     []string
+	}{
+		{"{ u", "u", []string{"user", "users"}},
+		{"{ user(id: 1) { n", "n", []string{"name", "nickname"}},
+		{"query Q { users { friends { na", "na", []string{"name"}},
+		{"{ user { name } us", "us", []string{"user", "users"}},
+		{"mutation { up", "up", []string{"updateUser"}},
+		{"{ me: user(id: 1) { ni", "ni", []string{"nickname"}},
+		{"{ users(fi", "fi", []string{"first", "filter"}},
+		{"{ users(first: 2, fi", "fi", []string{"filter"}},
+		{"{ users(first: fi", "fi", []string{}},
+		{"{ user { ... on U", "U", []string{"User"}},
+		{"query Q($f: U", "U", []string{"UserFilter"}},
+		{"{ user { friends @include(if: $x) { id } } # comment n", "n", []string{}},
+		{"q", "q", []string{"query"}},
+		{"{ user { __t", "__t", []string{"__typename"}},
+	} {
+		if result := schema.completions(test.text, test.symbol); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.text, test.expected, result)
+		}
+	}
+
+	if _, err := parseIntrospection([]byte(`{"errors": [{"message": "introspection disabled"}]}`)); err == nil {
+		t.Error("expected an error for an introspection response with errors")
+	}
+}
+
+func TestGraphQLDocument(t *testing.T) {
+	if document := graphQLDocument(`{"query": "{ user(name: \"x\") {\n id`); document != "{ user(name: \"x\") {\n id" {
+		t.Errorf("unexpected document %q", document)
+	}
+	if document := graphQLDocument("{ user { id"); document != "{ user { id" {
+		t.Errorf("unexpected document %q", document)
+	}
+}
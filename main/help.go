@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// helpEditor filters the help by the typed text
+type helpEditor struct {
+	app *App
+}
+
+func (e *helpEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	switch {
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		if filter := []rune(e.app.helpFilter); len(filter) > 0 {
+			e.app.helpFilter = string(filter[:len(filter)-1])
+		}
+	case key == gocui.KeySpace:
+		e.app.helpFilter += " "
+	case ch != 0 && mod == gocui.ModNone:
+		e.app.helpFilter += string(ch)
+	default:
+		return
+	}
+	e.app.renderHelp(v)
+}
+
+// ToggleHelp shows the keybindings grouped by view with the descriptions of
+// their commands, followed by the commands without a key
+func (a *App) ToggleHelp(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == HELP_VIEW {
+		a.closePopup(g, HELP_VIEW)
+		return nil
+	}
+	maxX, maxY := g.Size()
+	help, err := a.CreatePopupView(HELP_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	help.Highlight = false
+	help.Editable = true
+	help.Editor = &helpEditor{a}
+	a.helpFilter = ""
+	a.renderHelp(help)
+	g.SetViewOnTop(HELP_VIEW)
+	g.SetCurrentView(HELP_VIEW)
+	return nil
+}
+
+func (a *App) renderHelp(v *gocui.View) {
+	v.Title = VIEW_TITLES[HELP_VIEW] + " (type to filter)"
+	if a.helpFilter != "" {
+		v.Title = fmt.Sprintf("%v [filter: %v]", VIEW_TITLES[HELP_VIEW], a.helpFilter)
+	}
+	v.Clear()
+	v.SetOrigin(0, 0)
+	fmt.Fprint(v, helpText(a.config.Keys, a.helpFilter))
+}
+
+// helpText lists the keybindings and the unbound commands matching filter,
+// a category matching it is listed entirely
+func helpText(keys map[string]map[string]string, filter string) string {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	var out strings.Builder
+	writeGroup := func(name string, lines []string) {
+		categoryMatches := strings.Contains(name, filter)
+		var matching []string
+		for _, line := range lines {
+			if categoryMatches || strings.Contains(strings.ToLower(line), filter) {
+				matching = append(matching, line)
+			}
+		}
+		if len(matching) > 0 {
+			fmt.Fprintf(&out, " %v\n%v\n", name, strings.Join(matching, "\n"))
+		}
+	}
+
+	bound := make(map[string]bool)
+	for _, category := range sortedCategories(keys) {
+		names := make([]string, 0, len(keys[category]))
+		for key, command := range keys[category] {
+			if command != "" {
+				names = append(names, key)
+			}
+		}
+		sort.Strings(names)
+		lines := make([]string, 0, len(names))
+		for _, key := range names {
+			command := keys[category][key]
+			name := strings.SplitN(command, " ", 2)[0]
+			bound[name] = true
+			lines = append(lines, fmt.Sprintf("  %-15v %-28v %v", key, command, COMMAND_DESCRIPTIONS[name]))
+		}
+		writeGroup(category, lines)
+	}
+
+	var unbound []string
+	for name := range COMMANDS {
+		if !bound[name] {
+			unbound = append(unbound, fmt.Sprintf("  %-15v %-28v %v", "", name, COMMAND_DESCRIPTIONS[name]))
+		}
+	}
+	sort.Strings(unbound)
+	writeGroup("unbound commands", unbound)
+	writeGroup("fixed", []string{fmt.Sprintf("  %-15v %-28v %v", "F1", "", "Show or close this help")})
+
+	if out.Len() == 0 {
+		return "No keybinding or command matches " + filter
+	}
+	return out.String()
+}
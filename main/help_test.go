@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestCommandDescriptions(t *testing.T) {
+	for name := range COMMANDS {
+		if COMMAND_DESCRIPTIONS[name] == "" {
+			t.Errorf("%v has no description", name)
+		}
+	}
+}
+
+func TestHelpText(t *testing.T) {
+	help := helpText(config.DefaultKeys, "")
+	for _, expected := range []string{" global\n", "CtrlR", "Send the request", " response-body\n", " unbound commands\n", "reloadConfig"} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("%q not found in the help", expected)
+		}
+	}
+
+	help = helpText(config.DefaultKeys, "JSONPath")
+	if !strings.Contains(help, "copyJSONPath") || !strings.Contains(help, "extractJSONPath") || strings.Contains(help, "toggleWrap") || strings.Contains(help, " global\n") {
+		t.Errorf("unexpected help filtered by command:\n%v", help)
+	}
+
+	help = helpText(config.DefaultKeys, "multipart")
+	if !strings.Contains(help, " multipart\n") || !strings.Contains(help, "Insert") {
+		t.Errorf("unexpected help filtered by category:\n%v", help)
+	}
+
+	if help := helpText(config.DefaultKeys, "nothing like this"); !strings.HasPrefix(help, "No keybinding") {
+		t.Errorf("unexpected help %q", help)
+	}
+}
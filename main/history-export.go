@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alessio/shellescape"
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// HISTORY_SCRIPT_FILENAME is the suggested name of the exported history
+const HISTORY_SCRIPT_FILENAME = "buzz-history.sh"
+
+// DIGEST_HEADERS are the headers computed from the request body sent
+var DIGEST_HEADERS = []string{"Content-Md5", "Digest", "Content-Digest"}
+
+// historyCurlCommand returns the curl command sending the request of the
+// history entry r with the headers it was sent with. The headers curl sets
+// itself for the body are left out: the multipart boundary, the gzip
+// encoding of buzz and the digests of the body buzz sent.
+func historyCurlCommand(r *Request) string {
+	args := []string{"curl", "-X", r.Method}
+	headersText, _ := splitTrailers(r.Headers)
+	typed, _ := parseRequestHeaders(headersText)
+	header := r.RequestHeader
+	if header == nil {
+		header = typed
+	}
+	multipart := typed.Get("Content-Type") == config.ContentTypes["multipart"]
+	compressed := header.Get("Content-Encoding") == "gzip" && typed.Get("Content-Encoding") == ""
+	skipped := map[string]bool{"Content-Type": multipart, "Content-Encoding": compressed}
+	for _, name := range DIGEST_HEADERS {
+		skipped[name] = multipart || compressed
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if r.UserinfoAuth && name == "Authorization" {
+			// the URL credentials are hidden in the history
+			continue
+		}
+		if skipped[name] {
+			continue
+		}
+		for _, value := range header[name] {
+			if value == "" {
+				// an empty value removes a default header of curl
+				args = append(args, "-H", shellescape.Quote(name+":"))
+				continue
+			}
+			args = append(args, "-H", shellescape.Quote(name+": "+value))
+		}
+	}
+	args = append(args, curlBodyArgs(r.Data, typed.Get("Content-Type"))...)
+	args = append(args, r.Transport.curlArgs()...)
+	args = append(args, shellescape.Quote(r.fullURL()))
+	return strings.Join(args, " ")
+}
+
+// curlBodyArgs returns the curl options sending the body data the way
+// newHTTPRequest builds it for the typed Content-Type
+func curlBodyArgs(data, contentType string) []string {
+	if data == "" {
+		return nil
+	}
+	if contentType == config.ContentTypes["multipart"] {
+		parts, err := parseMultipartParts(data)
+		if err == nil {
+			var args []string
+			for _, part := range parts {
+				switch {
+				case part.File && part.ContentType != "":
+					args = append(args, "-F", shellescape.Quote(part.Name+"=@"+part.Value+";type="+part.ContentType))
+				case part.File:
+					args = append(args, "-F", shellescape.Quote(part.Name+"=@"+part.Value))
+				default:
+					// sent as it is, even if it starts with @ or <
+					args = append(args, "--form-string", shellescape.Quote(part.Name+"="+part.Value))
+				}
+			}
+			return args
+		}
+	}
+	if _, found := bodyFilePath(data); found {
+		return []string{"--data-binary", shellescape.Quote(data)}
+	}
+	if contentType == "application/x-www-form-urlencoded" {
+		data = strings.ReplaceAll(data, "\n", "&")
+	}
+	if strings.HasPrefix(data, "@") {
+		// not a file, curl would read one
+		return []string{"--data-raw", shellescape.Quote(data)}
+	}
+	return []string{"--data-binary", shellescape.Quote(data)}
+}
+
+// commentLines returns text with every line commented out
+func commentLines(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "#   " + strings.TrimRight(line, "\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// exportHistoryScript returns a shell script sending the requests of
+// history with curl in the same order. Raw requests and TCP connections
+// cannot be sent by curl, they are included as comments.
+func exportHistoryScript(history []*Request, exported time.Time) []byte {
+	script := &strings.Builder{}
+	fmt.Fprintf(script, "#!/bin/sh\n# %d requests of a buzz session, exported on %v\n", len(history), exported.Format(time.RFC3339))
+	for i, r := range history {
+		fmt.Fprintf(script, "\n# %d. %v %v", i+1, r.Method, r.fullURL())
+		if r.StatusCode != 0 {
+			fmt.Fprintf(script, " -> %d %v", r.StatusCode, http.StatusText(r.StatusCode))
+		}
+		if !r.Time.IsZero() {
+			fmt.Fprintf(script, " (%v)", r.Time.Format(time.RFC3339))
+		}
+		script.WriteString("\n")
+		switch _, socket := socketURL(r.Url); {
+		case r.RawRequest != "":
+			fmt.Fprintf(script, "# raw request, not sendable by curl:\n%v\n", commentLines(r.RawRequest))
+		case socket:
+			fmt.Fprintf(script, "# %v connection, not sendable by curl, data sent:\n%v\n", r.Method, commentLines(r.Data))
+		default:
+			fmt.Fprintln(script, historyCurlCommand(r))
+		}
+	}
+	return []byte(script.String())
+}
+
+// ExportHistory saves the history as a shell script of curl commands
+func (a *App) ExportHistory(g *gocui.Gui, _ *gocui.View) error {
+	if len(a.history) == 0 {
+		return a.OpenMessageView("The history is empty", g)
+	}
+	return a.OpenSaveDialog(VIEW_TITLES[EXPORT_HISTORY_DIALOG_VIEW], HISTORY_SCRIPT_FILENAME, g,
+		func(g *gocui.Gui, _ *gocui.View) error {
+			defer a.closePopup(g, SAVE_DIALOG_VIEW)
+			saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
+			saveResult := fmt.Sprintf("%d requests exported to %v", len(a.history), saveLocation)
+			// executable by its owner only, it contains the credentials
+			if err := os.WriteFile(saveLocation, exportHistoryScript(a.history, time.Now()), 0o700); err != nil {
+				saveResult = "Error exporting the history: " + err.Error()
+			}
+			return a.OpenSaveResultView(saveResult, g)
+		})
+}
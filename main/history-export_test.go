@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportHistoryScript(t *testing.T) {
+	history := []*Request{
+		{
+			Url:           "https://example.com/search",
+			GetParams:     "q=it's",
+			Method:        http.MethodGet,
+			RequestHeader: http.Header{"Accept": {"*/*"}, "User-Agent": {""}},
+			StatusCode:    200,
+		},
+		{
+			Url:           "https://example.com/items",
+			Method:        http.MethodPost,
+			Data:          `{"name": "a"}`,
+			RequestHeader: http.Header{"Authorization": {"Basic [hidden]"}, "Content-Type": {"application/json"}},
+			UserinfoAuth:  true,
+		},
+		{
+			Method:     http.MethodGet,
+			RawRequest: "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+		},
+		{
+			Url:    "tcp://localhost:6379",
+			Method: "TCP",
+			Data:   "PING",
+		},
+	}
+	script := string(exportHistoryScript(history, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	for _, expected := range []string{
+		"#!/bin/sh\n# 4 requests of a buzz session, exported on 2024-01-02T03:04:05Z\n",
+		"# 1. GET https://example.com/search?q=it's -> 200 OK\n" +
+			`curl -X GET -H 'Accept: */*' -H User-Agent: 'https://example.com/search?q=it'"'"'s'` + "\n",
+		"# 2. POST https://example.com/items\n" +
+			`curl -X POST -H 'Content-Type: application/json' --data-binary '{"name": "a"}' https://example.com/items` + "\n",
+		"# raw request, not sendable by curl:\n#   GET / HTTP/1.1\n#   Host: example.com\n",
+		"# TCP connection, not sendable by curl, data sent:\n#   PING\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expected %q in the script:\n%s", expected, script)
+		}
+	}
+	if strings.Index(script, "# 1.") > strings.Index(script, "# 2.") {
+		t.Error("the requests are not in the history order")
+	}
+}
+
+func TestHistoryCurlCommandBody(t *testing.T) {
+	for _, tc := range []struct {
+		r        *Request
+		expected string
+	}{
+		{
+			&Request{
+				Url:           "https://example.com/login",
+				Method:        http.MethodPost,
+				Headers:       "Content-Type: application/x-www-form-urlencoded",
+				Data:          "user=a\npassword=b",
+				RequestHeader: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			},
+			`curl -X POST -H 'Content-Type: application/x-www-form-urlencoded' --data-binary 'user=a&password=b' https://example.com/login`,
+		},
+		{
+			&Request{
+				Url:           "https://example.com/upload",
+				Method:        http.MethodPost,
+				Headers:       "Content-Type: multipart/form-data\nContent-Digest: sha-256",
+				Data:          "title=%40home\nfile=@photo.png;type=image/png",
+				RequestHeader: http.Header{"Content-Type": {"multipart/form-data; boundary=abc"}, "Content-Digest": {"sha-256=:x:"}},
+			},
+			`curl -X POST --form-string title=@home -F 'file=@photo.png;type=image/png' https://example.com/upload`,
+		},
+		{
+			&Request{
+				Url:     "https://example.com/items",
+				Method:  http.MethodPut,
+				Headers: "Content-Md5: md5",
+				Data:    "content",
+				RequestHeader: http.Header{
+					"Content-Encoding": {"gzip"},
+					"Content-Md5":      {"x"},
+					"Content-Type":     {"text/plain"},
+				},
+			},
+			`curl -X PUT -H 'Content-Type: text/plain' --data-binary content https://example.com/items`,
+		},
+		{
+			&Request{
+				Url:           "https://example.com/items",
+				Method:        http.MethodPut,
+				Headers:       "Content-Encoding: gzip",
+				Data:          "@body.gz",
+				RequestHeader: http.Header{"Content-Encoding": {"gzip"}},
+			},
+			`curl -X PUT -H 'Content-Encoding: gzip' --data-binary @body.gz https://example.com/items`,
+		},
+	} {
+		if command := historyCurlCommand(tc.r); command != tc.expected {
+			t.Errorf("expected\n%s\ngot\n%s", tc.expected, command)
+		}
+	}
+}
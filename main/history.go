@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// historyEditor edits the filter of the history popup, the popup content is
+// refreshed on every change
+type historyEditor struct {
+	app *App
+	g   *gocui.Gui
+}
+
+func (e *historyEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	filter := []rune(e.app.historyFilter)
+	switch {
+	case ch != 0 && mod == gocui.ModNone:
+		filter = append(filter, ch)
+	case key == gocui.KeySpace:
+		filter = append(filter, ' ')
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		if len(filter) == 0 {
+			return
+		}
+		filter = filter[:len(filter)-1]
+	default:
+		return
+	}
+	e.app.historyFilter = string(filter)
+	e.app.renderHistory(v)
+	selectListLine(v, 0)
+}
+
+// addToHistory appends r to the history and selects it. In deduplication
+// mode an identical previous request is replaced instead.
+func (a *App) addToHistory(r *Request) {
+	r.PreviousHeader = a.previousResponseHeader(r)
+	defer func() { OFFLINE_REPLAY.setHistory(a.history) }()
+	if a.config.General.HistoryDeduplication {
+		for i, h := range a.history {
+			if h.sameRequest(r) {
+				r.Pinned = h.Pinned
+				a.history[i] = r
+				a.historyIndex = i
+				return
+			}
+		}
+	}
+	a.history = append(a.history, r)
+	a.historyIndex = len(a.history) - 1
+}
+
+// sameRequest reports whether r and o send the same request
+func (r *Request) sameRequest(o *Request) bool {
+	return r.Method == o.Method &&
+		r.fullURL() == o.fullURL() &&
+		r.Headers == o.Headers &&
+		r.Data == o.Data
+}
+
+// matchesHistoryFilter reports whether r matches every whitespace separated
+// term of filter. A term is matched against the method if it is an HTTP
+// method, against the status code if it looks like one (e.g. 404 or 4xx)
+// and against the URL and the notes otherwise.
+func matchesHistoryFilter(r *Request, filter string) bool {
+	for _, term := range strings.Fields(filter) {
+		if !matchesHistoryTerm(r, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesHistoryTerm(r *Request, term string) bool {
+	for _, method := range METHODS {
+		if strings.EqualFold(term, method) {
+			return strings.EqualFold(r.Method, method)
+		}
+	}
+	if isStatusPattern(term) {
+		status := strconv.Itoa(r.StatusCode)
+		if len(status) != len(term) {
+			return false
+		}
+		for i := range term {
+			if term[i] != 'x' && term[i] != 'X' && term[i] != status[i] {
+				return false
+			}
+		}
+		return true
+	}
+	term = strings.ToLower(term)
+	return strings.Contains(strings.ToLower(r.fullURL()), term) || strings.Contains(strings.ToLower(r.Notes), term)
+}
+
+// isStatusPattern reports whether term is a status code like 200 or a
+// status class like 2xx
+func isStatusPattern(term string) bool {
+	if len(term) != 3 || term[0] < '1' || term[0] > '5' {
+		return false
+	}
+	for _, c := range term[1:] {
+		if (c < '0' || c > '9') && c != 'x' && c != 'X' {
+			return false
+		}
+	}
+	return true
+}
+
+// renderHistory writes the history entries matching the current filter to
+// the history popup
+func (a *App) renderHistory(v *gocui.View) {
+	v.Clear()
+	v.Title = VIEW_TITLES[HISTORY_VIEW] + " - type to filter by URL, notes, method or status"
+	if a.historyFilter != "" {
+		v.Title = fmt.Sprintf("%s (filter: %s)", VIEW_TITLES[HISTORY_VIEW], a.historyFilter)
+	}
+	a.historyEntries = a.historyEntries[:0]
+	// pinned entries are listed first
+	for _, pinned := range []bool{true, false} {
+		for i, r := range a.history {
+			if r.Pinned == pinned && matchesHistoryFilter(r, a.historyFilter) {
+				a.historyEntries = append(a.historyEntries, i)
+			}
+		}
+	}
+	for _, i := range a.historyEntries {
+		r := a.history[i]
+		pin := " "
+		if r.Pinned {
+			pin = "*"
+		}
+		req_str := fmt.Sprintf("%s[%02d] %s", pin, i, r.Time.Format("15:04:05"))
+		if r.StatusCode != 0 {
+			req_str += fmt.Sprintf(" \x1b[0;%dm%d\x1b[0;0m", statusColor(r.StatusCode), r.StatusCode)
+		} else {
+			req_str += " ---"
+		}
+		// the transferred size and the speed, as in the status line
+		transferred, speed := "", ""
+		if r.RawResponseBody != nil {
+			transferred, speed = formatSize(r.TransferSize), formatSize(int64(r.Throughput()))+"/s"
+		}
+		req_str += fmt.Sprintf(" %8v %10v %10v %12v", r.Duration.Round(time.Millisecond), formatSize(int64(len(r.RawResponseBody))), transferred, speed)
+		req_str += fmt.Sprintf(" %-7v %v", r.Method, r.Url)
+		if r.GetParams != "" {
+			req_str += fmt.Sprintf("?%v", strings.Replace(r.GetParams, "\n", "&", -1))
+		}
+		if r.Data != "" {
+			req_str += fmt.Sprintf(" %v", strings.Replace(r.Data, "\n", "&", -1))
+		}
+		if r.Headers != "" {
+			req_str += fmt.Sprintf(" %v", strings.Replace(r.Headers, "\n", ";", -1))
+		}
+		if r.Notes != "" {
+			req_str += fmt.Sprintf(" \x1b[0;36m# %v\x1b[0;0m", strings.Replace(r.Notes, "\n", " ", -1))
+		}
+		fmt.Fprintln(v, req_str)
+	}
+	if len(a.historyEntries) == 0 {
+		fmt.Fprint(v, "[!] No matching items in history")
+	}
+}
+
+// selectedHistoryEntry returns the history index of the selected line of
+// the history popup
+func (a *App) selectedHistoryEntry(v *gocui.View) (int, bool) {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	line := cy + oy
+	if line < 0 || line >= len(a.historyEntries) {
+		return 0, false
+	}
+	return a.historyEntries[line], true
+}
+
+// moveListCursor moves the selection of a list popup having count lines by
+// dy, scrolling the view if necessary
+func moveListCursor(v *gocui.View, dy, count int) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	line := cy + oy + dy
+	if line < 0 || line >= count {
+		return nil
+	}
+	selectListLine(v, line)
+	return nil
+}
+
+// selectListLine moves the cursor of a list popup to line
+func selectListLine(v *gocui.View, line int) {
+	_, height := v.Size()
+	oy := 0
+	if line >= height {
+		oy = line - height + 1
+	}
+	v.SetOrigin(0, oy)
+	v.SetCursor(0, line-oy)
+}
+
+func (a *App) deleteHistoryEntry(g *gocui.Gui, v *gocui.View) error {
+	idx, found := a.selectedHistoryEntry(v)
+	if !found {
+		return nil
+	}
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	a.history = append(a.history[:idx], a.history[idx+1:]...)
+	OFFLINE_REPLAY.setHistory(a.history)
+	if a.historyIndex > idx || a.historyIndex >= len(a.history) {
+		a.historyIndex = maxInt(a.historyIndex-1, 0)
+	}
+	if len(a.history) == 0 {
+		a.closePopup(g, HISTORY_VIEW)
+		return nil
+	}
+	a.renderHistory(v)
+	selectListLine(v, minInt(cy+oy, maxInt(len(a.historyEntries)-1, 0)))
+	return nil
+}
+
+// replayHistoryEntry sends the selected history entry again without
+// restoring it into the request views
+func (a *App) replayHistoryEntry(g *gocui.Gui, v *gocui.View) error {
+	idx, found := a.selectedHistoryEntry(v)
+	if !found {
+		return nil
+	}
+	h := a.history[idx]
+	a.closePopup(g, HISTORY_VIEW)
+	if u, found := socketURL(h.Url); found {
+		return a.sendSocket(g, u, h.Data)
+	}
+	variables, apiKey := copyVariables(a.variables), a.apiKey
+	return a.sendRequest(g, func(r *Request, conf *config.Config) (*http.Request, error) {
+		if h.RawRequest != "" {
+			req, err := parseRawRequest(h.RawRequest, conf.General.DefaultURLScheme)
+			if err == nil {
+				r.RawRequest = h.RawRequest
+				r.Notes = h.Notes
+				fillFromRawRequest(r, req)
+			}
+			return req, err
+		}
+		r.Url = h.Url
+		r.GetParams = h.GetParams
+		r.Method = h.Method
+		r.Headers = h.Headers
+		r.Data = h.Data
+		r.SendBody = h.SendBody
+		r.Notes = h.Notes
+		r.Auth = h.Auth
+		r.Schema = h.Schema
+		r.Transport = h.Transport
+		req, err := r.newHTTPRequest()
+		if err != nil {
+			return nil, err
+		}
+		if err := addAPIKey(req, apiKey, variables); err != nil {
+			return nil, err
+		}
+		return req, a.addConfigHeaders(r, req, conf)
+	})
+}
+
+func (a *App) togglePinHistoryEntry(_ *gocui.Gui, v *gocui.View) error {
+	idx, found := a.selectedHistoryEntry(v)
+	if !found {
+		return nil
+	}
+	a.history[idx].Pinned = !a.history[idx].Pinned
+	a.renderHistory(v)
+	for i, entry := range a.historyEntries {
+		if entry == idx {
+			selectListLine(v, i)
+		}
+	}
+	return nil
+}
+
+// clearHistory removes every history entry except the pinned ones
+func (a *App) clearHistory(g *gocui.Gui) {
+	history := make([]*Request, 0, 31)
+	for _, r := range a.history {
+		if r.Pinned {
+			history = append(history, r)
+		}
+	}
+	a.history = history
+	a.historyIndex = maxInt(len(a.history)-1, 0)
+	OFFLINE_REPLAY.setHistory(a.history)
+	a.closePopup(g, HISTORY_VIEW)
+}
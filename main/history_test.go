@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestMatchesHistoryFilter(t *testing.T) {
+	r := &Request{
+		Url:        "https://example.com/api/users",
+		GetParams:  "page=2",
+		Method:     "POST",
+		StatusCode: 404,
+		Notes:      "Expected: Deleted accounts return 404",
+	}
+	for filter, expected := range map[string]bool{
+		"":                 true,
+		"users":            true,
+		"EXAMPLE.COM":      true,
+		"page=2":           true,
+		"orders":           false,
+		"post":             true,
+		"GET":              false,
+		"404":              true,
+		"4xx":              true,
+		"2xx":              false,
+		"post 4xx users":   true,
+		"post 4xx orders":  false,
+		"delete 404 users": false,
+		"deleted":          true,
+		"post accounts":    true,
+	} {
+		if matchesHistoryFilter(r, filter) != expected {
+			t.Errorf("expected filter %q to match: %v", filter, expected)
+		}
+	}
+
+	if matchesHistoryFilter(&Request{Url: "https://example.com"}, "200") {
+		t.Error("request without response should not match status filter")
+	}
+}
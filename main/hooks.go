@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// shellCommand runs command with the shell of the system
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// runPostResponseHook runs the postResponseCommand of the configuration with
+// the response described by environment variables, its body is written to
+// the temporary file $BUZZ_BODY_FILE
+func (a *App) runPostResponseHook(r *Request) error {
+	file, err := os.CreateTemp("", "buzz-response-*"+extensionForContentType(r.ContentType))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(r.RawResponseBody)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	cmd := shellCommand(context.Background(), a.config.General.PostResponseCommand)
+	cmd.Env = append(os.Environ(),
+		"BUZZ_METHOD="+r.Method,
+		"BUZZ_URL="+r.fullURL(),
+		"BUZZ_STATUS="+strconv.Itoa(r.StatusCode),
+		"BUZZ_DURATION="+strconv.FormatInt(r.Duration.Milliseconds(), 10),
+		"BUZZ_CONTENT_TYPE="+r.ContentType,
+		"BUZZ_BODY_FILE="+file.Name(),
+	)
+	// the terminal belongs to the UI
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// postResponseHook runs the post response hook in the background if one is
+// configured
+func (a *App) postResponseHook(g *gocui.Gui, r *Request) {
+	if a.config.General.PostResponseCommand == "" {
+		return
+	}
+	go func() {
+		if err := a.runPostResponseHook(r); err != nil {
+			LOGGER.Error("post response hook failed", "command", a.config.General.PostResponseCommand, "error", err)
+			g.Update(func(g *gocui.Gui) error {
+				return a.OpenMessageView("Post response hook error: "+err.Error(), g)
+			})
+		}
+	}()
+}
+
+// pipeBody returns the output of command reading body from its standard input
+func pipeBody(ctx context.Context, command string, body []byte) ([]byte, error) {
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = bytes.NewReader(body)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v\n%s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// OpenPipeDialog prompts for the shell command the response body is piped
+// through
+func (a *App) OpenPipeDialog(g *gocui.Gui, _ *gocui.View) error {
+	dialog, err := a.CreatePopupView(PIPE_COMMAND_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[PIPE_COMMAND_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, a.pipeCommand)
+	g.SetViewOnTop(PIPE_COMMAND_VIEW)
+	g.SetCurrentView(PIPE_COMMAND_VIEW)
+	return nil
+}
+
+func (a *App) submitPipeCommand(g *gocui.Gui, _ *gocui.View) error {
+	a.pipeCommand = getViewValue(g, PIPE_COMMAND_VIEW)
+	a.closePopup(g, PIPE_COMMAND_VIEW)
+	a.resetBodyLimit()
+	a.PrintBody(g)
+	return nil
+}
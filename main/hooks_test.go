@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestRunPostResponseHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook command uses sh")
+	}
+	out := filepath.Join(t.TempDir(), "hook.txt")
+	a := &App{config: &config.Config{General: config.GeneralOptions{
+		PostResponseCommand: `printf '%s %s %s %s\n' "$BUZZ_STATUS" "$BUZZ_METHOD" "$BUZZ_URL" "$BUZZ_DURATION" > ` + out + ` && cat "$BUZZ_BODY_FILE" >> ` + out,
+	}}}
+	r := &Request{
+		Method:          "GET",
+		Url:             "http://localhost/",
+		GetParams:       "q=1",
+		StatusCode:      201,
+		Duration:        1500 * time.Millisecond,
+		RawResponseBody: []byte("created"),
+	}
+	if err := a.runPostResponseHook(r); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "201 GET http://localhost/?q=1 1500\ncreated"; string(content) != expected {
+		t.Errorf("unexpected hook output %q", content)
+	}
+
+	a.config.General.PostResponseCommand = "echo failed; exit 3"
+	if err := a.runPostResponseHook(r); err == nil {
+		t.Error("expected an error for a failing command")
+	}
+}
+
+func TestPipeBody(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the pipe command uses sh")
+	}
+	output, err := pipeBody(context.Background(), "tr a-z A-Z", []byte("items"))
+	if err != nil || string(output) != "ITEMS" {
+		t.Errorf("unexpected output %q, %v", output, err)
+	}
+	if _, err := pipeBody(context.Background(), "echo oops >&2; exit 1", nil); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expected the standard error in the error, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// HOST_CHECK_TIMEOUT is the maximum duration of the reachability check
+const HOST_CHECK_TIMEOUT = 3 * time.Second
+
+// HOST_CHECK_TTL is how long a reachable host is not checked again
+const HOST_CHECK_TTL = 30 * time.Second
+
+// hostCheck is the reachability check of the host of the URL view
+type hostCheck struct {
+	target   string // host:port checked
+	done     bool
+	err      error
+	duration time.Duration
+	checked  time.Time
+}
+
+// current returns whether the check of target is in progress or found the
+// host reachable less than HOST_CHECK_TTL ago
+func (c *hostCheck) current(target string, now time.Time) bool {
+	if c == nil || c.target != target {
+		return false
+	}
+	return !c.done || (c.err == nil && now.Sub(c.checked) < HOST_CHECK_TTL)
+}
+
+// proxied returns whether the requests to u go through a proxy of
+// transport, the host is not connected to directly then
+func proxied(transport *http.Transport, u *url.URL) bool {
+	if transport.Proxy == nil {
+		return false
+	}
+	proxy, err := transport.Proxy(&http.Request{URL: u})
+	return err != nil || proxy != nil
+}
+
+// hostCheckTarget returns the URL and the address of its host, with the
+// default port of its scheme
+func hostCheckTarget(rawURL string) (*url.URL, string, bool) {
+	if strings.Contains(rawURL, "{{") {
+		return nil, "", false
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return nil, "", false
+	}
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		default:
+			return nil, "", false
+		}
+	}
+	return u, net.JoinHostPort(u.Hostname(), port), true
+}
+
+// shortNetError returns the cause of a failed lookup, connection or
+// handshake without the addresses repeated by the net errors
+func shortNetError(step string, err error) error {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("%v: %v", step, dnsErr.Err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return fmt.Errorf("%v: timeout", step)
+	case errors.As(err, &opErr):
+		return fmt.Errorf("%v: %v", step, opErr.Err)
+	}
+	return fmt.Errorf("%v: %v", step, err)
+}
+
+// checkHost resolves the host of u, connects to addr and completes the TLS
+// handshake of https URLs
+func checkHost(ctx context.Context, u *url.URL, addr string) error {
+	if net.ParseIP(u.Hostname()) == nil {
+		if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+			return shortNetError("DNS", err)
+		}
+	}
+	transport := TRANSPORT.Load()
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return shortNetError("TCP", err)
+	}
+	defer conn.Close()
+	if !strings.EqualFold(u.Scheme, "https") {
+		return nil
+	}
+	config := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	}
+	config.ServerName = u.Hostname()
+	if config.VerifyConnection != nil {
+		config.VerifyConnection = verifyPins(u.Hostname())
+	}
+	if err := tls.Client(conn, config).HandshakeContext(ctx); err != nil {
+		return shortNetError("TLS", err)
+	}
+	return nil
+}
+
+// startHostCheck checks in the background whether the host of the URL view
+// is reachable, unless the last check is current. Hosts behind a proxy are
+// not checked.
+func (a *App) startHostCheck(g *gocui.Gui) {
+	u, addr, ok := hostCheckTarget(expandVariables(getViewValue(g, URL_VIEW), a.variables))
+	if !ok || a.hostCheck.current(addr, time.Now()) {
+		return
+	}
+	if proxied(TRANSPORT.Load(), u) {
+		a.hostCheck = nil
+		refreshStatusLine(a, g)
+		return
+	}
+	check := &hostCheck{target: addr}
+	a.hostCheck = check
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), HOST_CHECK_TIMEOUT)
+		defer cancel()
+		start := time.Now()
+		err := checkHost(ctx, u, addr)
+		g.Update(func(g *gocui.Gui) error {
+			check.done = true
+			check.err = err
+			check.duration = time.Since(start)
+			check.checked = time.Now()
+			if err != nil {
+				LOGGER.Info("host check failed", "host", addr, "error", err)
+			}
+			refreshStatusLine(a, g)
+			return nil
+		})
+	}()
+}
+
+// checkHostOnBlur starts the host check when the URL view loses the focus
+func (a *App) checkHostOnBlur(g *gocui.Gui) {
+	focused := ""
+	if v := g.CurrentView(); v != nil {
+		focused = v.Name()
+	}
+	if a.focusedView == URL_VIEW && focused != URL_VIEW && a.config.General.HostCheck {
+		a.startHostCheck(g)
+	}
+	a.focusedView = focused
+}
+
+// HostCheck returns whether the host of the URL is reachable, checked when
+// the URL view loses the focus
+func (s *StatusLineFunctions) HostCheck() string {
+	check := s.app.hostCheck
+	switch {
+	case check == nil:
+		return ""
+	case !check.done:
+		return check.target + " ..."
+	case check.err != nil:
+		return fmt.Sprintf("\x1b[0;31m✗ %v %v\x1b[0;0m", check.target, check.err)
+	}
+	return fmt.Sprintf("\x1b[0;32m✓ %v %v\x1b[0;0m", check.target, check.duration.Round(time.Millisecond))
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHostCheckTarget(t *testing.T) {
+	cases := []struct {
+		url  string
+		addr string
+		ok   bool
+	}{
+		{"http://example.com/path", "example.com:80", true},
+		{"https://example.com", "example.com:443", true},
+		{"https://example.com:8443/x", "example.com:8443", true},
+		{"http://[::1]/", "[::1]:80", true},
+		{"tcp://example.com", "", false},
+		{"http://{{host}}/path", "", false},
+		{"/relative", "", false},
+	}
+	for _, c := range cases {
+		_, addr, ok := hostCheckTarget(c.url)
+		if addr != c.addr || ok != c.ok {
+			t.Errorf("hostCheckTarget(%q) = %q, %v, want %q, %v", c.url, addr, ok, c.addr, c.ok)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	u, target, ok := hostCheckTarget("http://" + addr + "/")
+	if !ok || target != addr {
+		t.Fatalf("unexpected target %q", target)
+	}
+	if err := checkHost(context.Background(), u, target); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	l.Close()
+	err = checkHost(context.Background(), u, target)
+	if err == nil || !strings.HasPrefix(err.Error(), "TCP: ") {
+		t.Errorf("expected a TCP error, got %v", err)
+	}
+}
+
+func TestHostCheckCurrent(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		check   *hostCheck
+		current bool
+	}{
+		{nil, false},
+		{&hostCheck{target: "a:80"}, true},
+		{&hostCheck{target: "b:80"}, false},
+		{&hostCheck{target: "a:80", done: true, checked: now.Add(-time.Second)}, true},
+		{&hostCheck{target: "a:80", done: true, checked: now.Add(-HOST_CHECK_TTL)}, false},
+		{&hostCheck{target: "a:80", done: true, err: errors.New("TCP: refused"), checked: now}, false},
+	}
+	for i, c := range cases {
+		if current := c.check.current("a:80", now); current != c.current {
+			t.Errorf("%d: expected %v, got %v", i, c.current, current)
+		}
+	}
+}
+
+func TestProxied(t *testing.T) {
+	u, _ := url.Parse("http://example.com/")
+	if proxied(&http.Transport{}, u) {
+		t.Error("expected no proxy")
+	}
+	proxy, _ := url.Parse("http://proxy:3128")
+	if !proxied(&http.Transport{Proxy: http.ProxyURL(proxy)}, u) {
+		t.Error("expected the proxy")
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// evalJSONPath returns the values of body selected by the JSONPath
+// expression path. Supported are child names (.name, ['name']), array
+// indexes ([0], [-1]), wildcards (.*, [*]) and recursive descent (..name).
+func evalJSONPath(path string, body []byte) ([]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("the response body is not JSON: %v", err)
+	}
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath must start with $")
+	}
+	nodes := []interface{}{root}
+	for pos := 1; pos < len(path); {
+		recursive := false
+		var step jsonPathStep
+		switch path[pos] {
+		case '.':
+			pos++
+			if pos < len(path) && path[pos] == '.' {
+				recursive = true
+				pos++
+			}
+			if pos < len(path) && path[pos] == '[' {
+				continue
+			}
+			end := pos
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			step.name = path[pos:end]
+			if step.name == "" {
+				return nil, fmt.Errorf("missing name at %d", pos)
+			}
+			step.wildcard = step.name == "*"
+			pos = end
+		case '[':
+			if pos+1 < len(path) && (path[pos+1] == '\'' || path[pos+1] == '"') {
+				// quoted names may contain ] and escaped quotes
+				quote := path[pos+1]
+				var name strings.Builder
+				i := pos + 2
+				for ; i < len(path) && path[i] != quote; i++ {
+					if path[i] == '\\' && i+1 < len(path) {
+						i++
+					}
+					name.WriteByte(path[i])
+				}
+				if i+1 >= len(path) || path[i+1] != ']' {
+					return nil, fmt.Errorf("unterminated name at %d", pos)
+				}
+				step.name = name.String()
+				pos = i + 2
+			} else {
+				end := strings.IndexByte(path[pos:], ']')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated [ at %d", pos)
+				}
+				inner := strings.TrimSpace(path[pos+1 : pos+end])
+				step.wildcard = inner == "*"
+				index, err := strconv.Atoi(inner)
+				if err != nil && !step.wildcard {
+					return nil, fmt.Errorf("invalid index [%s]", inner)
+				}
+				step.index = &index
+				pos += end + 1
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", path[pos], pos)
+		}
+
+		if recursive {
+			nodes = descendants(nodes)
+		}
+		var selected []interface{}
+		for _, node := range nodes {
+			selected = append(selected, selectJSONChildren(node, step)...)
+		}
+		nodes = selected
+	}
+	return nodes, nil
+}
+
+// descendants returns the nodes and all their children, recursively
+func descendants(nodes []interface{}) []interface{} {
+	var all []interface{}
+	for _, node := range nodes {
+		all = append(all, node)
+		all = append(all, descendants(selectJSONChildren(node, jsonPathStep{wildcard: true}))...)
+	}
+	return all
+}
+
+// jsonPathStep selects the children of a node by name, by index or all of
+// them
+type jsonPathStep struct {
+	name     string
+	index    *int
+	wildcard bool
+}
+
+func selectJSONChildren(node interface{}, step jsonPathStep) []interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if step.wildcard {
+			children := make([]interface{}, 0, len(n))
+			for _, key := range sortedKeys(n) {
+				children = append(children, n[key])
+			}
+			return children
+		}
+		if child, found := n[step.name]; found && step.index == nil {
+			return []interface{}{child}
+		}
+	case []interface{}:
+		if step.wildcard {
+			return n
+		}
+		if step.index == nil {
+			return nil
+		}
+		i := *step.index
+		if i < 0 {
+			i += len(n)
+		}
+		if i >= 0 && i < len(n) {
+			return []interface{}{n[i]}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatJSONValues renders a single string unquoted, other results as
+// indented JSON
+func formatJSONValues(values []interface{}) string {
+	var value interface{} = values
+	if len(values) == 1 {
+		value = values[0]
+	}
+	if str, ok := value.(string); ok {
+		return str
+	}
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
+
+// EXTRACT_INPUT_PATTERN matches the "name = $.path" input of the extract
+// dialog
+var EXTRACT_INPUT_PATTERN = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*=\s*(\$.*)$`)
+
+// OpenExtractDialog prompts for a JSONPath evaluated against the response
+// body. The result is copied, or stored in a variable with "name = $.path".
+func (a *App) OpenExtractDialog(g *gocui.Gui, _ *gocui.View) error {
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil {
+		return a.OpenMessageView("Error: no response", g)
+	}
+	dialog, err := a.CreatePopupView(EXTRACT_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[EXTRACT_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, "$.")
+	g.SetViewOnTop(EXTRACT_VIEW)
+	g.SetCurrentView(EXTRACT_VIEW)
+	return nil
+}
+
+func (a *App) submitExtract(g *gocui.Gui, _ *gocui.View) error {
+	input := getViewValue(g, EXTRACT_VIEW)
+	a.closePopup(g, EXTRACT_VIEW)
+	name, path := "", input
+	if m := EXTRACT_INPUT_PATTERN.FindStringSubmatch(input); m != nil {
+		name, path = m[1], m[2]
+	}
+	values, err := evalJSONPath(path, a.history[a.historyIndex].RawResponseBody)
+	if err != nil {
+		return a.OpenMessageView("JSONPath error: "+err.Error(), g)
+	}
+	if len(values) == 0 {
+		return a.OpenMessageView("No value matches "+path, g)
+	}
+	value := formatJSONValues(values)
+	if name == "" {
+		return a.copyAndNotify(g, value, "value of "+path)
+	}
+	if a.variables == nil {
+		a.variables = make(map[string]string)
+	}
+	a.variables[name] = value
+	return a.OpenMessageView(fmt.Sprintf("{{%s}} = %s", name, value), g)
+}
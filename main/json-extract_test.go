@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestEvalJSONPath(t *testing.T) {
+	body := []byte(`{"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b", "tags": {"id": 3}}], "odd key]": "x", "token": "s3cret"}`)
+	for path, expected := range map[string]string{
+		"$.token":              "s3cret",
+		"$.items[0].id":        "1",
+		"$.items[-1].name":     "b",
+		"$.items[*].name":      "[\n  \"a\",\n  \"b\"\n]",
+		"$['odd key]']":        "x",
+		"$..id":                "[\n  1,\n  2,\n  3\n]",
+		"$.items[1].tags":      "{\n  \"id\": 3\n}",
+		"$.items[1]['tags'].*": "3",
+	} {
+		values, err := evalJSONPath(path, body)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if value := formatJSONValues(values); value != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, value)
+		}
+	}
+	for _, path := range []string{"token", "$.items[x]", "$.items[0", "$['token"} {
+		if _, err := evalJSONPath(path, body); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+	if values, _ := evalJSONPath("$.missing", body); len(values) != 0 {
+		t.Errorf("unexpected values %v", values)
+	}
+}
+
+func TestExpandVariables(t *testing.T) {
+	text := expandVariables("Authorization: Bearer {{token}}\nX-Id: {{ id }}", map[string]string{"token": "s3cret"})
+	if text != "Authorization: Bearer s3cret\nX-Id: {{ id }}" {
+		t.Errorf("unexpected expansion %q", text)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// jsonLine is a line of the formatted JSON response body: the JSONPath of
+// the node starting or ending on the line and the position of the node in
+// the raw body
+type jsonLine struct {
+	path       string
+	start, end int
+}
+
+var JSON_PATH_IDENTIFIER = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type jsonScanner struct {
+	data  []byte
+	pos   int
+	lines []jsonLine
+}
+
+// jsonLines returns the nodes of every line of body formatted with an
+// indentation, in the layout of the JSON formatter
+func jsonLines(body []byte) ([]jsonLine, error) {
+	s := &jsonScanner{data: body}
+	if err := s.value("$"); err != nil {
+		return nil, err
+	}
+	if s.skipSpaces(); s.pos != len(s.data) {
+		return nil, s.errorf("unexpected data after the JSON value")
+	}
+	return s.lines, nil
+}
+
+func (s *jsonScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid JSON at offset %d: %s", s.pos, fmt.Sprintf(format, args...))
+}
+
+func (s *jsonScanner) skipSpaces() {
+	for s.pos < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) value(path string) error {
+	s.skipSpaces()
+	if s.pos >= len(s.data) {
+		return s.errorf("unexpected end")
+	}
+	start := s.pos
+	s.lines = append(s.lines, jsonLine{path: path, start: start})
+	i := len(s.lines) - 1
+	switch s.data[s.pos] {
+	case '{', '[':
+		if err := s.container(path); err != nil {
+			return err
+		}
+		s.lines[i].end = s.pos
+		if len(s.lines)-1 > i {
+			// the closing bracket of a non empty container has its own line
+			s.lines = append(s.lines, s.lines[i])
+		}
+	case '"':
+		if _, err := s.string(); err != nil {
+			return err
+		}
+		s.lines[i].end = s.pos
+	default:
+		for s.pos < len(s.data) && strings.IndexByte(",]} \t\r\n", s.data[s.pos]) < 0 {
+			s.pos++
+		}
+		s.lines[i].end = s.pos
+		if !json.Valid(s.data[start:s.pos]) {
+			return s.errorf("invalid value %q", s.data[start:s.pos])
+		}
+	}
+	return nil
+}
+
+// container scans an object or an array
+func (s *jsonScanner) container(path string) error {
+	closing := byte('}')
+	if s.data[s.pos] == '[' {
+		closing = ']'
+	}
+	s.pos++
+	s.skipSpaces()
+	if s.pos < len(s.data) && s.data[s.pos] == closing {
+		s.pos++
+		return nil
+	}
+	for index := 0; ; index++ {
+		childPath := path + "[" + strconv.Itoa(index) + "]"
+		if closing == '}' {
+			s.skipSpaces()
+			key, err := s.string()
+			if err != nil {
+				return err
+			}
+			childPath = jsonChildPath(path, key)
+			if s.skipSpaces(); s.pos >= len(s.data) || s.data[s.pos] != ':' {
+				return s.errorf("missing colon")
+			}
+			s.pos++
+		}
+		if err := s.value(childPath); err != nil {
+			return err
+		}
+		s.skipSpaces()
+		if s.pos >= len(s.data) {
+			return s.errorf("unexpected end")
+		}
+		switch s.data[s.pos] {
+		case ',':
+			s.pos++
+		case closing:
+			s.pos++
+			return nil
+		default:
+			return s.errorf("unexpected character %q", s.data[s.pos])
+		}
+	}
+}
+
+func (s *jsonScanner) string() (string, error) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", s.errorf("string expected")
+	}
+	start := s.pos
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var str string
+			if err := json.Unmarshal(s.data[start:s.pos], &str); err != nil {
+				return "", s.errorf("invalid string")
+			}
+			return str, nil
+		}
+	}
+	return "", s.errorf("unterminated string")
+}
+
+func jsonChildPath(path, key string) string {
+	if JSON_PATH_IDENTIFIER.MatchString(key) {
+		return path + "." + key
+	}
+	key = strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(key)
+	return path + "['" + key + "']"
+}
+
+// nodeValue returns the value of the node of line: strings are unquoted and
+// containers are indented
+func (l jsonLine) nodeValue(body []byte) string {
+	raw := body[l.start:l.end]
+	var str string
+	if raw[0] == '"' && json.Unmarshal(raw, &str) == nil {
+		return str
+	}
+	var indented bytes.Buffer
+	if json.Indent(&indented, raw, "", "  ") == nil {
+		return indented.String()
+	}
+	return string(raw)
+}
+
+// bufferLine returns the line of the view buffer displayed on the row y of
+// the view, taking wrapped lines into account
+func bufferLine(v *gocui.View, y int) int {
+	if !v.Wrap {
+		return y
+	}
+	row := 0
+	for i, line := range v.BufferLines() {
+		rows := wrappedRows(v, line)
+		if y < row+rows {
+			return i
+		}
+		row += rows
+	}
+	return y
+}
+
+// viewRow returns the row of the view displaying the line of the view
+// buffer, the reverse of bufferLine
+func viewRow(v *gocui.View, line int) int {
+	if !v.Wrap {
+		return line
+	}
+	row := 0
+	for i, l := range v.BufferLines() {
+		if i == line {
+			break
+		}
+		row += wrappedRows(v, l)
+	}
+	return row
+}
+
+// wrappedRows returns the number of rows used by line in a wrapped view
+func wrappedRows(v *gocui.View, line string) int {
+	width, _ := v.Size()
+	if n := len([]rune(line)); width > 0 && n > width {
+		return (n + width - 1) / width
+	}
+	return 1
+}
+
+// jsonNodeAtCursor returns the JSON node of the response body line under the
+// cursor and the response body
+func (a *App) jsonNodeAtCursor(g *gocui.Gui) (jsonLine, []byte, error) {
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil {
+		return jsonLine{}, nil, errors.New("no response")
+	}
+	if a.rawResponse || getViewValue(g, SEARCH_VIEW) != "" {
+		return jsonLine{}, nil, errors.New("only available for the formatted response body, without search")
+	}
+	body := a.history[a.historyIndex].RawResponseBody
+	lines, err := jsonLines(body)
+	if err != nil {
+		return jsonLine{}, nil, fmt.Errorf("the response body is not JSON: %v", err)
+	}
+	v, _ := g.View(RESPONSE_BODY_VIEW)
+	_, oy := v.Origin()
+	_, cy := v.Cursor()
+	line := bufferLine(v, oy+cy)
+	if line >= len(lines) {
+		line = len(lines) - 1
+	}
+	return lines[line], body, nil
+}
+
+// CopyJSONPath copies the JSONPath of the node under the cursor
+func (a *App) CopyJSONPath(g *gocui.Gui, _ *gocui.View) error {
+	node, _, err := a.jsonNodeAtCursor(g)
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	return a.copyAndNotify(g, node.path, "JSONPath "+node.path)
+}
+
+// CopyJSONValue copies the value of the node under the cursor
+func (a *App) CopyJSONValue(g *gocui.Gui, _ *gocui.View) error {
+	node, body, err := a.jsonNodeAtCursor(g)
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	return a.copyAndNotify(g, node.nodeValue(body), "value of "+node.path)
+}
+
+func (a *App) copyAndNotify(g *gocui.Gui, text, description string) error {
+	if err := copyToClipboard(text); err != nil {
+		return a.OpenMessageView("Clipboard error: "+err.Error(), g)
+	}
+	return a.OpenMessageView("Copied the "+description, g)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nwidger/jsoncolor"
+)
+
+func TestJSONLines(t *testing.T) {
+	body := []byte(`{"a": {}, "b": [], "c": [1, {"d": null}], "e f": "x\"y", "g": [[], [[]]]}`)
+	lines, err := jsonLines(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lines must match the output of the JSON formatter
+	f := jsoncolor.NewFormatter()
+	f.Indent = "  "
+	formatted := &bytes.Buffer{}
+	if err := f.Format(formatted, body); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(formatted.String(), "\n") + 1; n != len(lines) {
+		t.Fatalf("expected %d lines, got %d", n, len(lines))
+	}
+
+	for _, tc := range []struct {
+		line        int
+		path, value string
+	}{
+		{0, "$", ""},
+		{1, "$.a", "{}"},
+		{4, "$.c[0]", "1"},
+		{6, "$.c[1].d", "null"},
+		{9, "$['e f']", `x"y`},
+		{13, "$.g[1][0]", "[]"},
+		{15, "$.g", "[\n  [],\n  [\n    []\n  ]\n]"},
+	} {
+		l := lines[tc.line]
+		if l.path != tc.path {
+			t.Errorf("line %d: expected path %s, got %s", tc.line, tc.path, l.path)
+		}
+		if tc.value != "" && l.nodeValue(body) != tc.value {
+			t.Errorf("line %d: unexpected value %q", tc.line, l.nodeValue(body))
+		}
+	}
+
+	if _, err := jsonLines([]byte(`{"a": tru}`)); err == nil {
+		t.Error("invalid JSON accepted")
+	}
+}
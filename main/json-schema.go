@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jroimartin/gocui"
+)
+
+// schemaViolation is a value of the response body not matching the schema,
+// pointer is the JSON pointer of the value
+type schemaViolation struct {
+	pointer string
+	message string
+}
+
+func (v schemaViolation) String() string {
+	pointer := v.pointer
+	if pointer == "" {
+		pointer = "(root)"
+	}
+	return pointer + ": " + v.message
+}
+
+// loadSchema reads the JSON Schema of a file path or a http(s) URL
+func loadSchema(location string) (interface{}, error) {
+	var content []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var response *http.Response
+		if response, err = CLIENT.Get(location); err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%v: %v", location, response.Status)
+		}
+		content, err = io.ReadAll(response.Body)
+	} else {
+		content, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	schema, err := decodeJSONNumbers(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %v: %v", location, err)
+	}
+	return schema, nil
+}
+
+func decodeJSONNumbers(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+// validateSchema returns the violations of the schema by body. The
+// supported keywords are those of JSON Schema draft 7 except the formats,
+// the dependencies and the remote references.
+func validateSchema(schema interface{}, body []byte) ([]schemaViolation, error) {
+	return validateSchemaWithRoot(schema, schema, body)
+}
+
+// validateSchemaWithRoot validates body against schema, its references are
+// resolved in the root document
+func validateSchemaWithRoot(root, schema interface{}, body []byte) ([]schemaViolation, error) {
+	instance, err := decodeJSONNumbers(body)
+	if err != nil {
+		return nil, fmt.Errorf("the response body is not JSON: %v", err)
+	}
+	v := &schemaValidator{root: root}
+	v.validate(schema, instance, "")
+	return v.violations, nil
+}
+
+type schemaValidator struct {
+	root       interface{}
+	violations []schemaViolation
+}
+
+func (v *schemaValidator) fail(pointer, format string, args ...interface{}) {
+	v.violations = append(v.violations, schemaViolation{pointer, fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether the instance is valid without recording the
+// violations
+func (v *schemaValidator) matches(schema, instance interface{}, pointer string) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(schema, instance, pointer)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(schema, instance interface{}, pointer string) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(pointer, "no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateKeywords(s, instance, pointer)
+	}
+}
+
+func (v *schemaValidator) validateKeywords(s map[string]interface{}, instance interface{}, pointer string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := resolveSchemaRef(v.root, ref)
+		if err != nil {
+			v.fail(pointer, "%v", err)
+			return
+		}
+		v.validate(target, instance, pointer)
+	}
+
+	if nullable, _ := s["nullable"].(bool); nullable && instance == nil {
+		// OpenAPI 3.0 extension
+		return
+	}
+	if t, found := s["type"]; found && !matchesSchemaType(t, instance) {
+		v.fail(pointer, "expected %v, got %v", formatSchemaTypes(t), jsonType(instance))
+		return
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, value := range enum {
+			found = found || jsonEqual(value, instance)
+		}
+		if !found {
+			v.fail(pointer, "%v is not one of %v", compactJSON(instance), compactJSON(enum))
+		}
+	}
+	if value, found := s["const"]; found && !jsonEqual(value, instance) {
+		v.fail(pointer, "expected %v, got %v", compactJSON(value), compactJSON(instance))
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		schemas, ok := s[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		matching := 0
+		for _, sub := range schemas {
+			if keyword == "allOf" {
+				v.validate(sub, instance, pointer)
+			} else if v.matches(sub, instance, pointer) {
+				matching++
+			}
+		}
+		if keyword == "anyOf" && matching == 0 {
+			v.fail(pointer, "does not match any schema of anyOf")
+		} else if keyword == "oneOf" && matching != 1 {
+			v.fail(pointer, "matches %d schemas of oneOf instead of one", matching)
+		}
+	}
+	if not, found := s["not"]; found && v.matches(not, instance, pointer) {
+		v.fail(pointer, "matches the schema of not")
+	}
+
+	switch value := instance.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, value, pointer)
+	case []interface{}:
+		v.validateArray(s, value, pointer)
+	case string:
+		length := utf8.RuneCountInString(value)
+		if min, ok := schemaNumber(s, "minLength"); ok && float64(length) < min {
+			v.fail(pointer, "shorter than %v characters", min)
+		}
+		if max, ok := schemaNumber(s, "maxLength"); ok && float64(length) > max {
+			v.fail(pointer, "longer than %v characters", max)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err != nil {
+				v.fail(pointer, "invalid pattern %v: %v", pattern, err)
+			} else if !re.MatchString(value) {
+				v.fail(pointer, "%q does not match %v", value, pattern)
+			}
+		}
+	case json.Number:
+		n, _ := value.Float64()
+		if min, ok := schemaNumber(s, "minimum"); ok && n < min {
+			v.fail(pointer, "%v is lower than %v", value, min)
+		}
+		if max, ok := schemaNumber(s, "maximum"); ok && n > max {
+			v.fail(pointer, "%v is greater than %v", value, max)
+		}
+		if min, ok := schemaNumber(s, "exclusiveMinimum"); ok && n <= min {
+			v.fail(pointer, "%v is not greater than %v", value, min)
+		}
+		if max, ok := schemaNumber(s, "exclusiveMaximum"); ok && n >= max {
+			v.fail(pointer, "%v is not lower than %v", value, max)
+		}
+		if factor, ok := schemaNumber(s, "multipleOf"); ok && factor > 0 {
+			if q := n / factor; math.Abs(q-math.Round(q)) > 1e-9 {
+				v.fail(pointer, "%v is not a multiple of %v", value, factor)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]interface{}, object map[string]interface{}, pointer string) {
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, found := object[name]; !found {
+					v.fail(pointer, "missing required property %q", name)
+				}
+			}
+		}
+	}
+	if min, ok := schemaNumber(s, "minProperties"); ok && float64(len(object)) < min {
+		v.fail(pointer, "fewer than %v properties", min)
+	}
+	if max, ok := schemaNumber(s, "maxProperties"); ok && float64(len(object)) > max {
+		v.fail(pointer, "more than %v properties", max)
+	}
+	properties, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		childPointer := pointer + "/" + escapeJSONPointer(name)
+		matched := false
+		if sub, found := properties[name]; found {
+			matched = true
+			v.validate(sub, object[name], childPointer)
+		}
+		for pattern, sub := range patterns {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+				matched = true
+				v.validate(sub, object[name], childPointer)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(childPointer, "additional property is not allowed")
+			} else {
+				v.validate(additional, object[name], childPointer)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]interface{}, array []interface{}, pointer string) {
+	if min, ok := schemaNumber(s, "minItems"); ok && float64(len(array)) < min {
+		v.fail(pointer, "fewer than %v items", min)
+	}
+	if max, ok := schemaNumber(s, "maxItems"); ok && float64(len(array)) > max {
+		v.fail(pointer, "more than %v items", max)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		seen := make(map[string]int)
+		for i, item := range array {
+			key := compactJSON(item)
+			if first, found := seen[key]; found {
+				v.fail(pointer+"/"+strconv.Itoa(i), "duplicate of item %d", first)
+			} else {
+				seen[key] = i
+			}
+		}
+	}
+	switch items := s["items"].(type) {
+	case []interface{}:
+		// tuple validation
+		for i, item := range array {
+			if i < len(items) {
+				v.validate(items[i], item, pointer+"/"+strconv.Itoa(i))
+			} else if additional, found := s["additionalItems"]; found {
+				v.validate(additional, item, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	case nil:
+	default:
+		for i, item := range array {
+			v.validate(items, item, pointer+"/"+strconv.Itoa(i))
+		}
+	}
+	if contains, found := s["contains"]; found {
+		matching := false
+		for i, item := range array {
+			matching = matching || v.matches(contains, item, pointer+"/"+strconv.Itoa(i))
+		}
+		if !matching {
+			v.fail(pointer, "no item matches the schema of contains")
+		}
+	}
+}
+
+// resolveSchemaRef returns the schema of a "#/definitions/name" reference
+func resolveSchemaRef(root interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %v", ref)
+	}
+	node := root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %v", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("unresolved reference %v", ref)
+		}
+	}
+	return node, nil
+}
+
+func escapeJSONPointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func schemaNumber(s map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := s[keyword].(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesSchemaType(t interface{}, instance interface{}) bool {
+	actual := jsonType(instance)
+	types, ok := t.([]interface{})
+	if !ok {
+		types = []interface{}{t}
+	}
+	for _, expected := range types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+		if n, ok := instance.(json.Number); ok && expected == "integer" {
+			// 1.0 is an integer
+			if f, err := n.Float64(); err == nil && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatSchemaTypes(t interface{}) string {
+	if types, ok := t.([]interface{}); ok {
+		names := make([]string, len(types))
+		for i, name := range types {
+			names[i] = fmt.Sprint(name)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func compactJSON(value interface{}) string {
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(out)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	if na, ok := a.(json.Number); ok {
+		if nb, ok := b.(json.Number); ok {
+			fa, _ := na.Float64()
+			fb, _ := nb.Float64()
+			return fa == fb
+		}
+	}
+	return compactJSON(a) == compactJSON(b)
+}
+
+// checkSchema validates the response body of r against its schema
+func checkSchema(r *Request) {
+	schema, err := loadSchema(r.Schema)
+	if err == nil {
+		r.SchemaViolations, err = validateSchema(schema, r.RawResponseBody)
+	}
+	r.SchemaError = err
+}
+
+// schemaReport describes the schema and OpenAPI violations of r, it is
+// empty if the response is valid
+func schemaReport(r *Request) string {
+	var reports []string
+	if r.SchemaError != nil {
+		reports = append(reports, "Schema error: "+r.SchemaError.Error())
+	} else if len(r.SchemaViolations) > 0 {
+		reports = append(reports, formatViolations(fmt.Sprintf("%d schema violations (%v):", len(r.SchemaViolations), r.Schema), r.SchemaViolations))
+	}
+	if r.ContractError != nil {
+		reports = append(reports, "OpenAPI error: "+r.ContractError.Error())
+	} else if len(r.ContractViolations) > 0 {
+		reports = append(reports, formatViolations(fmt.Sprintf("%d OpenAPI mismatches:", len(r.ContractViolations)), r.ContractViolations))
+	}
+	return strings.Join(reports, "\n\n")
+}
+
+func formatViolations(title string, violations []schemaViolation) string {
+	lines := []string{title}
+	for _, violation := range violations {
+		lines = append(lines, violation.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// OpenSchemaDialog prompts for the JSON Schema the responses are validated
+// against
+func (a *App) OpenSchemaDialog(g *gocui.Gui, _ *gocui.View) error {
+	dialog, err := a.CreatePopupView(SCHEMA_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[SCHEMA_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, a.schema)
+	g.SetViewOnTop(SCHEMA_VIEW)
+	g.SetCurrentView(SCHEMA_VIEW)
+	return nil
+}
+
+func (a *App) submitSchema(g *gocui.Gui, _ *gocui.View) error {
+	a.schema = strings.TrimSpace(getViewValue(g, SCHEMA_VIEW))
+	a.closePopup(g, SCHEMA_VIEW)
+	refreshStatusLine(a, g)
+	return nil
+}
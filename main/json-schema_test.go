@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema, err := decodeJSONNumbers([]byte(`{
+		"type": "object",
+		"required": ["id", "email"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"email": {"type": "string", "pattern": "@"},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "items": {"$ref": "#/definitions/tag"}, "uniqueItems": true},
+			"a/b": {"oneOf": [{"type": "string"}, {"type": "number"}]}
+		},
+		"definitions": {"tag": {"type": "string", "maxLength": 3}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := validateSchema(schema, []byte(`{"id": 1.0, "email": "a@b", "role": "user", "tags": ["x"], "a/b": 2}`))
+	if err != nil || len(violations) != 0 {
+		t.Errorf("unexpected violations %v, %v", violations, err)
+	}
+
+	violations, err = validateSchema(schema, []byte(`{"id": 0, "role": "root", "tags": ["long", "x", "x"], "a/b": null, "extra": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, violation := range violations {
+		messages = append(messages, violation.String())
+	}
+	expected := []string{
+		`(root): missing required property "email"`,
+		`/a~1b: matches 0 schemas of oneOf instead of one`,
+		`/extra: additional property is not allowed`,
+		`/id: 0 is lower than 1`,
+		`/role: "root" is not one of ["admin","user"]`,
+		`/tags/2: duplicate of item 1`,
+		`/tags/0: longer than 3 characters`,
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected violations:\n%v", strings.Join(messages, "\n"))
+	}
+
+	if _, err := validateSchema(schema, []byte("not json")); err == nil {
+		t.Error("expected an error for a body which is not JSON")
+	}
+}
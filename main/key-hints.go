@@ -0,0 +1,110 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// KEY_HINT_SKIPPED_COMMANDS are not worth a hint, moving around views is
+// expected to be known
+var KEY_HINT_SKIPPED_COMMANDS = map[string]bool{
+	"scrollUp":     true,
+	"scrollDown":   true,
+	"scrollLeft":   true,
+	"scrollRight":  true,
+	"pageUp":       true,
+	"pageDown":     true,
+	"halfPageUp":   true,
+	"halfPageDown": true,
+	"scrollTop":    true,
+	"scrollBottom": true,
+	"nextView":     true,
+	"prevView":     true,
+	"focus":        true,
+	"deleteLine":   true,
+	"deleteWord":   true,
+	"undo":         true,
+	"redo":         true,
+	"quit":         true,
+}
+
+// KEY_HINT_GLOBAL_COMMANDS are the global commands hinted after those of
+// the focused view, most relevant first
+var KEY_HINT_GLOBAL_COMMANDS = []string{
+	"submit",
+	"history",
+	"previewRequest",
+	"saveRequest",
+	"saveResponse",
+	"keybindings",
+}
+
+// keyHints returns up to limit "key command" hints of the view, its own
+// commands first, followed by the help key
+func keyHints(keys map[string]map[string]string, view string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	var hints []string
+	hinted := make(map[string]bool)
+	add := func(key, command string) {
+		if len(hints) < limit && !hinted[command] {
+			hinted[command] = true
+			hints = append(hints, formatHintKey(key)+" "+command)
+		}
+	}
+
+	viewKeys := keys[view]
+	if view == "global" {
+		viewKeys = nil
+	}
+	byCommand := make(map[string]string, len(viewKeys))
+	for key, command := range viewKeys {
+		name := strings.SplitN(command, " ", 2)[0]
+		if command == "" || KEY_HINT_SKIPPED_COMMANDS[name] {
+			continue
+		}
+		// the same key is hinted for commands bound to several keys
+		if other, found := byCommand[command]; !found || key < other {
+			byCommand[command] = key
+		}
+	}
+	commands := make([]string, 0, len(byCommand))
+	for command := range byCommand {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	for _, command := range commands {
+		add(byCommand[command], command)
+	}
+
+	for _, command := range KEY_HINT_GLOBAL_COMMANDS {
+		globalKeys := make([]string, 0, 1)
+		for key, globalCommand := range keys["global"] {
+			if globalCommand == command {
+				globalKeys = append(globalKeys, key)
+			}
+		}
+		if len(globalKeys) > 0 {
+			sort.Strings(globalKeys)
+			add(globalKeys[0], command)
+		}
+	}
+	return strings.Join(append(hints, "F1 help"), " | ")
+}
+
+// formatHintKey writes the modifiers of key names like the help, e.g.
+// "ctrl+r" for CtrlR
+func formatHintKey(key string) string {
+	for _, modifier := range []string{"Ctrl", "Alt"} {
+		rest, found := strings.CutPrefix(key, modifier)
+		if found && rest != "" {
+			if utf8.RuneCountInString(rest) == 1 {
+				rest = strings.ToLower(rest)
+			}
+			return strings.ToLower(modifier) + "+" + rest
+		}
+	}
+	return key
+}
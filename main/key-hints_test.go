@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestKeyHints(t *testing.T) {
+	for _, test := range []struct {
+		view     string
+		limit    int
+		expected string
+	}{
+		{"response-body", 3, "alt+j copyJSONPath | alt+v copyJSONValue | alt+x extractJSONPath | F1 help"},
+		{"url", 2, "Enter submit | alt+h history | F1 help"},
+		{"get", 2, "ctrl+r submit | alt+h history | F1 help"},
+		{"global", 1, "ctrl+r submit | F1 help"},
+		{"url", 0, ""},
+	} {
+		keys := config.DefaultKeys
+		if test.view == "url" {
+			keys = map[string]map[string]string{
+				"global": {"CtrlR": "submit", "CtrlG": "history", "AltH": "history"},
+				"url":    {"Enter": "submit", "ArrowUp": "scrollUp"},
+			}
+		}
+		if hints := keyHints(keys, test.view, test.limit); hints != test.expected {
+			t.Errorf("%v: expected %q, got %q", test.view, test.expected, hints)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// keyBinding is a line of the keybinding editor, conflict describes why it
+// does not work as expected
+type keyBinding struct {
+	category string
+	key      string
+	command  string
+	conflict string
+}
+
+// KEY_BINDING_INPUT_PATTERN matches the "category key = command" input of
+// the keybinding editor, an empty command removes the binding
+var KEY_BINDING_INPUT_PATTERN = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s+(\S+)\s*=\s*(.*?)\s*$`)
+
+var BARE_TOML_KEY_PATTERN = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func init() {
+	// registered here as the editor checks the commands of COMMANDS
+	COMMANDS["keybindings"] = func(_ string, a *App) CommandFunc {
+		return a.ToggleKeyBindings
+	}
+	COMMAND_DESCRIPTIONS["keybindings"] = "List, change and check the keybindings"
+}
+
+// keyConflict returns why the binding of key in category conflicts with the
+// other bindings, global bindings fire together with those of the views
+func keyConflict(keys map[string]map[string]string, category, key, command string) string {
+	parsed, mod, err := parseKey(key)
+	if err != nil {
+		return err.Error()
+	}
+	if name := strings.SplitN(command, " ", 2)[0]; command != "" && COMMANDS[name] == nil {
+		return "unknown command: " + name
+	}
+	if parsed == gocui.KeyF1 && mod == gocui.ModNone {
+		return "F1 opens the help"
+	}
+	for _, otherCategory := range sortedCategories(keys) {
+		if otherCategory != category && otherCategory != "global" && category != "global" {
+			continue
+		}
+		for otherKey, otherCommand := range keys[otherCategory] {
+			if otherCommand == "" || (otherCategory == category && otherKey == key) {
+				continue
+			}
+			if otherParsed, otherMod, err := parseKey(otherKey); err == nil && otherParsed == parsed && otherMod == mod {
+				return fmt.Sprintf("also bound to %v in %v", otherCommand, otherCategory)
+			}
+		}
+	}
+	return ""
+}
+
+// sortedCategories returns the keybinding categories, global first
+func sortedCategories(keys map[string]map[string]string) []string {
+	categories := make([]string, 0, len(keys))
+	for category := range keys {
+		if category != "global" {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return append([]string{"global"}, categories...)
+}
+
+// keyBindings lists the bound keys with their conflicts
+func (a *App) keyBindings() []keyBinding {
+	var bindings []keyBinding
+	for _, category := range sortedCategories(a.config.Keys) {
+		keys := make([]string, 0, len(a.config.Keys[category]))
+		for key, command := range a.config.Keys[category] {
+			if command != "" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			command := a.config.Keys[category][key]
+			bindings = append(bindings, keyBinding{category, key, command, keyConflict(a.config.Keys, category, key, command)})
+		}
+	}
+	return bindings
+}
+
+// ToggleKeyBindings lists the keybindings, the selected one is changed with
+// enter and new ones are added with n
+func (a *App) ToggleKeyBindings(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == KEYBINDINGS_VIEW {
+		a.closePopup(g, KEYBINDINGS_VIEW)
+		return nil
+	}
+	a.keyBindingList = a.keyBindings()
+	v, err := a.CreatePopupView(KEYBINDINGS_VIEW, 100, len(a.keyBindingList), g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[KEYBINDINGS_VIEW]
+	for _, b := range a.keyBindingList {
+		fmt.Fprintf(v, "%-16s %-14s %-28s", b.category, b.key, b.command)
+		if b.conflict != "" {
+			fmt.Fprintf(v, " \x1b[0;31m! %s\x1b[0;0m", b.conflict)
+		}
+		fmt.Fprintln(v)
+	}
+	g.SetViewOnTop(KEYBINDINGS_VIEW)
+	g.SetCurrentView(KEYBINDINGS_VIEW)
+	selectListLine(v, 0)
+	return nil
+}
+
+// openKeyBindingEditor prompts for the "category key = command" binding
+// replacing the edited one, nil adds a new binding
+func (a *App) openKeyBindingEditor(g *gocui.Gui, edited *keyBinding) error {
+	input := "global "
+	if edited != nil {
+		input = fmt.Sprintf("%v %v = %v", edited.category, edited.key, edited.command)
+	}
+	a.editedKeyBinding = edited
+	dialog, err := a.CreatePopupView(KEYBINDING_EDIT_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[KEYBINDING_EDIT_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, input)
+	g.SetViewOnTop(KEYBINDING_EDIT_VIEW)
+	g.SetCurrentView(KEYBINDING_EDIT_VIEW)
+	return nil
+}
+
+func (a *App) editSelectedKeyBinding(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.keyBindingList) {
+		return nil
+	}
+	selected := a.keyBindingList[cy+oy]
+	return a.openKeyBindingEditor(g, &selected)
+}
+
+func (a *App) submitKeyBinding(g *gocui.Gui, _ *gocui.View) error {
+	input := getViewValue(g, KEYBINDING_EDIT_VIEW)
+	a.closePopup(g, KEYBINDING_EDIT_VIEW)
+	m := KEY_BINDING_INPUT_PATTERN.FindStringSubmatch(input)
+	if m == nil {
+		return a.OpenMessageView("Error: expected category key = command, e.g. response-body AltY = openInBrowser", g)
+	}
+	category, key, command := m[1], m[2], m[3]
+	if _, found := a.config.Keys[category]; !found {
+		return a.OpenMessageView("Error: unknown category "+category, g)
+	}
+
+	// the edited binding is replaced, it does not conflict with the new one
+	keys := make(map[string]map[string]string, len(a.config.Keys))
+	for c, bindings := range a.config.Keys {
+		keys[c] = make(map[string]string, len(bindings))
+		for k, cmd := range bindings {
+			keys[c][k] = cmd
+		}
+	}
+	if edited := a.editedKeyBinding; edited != nil {
+		keys[edited.category][edited.key] = ""
+	}
+	if command != "" {
+		if conflict := keyConflict(keys, category, key, command); conflict != "" {
+			return a.OpenMessageView(fmt.Sprintf("Error: %v: %v", key, conflict), g)
+		}
+	}
+
+	changes := []keyBinding{{category: category, key: key, command: command}}
+	if edited := a.editedKeyBinding; edited != nil && (edited.category != category || edited.key != key) {
+		changes = append([]keyBinding{{category: edited.category, key: edited.key}}, changes...)
+	}
+	for _, change := range changes {
+		if err := a.rebindKey(g, change.category, change.key, change.command); err != nil {
+			return a.OpenMessageView("Error: "+err.Error(), g)
+		}
+	}
+	if err := writeConfigKeys(a.configPath, changes); err != nil {
+		return a.OpenMessageView("Keybinding changed but not saved: "+err.Error(), g)
+	}
+	return a.OpenMessageView(fmt.Sprintf("%v %v = %q saved to %v", category, key, command, a.configPath), g)
+}
+
+// rebindKey replaces the binding of key in category by command, an empty
+// command removes it
+func (a *App) rebindKey(g *gocui.Gui, category, key, command string) error {
+	viewName := category
+	if category == "global" {
+		viewName = ALL_VIEWS
+	}
+	parsed, mod, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	g.DeleteKeybinding(viewName, parsed, mod)
+	a.config.Keys[category][key] = command
+	return a.setKey(g, key, command, viewName)
+}
+
+// writeConfigKeys saves the changed keybindings in the config file, created
+// if it does not exist yet
+func writeConfigKeys(path string, changes []keyBinding) error {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	text := string(content)
+	for _, change := range changes {
+		text = updateConfigKey(text, change.category, change.key, change.command)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(text), 0o644)
+}
+
+// updateConfigKey sets the key of the [keys.category] section of the TOML
+// config text, keeping the rest of the file as it is
+func updateConfigKey(text, category, key, command string) string {
+	tomlKey := key
+	if !BARE_TOML_KEY_PATTERN.MatchString(key) {
+		tomlKey = strconv.Quote(key)
+	}
+	line := tomlKey + " = " + strconv.Quote(command)
+	keyPattern := regexp.MustCompile(`^\s*(` + regexp.QuoteMeta(key) + `|"` + regexp.QuoteMeta(key) + `")\s*=`)
+
+	lines := strings.Split(text, "\n")
+	section := -1
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if section < 0 {
+			if trimmed == "[keys."+category+"]" {
+				section = i
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			break
+		}
+		if keyPattern.MatchString(l) {
+			lines[i] = line
+			return strings.Join(lines, "\n")
+		}
+	}
+	if section < 0 {
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if text != "" {
+			text += "\n"
+		}
+		return text + "[keys." + category + "]\n" + line + "\n"
+	}
+	// insert after the last binding of the section
+	last := section
+	for i := section + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "[") {
+			break
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			last = i
+		}
+	}
+	lines = append(lines[:last+1], append([]string{line}, lines[last+1:]...)...)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestKeyConflict(t *testing.T) {
+	keys := map[string]map[string]string{
+		"global":        {"CtrlR": "submit", "AltT": "toggleHTMLFormat"},
+		"response-body": {"AltT": "toggleHTMLFormat", "AltB": "openInBrowser"},
+		"data":          {"AltB": "prettifyJSON"},
+	}
+	for _, tc := range []struct {
+		category, key, command, expected string
+	}{
+		{"global", "CtrlR", "submit", ""},
+		{"response-body", "AltB", "openInBrowser", ""},
+		{"response-body", "AltT", "toggleHTMLFormat", "also bound to toggleHTMLFormat in global"},
+		{"global", "Altt", "toggleHTMLFormat", "also bound to toggleHTMLFormat in global"},
+		{"data", "CtrlX", "noSuchCommand", "unknown command: noSuchCommand"},
+		{"data", "AltFoo", "submit", "unknown key: Foo"},
+		{"global", "F1", "submit", "F1 opens the help"},
+	} {
+		if conflict := keyConflict(keys, tc.category, tc.key, tc.command); conflict != tc.expected {
+			t.Errorf("unexpected conflict of %v %v: %q", tc.category, tc.key, conflict)
+		}
+	}
+}
+
+func TestUpdateConfigKey(t *testing.T) {
+	text := `[general]
+timeout = "1m"
+
+[keys.global]
+# send
+CtrlR = "submit"
+
+[keys.response-body]
+AltB = "openInBrowser"
+`
+	text = updateConfigKey(text, "global", "CtrlR", "")
+	text = updateConfigKey(text, "global", "F10", "submit")
+	text = updateConfigKey(text, "response-body", "|", "pipeResponse")
+	text = updateConfigKey(text, "history", "Delete", "deleteHistoryEntry")
+	expected := `[general]
+timeout = "1m"
+
+[keys.global]
+# send
+CtrlR = ""
+F10 = "submit"
+
+[keys.response-body]
+AltB = "openInBrowser"
+"|" = "pipeResponse"
+
+[keys.history]
+Delete = "deleteHistoryEntry"
+`
+	if text != expected {
+		t.Errorf("unexpected config:\n%s", text)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// LOG_HANDLER writes to the current log file, the config reload changes the
+// file while the requests in progress are logged
+var LOG_HANDLER = &logHandler{}
+
+// LOGGER writes the structured log of the requests, responses and errors
+// to the file set by logFile, it discards them otherwise
+var LOGGER = slog.New(LOG_HANDLER)
+
+func init() {
+	LOG_HANDLER.Store(slog.NewJSONHandler(io.Discard, nil))
+}
+
+// logHandler passes the log records to the JSON handler of the log file
+type logHandler struct {
+	atomic.Pointer[slog.JSONHandler]
+}
+
+func (h *logHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.Load().Enabled(ctx, level)
+}
+
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.Load().Handle(ctx, record)
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.Load().WithAttrs(attrs)
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return h.Load().WithGroup(name)
+}
+
+// openLogFile appends the JSON log lines to the file at path
+func openLogFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	LOG_HANDLER.Store(slog.NewJSONHandler(file, nil))
+	return nil
+}
+
+// logResponse records the metadata of the response of r
+func logResponse(r *Request) {
+	LOGGER.Info("response",
+		"method", r.Method,
+		"url", r.fullURL(),
+		"status", r.StatusCode,
+		"proto", r.Proto,
+		"content_type", r.ContentType,
+		"size", len(r.RawResponseBody),
+		"transferred", r.TransferSize,
+		"duration_ms", r.Duration.Milliseconds(),
+		"download_ms", r.DownloadDuration.Milliseconds(),
+		"remote_addr", r.RemoteAddr,
+		"reused", r.ConnReused,
+		"cache", r.CacheStatus,
+	)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogResponse(t *testing.T) {
+	defaultHandler := LOG_HANDLER.Load()
+	defer LOG_HANDLER.Store(defaultHandler)
+
+	path := filepath.Join(t.TempDir(), "buzz.log")
+	if err := openLogFile(path); err != nil {
+		t.Fatal(err)
+	}
+	logResponse(&Request{
+		Method:          http.MethodGet,
+		Url:             "https://example.com/items",
+		GetParams:       "page=2",
+		StatusCode:      200,
+		ContentType:     "application/json",
+		RawResponseBody: []byte(`{}`),
+		Duration:        1500 * time.Millisecond,
+	})
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(content))), &entry); err != nil {
+		t.Fatalf("invalid log line %q: %v", content, err)
+	}
+	if entry["msg"] != "response" || entry["url"] != "https://example.com/items?page=2" || entry["status"] != 200.0 || entry["duration_ms"] != 1500.0 || entry["size"] != 2.0 {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// multipartPart is a part of a multipart/form-data request body. The data
+// view stores one part per line like curl's -F option: "name=value" for
+// text parts and "name=@path" for file parts, optionally followed by
+// ";type=CONTENT-TYPE".
+type multipartPart struct {
+	Name        string
+	Value       string // text or path of the file
+	File        bool
+	ContentType string
+}
+
+var multipartValueEscaper = strings.NewReplacer(
+	"%", "%25",
+	"&", "%26",
+	"+", "%2B",
+	";", "%3B",
+	"=", "%3D",
+	"\n", "%0A",
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// parseMultipartParts parses the multipart parts of the data view, parts
+// are separated by new lines or "&" and their values are URL decoded
+func parseMultipartParts(data string) ([]multipartPart, error) {
+	var parts []multipartPart
+	for _, field := range strings.FieldsFunc(data, func(r rune) bool { return r == '\n' || r == '&' }) {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(field, "=")
+		var part multipartPart
+		var err error
+		if i := strings.LastIndex(value, ";type="); i >= 0 {
+			if part.ContentType, err = url.QueryUnescape(value[i+len(";type="):]); err != nil {
+				return nil, fmt.Errorf("Invalid form data: %v", err)
+			}
+			value = value[:i]
+		}
+		if strings.HasPrefix(value, "@") {
+			part.File = true
+			value = value[1:]
+		}
+		if part.Name, err = url.QueryUnescape(name); err != nil {
+			return nil, fmt.Errorf("Invalid form data: %v", err)
+		}
+		if part.Value, err = url.QueryUnescape(value); err != nil {
+			return nil, fmt.Errorf("Invalid form data: %v", err)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+func (p multipartPart) String() string {
+	s := multipartValueEscaper.Replace(p.Name) + "="
+	value := multipartValueEscaper.Replace(p.Value)
+	if p.File {
+		s += "@"
+	} else if strings.HasPrefix(value, "@") {
+		value = "%40" + value[1:]
+	}
+	s += value
+	if p.ContentType != "" {
+		s += ";type=" + multipartValueEscaper.Replace(p.ContentType)
+	}
+	return s
+}
+
+func formatMultipartParts(parts []multipartPart) string {
+	lines := make([]string, len(parts))
+	for i, p := range parts {
+		lines[i] = p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p multipartPart) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.Name))
+	contentType := p.ContentType
+	if p.File {
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(filepath.Base(p.Value)))
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(p.Value))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	h.Set("Content-Disposition", disposition)
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return h
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// newMultipartBody returns a multipart/form-data body streaming the parts,
+// the content of the files is read while the body is sent. The length of
+// the body is computed in advance from the size of the files.
+func newMultipartBody(parts []multipartPart) (body io.Reader, contentType string, length int64, err error) {
+	// compute the length without the content of the files
+	counter := &countingWriter{}
+	mw := multipart.NewWriter(counter)
+	for _, p := range parts {
+		w, err := mw.CreatePart(p.header())
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if !p.File {
+			io.WriteString(w, p.Value)
+			continue
+		}
+		info, err := os.Stat(p.Value)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		if info.IsDir() {
+			return nil, "", 0, fmt.Errorf("%s is a directory", p.Value)
+		}
+		counter.n += info.Size()
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", 0, err
+	}
+	boundary := mw.Boundary()
+
+	pr, pw := io.Pipe()
+	go func() {
+		mw := multipart.NewWriter(pw)
+		mw.SetBoundary(boundary)
+		pw.CloseWithError(writeMultipartParts(mw, parts))
+	}()
+	return pr, mw.FormDataContentType(), counter.n, nil
+}
+
+func writeMultipartParts(mw *multipart.Writer, parts []multipartPart) error {
+	for _, p := range parts {
+		w, err := mw.CreatePart(p.header())
+		if err != nil {
+			return err
+		}
+		if !p.File {
+			if _, err := io.WriteString(w, p.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		file, err := os.Open(p.Value)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// ToggleMultipartEditor lists the parts of the multipart request body
+func (a *App) ToggleMultipartEditor(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == MULTIPART_VIEW {
+		a.closePopup(g, MULTIPART_VIEW)
+		return nil
+	}
+	parts, err := parseMultipartParts(getViewValue(g, REQUEST_DATA_VIEW))
+	if err != nil {
+		return a.OpenMessageView(err.Error(), g)
+	}
+	a.multipartParts = parts
+	return a.openMultipartEditor(g, 0)
+}
+
+func (a *App) openMultipartEditor(g *gocui.Gui, selected int) error {
+	maxX, _ := g.Size()
+	v, err := a.CreatePopupView(MULTIPART_VIEW, maxX*3/4, len(a.multipartParts)+1, g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[MULTIPART_VIEW]
+	if len(a.multipartParts) == 0 {
+		fmt.Fprintln(v, "[!] No parts, press insert to add a text part or alt+o to add a file")
+	}
+	for _, p := range a.multipartParts {
+		kind := "text"
+		if p.File {
+			kind = "file"
+		}
+		contentType := p.ContentType
+		if contentType == "" {
+			contentType = "-"
+		}
+		fmt.Fprintf(v, "%-20s %-4s  %-24s %s\n", p.Name, kind, contentType, strings.ReplaceAll(p.Value, "\n", "\\n"))
+	}
+	g.SetViewOnTop(MULTIPART_VIEW)
+	g.SetCurrentView(MULTIPART_VIEW)
+	if selected >= len(a.multipartParts) {
+		selected = len(a.multipartParts) - 1
+	}
+	if selected > 0 {
+		selectListLine(v, selected)
+	}
+	return nil
+}
+
+// selectedMultipartPart returns the index of the part under the cursor
+func (a *App) selectedMultipartPart(v *gocui.View) (int, bool) {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	i := cy + oy
+	return i, i >= 0 && i < len(a.multipartParts)
+}
+
+// saveMultipartParts writes the parts to the data view and sets the
+// multipart content type if the request has none
+func (a *App) saveMultipartParts(g *gocui.Gui) {
+	vdata, _ := g.View(REQUEST_DATA_VIEW)
+	setViewTextAndCursor(vdata, formatMultipartParts(a.multipartParts))
+	if !a.hasHeader(g, "Content-Type") {
+		vheader, _ := g.View(REQUEST_HEADERS_VIEW)
+		headers := getViewValue(g, REQUEST_HEADERS_VIEW)
+		if headers != "" {
+			headers += "\n"
+		}
+		setViewTextAndCursor(vheader, headers+"Content-Type: "+config.ContentTypes["multipart"])
+	}
+}
+
+func (a *App) deleteMultipartPart(g *gocui.Gui, v *gocui.View) error {
+	i, found := a.selectedMultipartPart(v)
+	if !found {
+		return nil
+	}
+	a.multipartParts = append(a.multipartParts[:i], a.multipartParts[i+1:]...)
+	a.saveMultipartParts(g)
+	return a.openMultipartEditor(g, i)
+}
+
+// editMultipartPart opens a dialog to edit the part under the cursor in the
+// format of the data view
+func (a *App) editMultipartPart(g *gocui.Gui, v *gocui.View) error {
+	i, found := a.selectedMultipartPart(v)
+	if !found {
+		return nil
+	}
+	return a.openMultipartPartDialog(g, i, a.multipartParts[i])
+}
+
+func (a *App) addMultipartText(g *gocui.Gui, _ *gocui.View) error {
+	return a.openMultipartPartDialog(g, -1, multipartPart{Name: "field"})
+}
+
+func (a *App) addMultipartFile(g *gocui.Gui, _ *gocui.View) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "/"
+	}
+	return a.OpenFilePicker(g, dir, func(g *gocui.Gui, path string) error {
+		a.multipartParts = append(a.multipartParts, multipartPart{
+			Name:        "file",
+			Value:       path,
+			File:        true,
+			ContentType: mime.TypeByExtension(filepath.Ext(path)),
+		})
+		a.saveMultipartParts(g)
+		return a.openMultipartEditor(g, len(a.multipartParts)-1)
+	})
+}
+
+// openMultipartPartDialog edits p, the part at index i or a new part if i
+// is negative
+func (a *App) openMultipartPartDialog(g *gocui.Gui, i int, p multipartPart) error {
+	dialog, err := a.CreatePopupView(MULTIPART_PART_DIALOG_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[MULTIPART_PART_DIALOG_VIEW]
+	dialog.Editable = true
+	dialog.Highlight = false
+	setViewTextAndCursor(dialog, p.String())
+	g.SetViewOnTop(MULTIPART_PART_DIALOG_VIEW)
+	g.SetCurrentView(MULTIPART_PART_DIALOG_VIEW)
+	g.DeleteKeybinding(MULTIPART_PART_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone)
+	g.SetKeybinding(MULTIPART_PART_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		parts, err := parseMultipartParts(getViewValue(g, MULTIPART_PART_DIALOG_VIEW))
+		if err == nil && len(parts) != 1 {
+			err = errors.New("a single part is expected")
+		}
+		if err != nil {
+			a.closePopup(g, MULTIPART_PART_DIALOG_VIEW)
+			return a.OpenMessageView(err.Error(), g)
+		}
+		if i < 0 {
+			i = len(a.multipartParts)
+			a.multipartParts = append(a.multipartParts, parts[0])
+		} else {
+			a.multipartParts[i] = parts[0]
+		}
+		a.saveMultipartParts(g)
+		return a.openMultipartEditor(g, i)
+	})
+	return nil
+}
+
+// OpenFilePicker lists the content of dir, choosing a file calls choose with
+// its path
+func (a *App) OpenFilePicker(g *gocui.Gui, dir string, choose func(g *gocui.Gui, path string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return a.OpenMessageView(err.Error(), g)
+	}
+	names := []string{".."}
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name()+"/")
+		} else {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(names[1:])
+	sort.Strings(files)
+	names = append(names, files...)
+
+	maxX, maxY := g.Size()
+	v, err := a.CreatePopupView(FILE_PICKER_VIEW, maxX/2, maxY, g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[FILE_PICKER_VIEW] + ": " + dir
+	for _, name := range names {
+		fmt.Fprintln(v, name)
+	}
+	g.SetViewOnTop(FILE_PICKER_VIEW)
+	g.SetCurrentView(FILE_PICKER_VIEW)
+
+	g.DeleteKeybinding(FILE_PICKER_VIEW, gocui.KeyArrowDown, gocui.ModNone)
+	g.SetKeybinding(FILE_PICKER_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(_ *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(names))
+	})
+	g.DeleteKeybinding(FILE_PICKER_VIEW, gocui.KeyArrowUp, gocui.ModNone)
+	g.SetKeybinding(FILE_PICKER_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(_ *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(names))
+	})
+	g.DeleteKeybinding(FILE_PICKER_VIEW, gocui.KeyEnter, gocui.ModNone)
+	g.SetKeybinding(FILE_PICKER_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		_, cy := v.Cursor()
+		_, oy := v.Origin()
+		if cy+oy >= len(names) {
+			return nil
+		}
+		name := names[cy+oy]
+		if name == ".." || strings.HasSuffix(name, "/") {
+			return a.OpenFilePicker(g, filepath.Join(dir, name), choose)
+		}
+		a.closePopup(g, FILE_PICKER_VIEW)
+		return choose(g, filepath.Join(dir, name))
+	})
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMultipartPartsFormat(t *testing.T) {
+	parts := []multipartPart{
+		{Name: "comment", Value: "a=b&c+d; e\nf"},
+		{Name: "handle", Value: "@buzz"},
+		{Name: "avatar", Value: "/tmp/a b.png", File: true, ContentType: "image/png"},
+	}
+	parsed, err := parseMultipartParts(formatMultipartParts(parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, parts) {
+		t.Errorf("expected %+v, got %+v", parts, parsed)
+	}
+
+	// form data of previous versions
+	parsed, err = parseMultipartParts("a=1&b=x%20y\nfile=@/tmp/f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []multipartPart{
+		{Name: "a", Value: "1"},
+		{Name: "b", Value: "x y"},
+		{Name: "file", Value: "/tmp/f.txt", File: true},
+	}
+	if !reflect.DeepEqual(parsed, expected) {
+		t.Errorf("expected %+v, got %+v", expected, parsed)
+	}
+}
+
+func TestMultipartBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte(`{"a": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	body, contentType, length, err := newMultipartBody([]multipartPart{
+		{Name: "name", Value: "buzz"},
+		{Name: "upload", Value: path, File: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(data)) != length {
+		t.Errorf("expected length %d, got %d bytes", length, len(data))
+	}
+
+	_, params, _ := mime.ParseMediaType(contentType)
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for _, expected := range []struct{ name, filename, contentType, content string }{
+		{"name", "", "", "buzz"},
+		{"upload", "data.json", "application/json", `{"a": 1}`},
+	} {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(part)
+		if part.FormName() != expected.name || part.FileName() != expected.filename ||
+			part.Header.Get("Content-Type") != expected.contentType || string(content) != expected.content {
+			t.Errorf("unexpected part %v %q", part.Header, content)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("expected the end of the body, got %v", err)
+	}
+}
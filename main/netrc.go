@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// netrcEntry holds the credentials of a machine of a .netrc file, the
+// default entry has an empty machine
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// netrcPath returns the path of the .netrc file used when none is
+// configured: $NETRC or ~/.netrc
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// parseNetrc parses the machine and default entries of a .netrc file,
+// macro definitions are skipped
+func parseNetrc(content string) []netrcEntry {
+	var entries []netrcEntry
+	var entry *netrcEntry
+	var tokens []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// a macro definition ends with an empty line
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "macdef" {
+				fields = fields[:i]
+				inMacro = true
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		var value string
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "machine":
+			entries = append(entries, netrcEntry{machine: value})
+			entry = &entries[len(entries)-1]
+			i++
+		case "default":
+			entries = append(entries, netrcEntry{})
+			entry = &entries[len(entries)-1]
+		case "login", "password", "account":
+			if entry != nil && tokens[i] == "login" {
+				entry.login = value
+			} else if entry != nil && tokens[i] == "password" {
+				entry.password = value
+			}
+			i++
+		}
+	}
+	return entries
+}
+
+// findNetrcEntry returns the entry of host, or the default entry if there
+// is no entry for host
+func findNetrcEntry(entries []netrcEntry, host string) (netrcEntry, bool) {
+	var fallback netrcEntry
+	hasDefault := false
+	for _, entry := range entries {
+		if entry.machine == "" && !hasDefault {
+			fallback, hasDefault = entry, true
+		} else if strings.EqualFold(entry.machine, host) {
+			return entry, true
+		}
+	}
+	return fallback, hasDefault
+}
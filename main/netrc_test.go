@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParseNetrc(t *testing.T) {
+	entries := parseNetrc(`# comment
+machine api.example.com
+  login alice
+  password s3cret
+macdef init
+cd /pub
+machine ignored login x
+
+machine other.example.com login bob password hunter2
+default login anonymous password guest
+`)
+	for _, tc := range []struct {
+		host, login, password string
+	}{
+		{"api.example.com", "alice", "s3cret"},
+		{"OTHER.example.com", "bob", "hunter2"},
+		{"unknown.example.com", "anonymous", "guest"},
+	} {
+		entry, found := findNetrcEntry(entries, tc.host)
+		if !found || entry.login != tc.login || entry.password != tc.password {
+			t.Errorf("%s: unexpected entry %+v", tc.host, entry)
+		}
+	}
+	if len(entries) != 3 {
+		t.Errorf("macro content parsed as entries: %+v", entries)
+	}
+}
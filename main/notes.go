@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// ToggleNotes opens the notes of the request, they are kept when the popup
+// is closed
+func (a *App) ToggleNotes(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == NOTES_VIEW {
+		return a.closeNotes(g)
+	}
+	maxX, maxY := g.Size()
+	v, err := a.CreatePopupView(NOTES_VIEW, maxX*2/3, maxY/2, g)
+	if err != nil {
+		return err
+	}
+	a.notesEntry = nil
+	if len(a.history) > 0 {
+		a.notesEntry = a.history[a.historyIndex]
+	}
+	g.Cursor = true
+	v.Title = VIEW_TITLES[NOTES_VIEW]
+	v.Editable = true
+	v.Wrap = true
+	v.Editor = &defaultEditor
+	fmt.Fprint(v, a.notes)
+	g.SetViewOnTop(NOTES_VIEW)
+	g.SetCurrentView(NOTES_VIEW)
+	return nil
+}
+
+// closeNotes closes the notes popup, its notes are saved by closePopup
+func (a *App) closeNotes(g *gocui.Gui) error {
+	a.closePopup(g, NOTES_VIEW)
+	return nil
+}
+
+// saveNotes stores the notes of the popup v as those of the request and of
+// the history entry displayed when they were opened
+func (a *App) saveNotes(v *gocui.View) {
+	a.notes = strings.TrimSpace(v.Buffer())
+	if a.notesEntry != nil {
+		a.notesEntry.Notes = a.notes
+		a.notesEntry = nil
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNotesExport(t *testing.T) {
+	var requestMap map[string]string
+	if err := json.Unmarshal(exportJSON(Request{Url: "https://example.com", Notes: "returns 204"}), &requestMap); err != nil {
+		t.Fatal(err)
+	}
+	if requestMap[NOTES_VIEW] != "returns 204" {
+		t.Errorf("the notes are not exported: %v", requestMap)
+	}
+	requestMap = nil
+	if err := json.Unmarshal(exportJSON(Request{Url: "https://example.com"}), &requestMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := requestMap[NOTES_VIEW]; found {
+		t.Errorf("empty notes are exported: %v", requestMap)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// OFFLINE_REPLAY serves the recorded responses instead of sending the
+// requests when the offline mode is enabled
+var OFFLINE_REPLAY = &offlineReplay{}
+
+// recordedResponse is a response of the history or of a HAR file
+type recordedResponse struct {
+	method string
+	url    string
+	proto  string
+	status int
+	header http.Header
+	body   []byte
+}
+
+// offlineReplay holds the responses of the HAR file and the entries of the
+// history, the latest response recorded for a method and URL is served
+type offlineReplay struct {
+	mu      sync.Mutex
+	enabled bool
+	har     []recordedResponse
+	history []*Request
+}
+
+func (o *offlineReplay) setEnabled(enabled bool) {
+	o.mu.Lock()
+	o.enabled = enabled
+	o.mu.Unlock()
+}
+
+func (o *offlineReplay) isEnabled() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.enabled
+}
+
+// setHAR replaces the responses of the HAR file
+func (o *offlineReplay) setHAR(responses []recordedResponse) {
+	o.mu.Lock()
+	o.har = responses
+	o.mu.Unlock()
+}
+
+// setHistory replaces the history entries whose responses are served. It
+// is called by the UI goroutine whenever the history changes, the entries
+// deleted from it are not served anymore.
+func (o *offlineReplay) setHistory(history []*Request) {
+	o.mu.Lock()
+	o.history = slices.Clone(history)
+	o.mu.Unlock()
+}
+
+// lookup returns the latest response recorded for method and rawURL, those
+// of the history first
+func (o *offlineReplay) lookup(method, rawURL string) (recordedResponse, bool) {
+	key := offlineKey(rawURL)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.history) - 1; i >= 0; i-- {
+		r := o.history[i]
+		if r.StatusCode != 0 && r.Method == method && offlineKey(r.fullURL()) == key {
+			return recordedResponse{
+				method: r.Method,
+				url:    key,
+				proto:  r.Proto,
+				status: r.StatusCode,
+				header: r.ResponseHeader,
+				body:   r.RawResponseBody,
+			}, true
+		}
+	}
+	for i := len(o.har) - 1; i >= 0; i-- {
+		if o.har[i].method == method && o.har[i].url == key {
+			return o.har[i], true
+		}
+	}
+	return recordedResponse{}, false
+}
+
+// offlineKey normalizes rawURL so that recorded and sent URLs compare equal
+func offlineKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// harFile is the part of an HTTP Archive needed to replay its responses
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method string
+				URL    string
+			}
+			Response struct {
+				Status      int
+				HTTPVersion string
+				Headers     []struct {
+					Name  string
+					Value string
+				}
+				Content struct {
+					Text     string
+					Encoding string
+				}
+			}
+		}
+	}
+}
+
+// loadHAR returns the responses of the HAR file at path
+func loadHAR(path string) ([]recordedResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	responses := make([]recordedResponse, 0, len(har.Log.Entries))
+	for i, entry := range har.Log.Entries {
+		header := make(http.Header)
+		for _, h := range entry.Response.Headers {
+			// HTTP/2 pseudo headers, e.g. :status
+			if !strings.HasPrefix(h.Name, ":") {
+				header.Add(h.Name, h.Value)
+			}
+		}
+		body := []byte(entry.Response.Content.Text)
+		if entry.Response.Content.Encoding == "base64" {
+			if body, err = base64.StdEncoding.DecodeString(entry.Response.Content.Text); err != nil {
+				return nil, fmt.Errorf("%v: entry %d: %v", path, i+1, err)
+			}
+		}
+		responses = append(responses, recordedResponse{
+			method: strings.ToUpper(entry.Request.Method),
+			url:    offlineKey(entry.Request.URL),
+			proto:  strings.ToUpper(entry.Response.HTTPVersion),
+			status: entry.Response.Status,
+			header: header,
+			body:   body,
+		})
+	}
+	return responses, nil
+}
+
+// offlineTransport serves the recorded responses in offline mode and sends
+// the requests with next otherwise
+type offlineTransport struct {
+	next http.RoundTripper
+}
+
+func (t *offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !OFFLINE_REPLAY.isEnabled() {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	recorded, found := OFFLINE_REPLAY.lookup(req.Method, req.URL.String())
+	if !found {
+		return nil, fmt.Errorf("offline: no recorded response for %v %v", req.Method, req.URL)
+	}
+	WIRE_LOG.printf("*", "Offline: replaying the recorded response of %v %v", req.Method, req.URL)
+	proto := recorded.proto
+	major, minor, ok := http.ParseHTTPVersion(proto)
+	if !ok {
+		proto, major, minor = "HTTP/1.1", 1, 1
+	}
+	header := recorded.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// the recorded bodies are already decoded
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	if served, found := req.Context().Value(offlineStatusKey{}).(*bool); found {
+		*served = true
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.status, http.StatusText(recorded.status)),
+		StatusCode:    recorded.status,
+		Proto:         proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(recorded.body)),
+		ContentLength: int64(len(recorded.body)),
+		Request:       req,
+	}, nil
+}
+
+type offlineStatusKey struct{}
+
+// withOfflineStatus sets served when the response to req is replayed in
+// offline mode
+func withOfflineStatus(req *http.Request, served *bool) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), offlineStatusKey{}, served))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadHAR(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recorded.har")
+	har := `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://example.com"},
+		 "response": {"status": 200, "httpVersion": "HTTP/2", "headers": [{"name": ":status", "value": "200"}, {"name": "content-type", "value": "application/json"}],
+		  "content": {"text": "{\"a\": 1}"}}},
+		{"request": {"method": "get", "url": "https://example.com/logo.png#top"},
+		 "response": {"status": 404, "headers": [], "content": {"text": "bm90IGZvdW5k", "encoding": "base64"}}}
+	]}}`
+	if err := os.WriteFile(path, []byte(har), 0644); err != nil {
+		t.Fatal(err)
+	}
+	responses, err := loadHAR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	first := responses[0]
+	if first.url != "https://example.com/" || first.proto != "HTTP/2" || first.header.Get("Content-Type") != "application/json" || len(first.header) != 1 {
+		t.Errorf("unexpected first response %+v", first)
+	}
+	second := responses[1]
+	if second.method != "GET" || second.url != "https://example.com/logo.png" || second.status != 404 || string(second.body) != "not found" {
+		t.Errorf("unexpected second response %+v", second)
+	}
+}
+
+func TestOfflineTransport(t *testing.T) {
+	defer OFFLINE_REPLAY.setEnabled(false)
+	defer OFFLINE_REPLAY.setHistory(nil)
+	OFFLINE_REPLAY.setHAR([]recordedResponse{{method: "GET", url: "http://example.com/a", status: 500, body: []byte("from the HAR")}})
+	OFFLINE_REPLAY.setHistory([]*Request{{
+		Method:          "GET",
+		Url:             "http://example.com/a",
+		Proto:           "HTTP/1.1",
+		StatusCode:      200,
+		ResponseHeader:  http.Header{"Content-Encoding": {"gzip"}, "Etag": {"x"}},
+		RawResponseBody: []byte("from the history"),
+	}})
+	OFFLINE_REPLAY.setEnabled(true)
+
+	transport := &offlineTransport{TRANSPORT}
+	req, _ := http.NewRequest("GET", "http://example.com/a", nil)
+	var served bool
+	response, err := transport.RoundTrip(withOfflineStatus(req, &served))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != 200 || string(body) != "from the history" || !served {
+		t.Errorf("unexpected response %v %q", response.StatusCode, body)
+	}
+	if response.Header.Get("Content-Encoding") != "" || response.Header.Get("Etag") != "x" {
+		t.Errorf("unexpected headers %v", response.Header)
+	}
+
+	req, _ = http.NewRequest("POST", "http://example.com/a", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Errorf("expected an error without recorded response")
+	}
+
+	// the deleted history entries are not served anymore
+	OFFLINE_REPLAY.setHistory(nil)
+	req, _ = http.NewRequest("GET", "http://example.com/a", nil)
+	if response, err := transport.RoundTrip(req); err != nil || response.StatusCode != 500 {
+		t.Errorf("expected the response of the HAR, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// openAPIOperation is the operation of an OpenAPI spec matching a request
+type openAPIOperation struct {
+	method, path string
+	operation    map[string]interface{}
+}
+
+// findOpenAPIOperation returns the operation of the JSON OpenAPI 3 spec
+// matching the method and the URL, the path of the servers is ignored
+func findOpenAPIOperation(spec interface{}, method string, u *url.URL) (openAPIOperation, bool) {
+	root, _ := spec.(map[string]interface{})
+	paths, _ := root["paths"].(map[string]interface{})
+	prefixes := []string{""}
+	servers, _ := root["servers"].([]interface{})
+	for _, server := range servers {
+		server, _ := server.(map[string]interface{})
+		serverURL, _ := server["url"].(string)
+		if su, err := url.Parse(serverURL); err == nil && su.Path != "" && su.Path != "/" {
+			prefixes = append(prefixes, strings.TrimSuffix(su.Path, "/"))
+		}
+	}
+
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	// literal segments take precedence over parameters
+	sort.Slice(templates, func(i, j int) bool {
+		ci, cj := strings.Count(templates[i], "{"), strings.Count(templates[j], "{")
+		if ci != cj {
+			return ci < cj
+		}
+		return templates[i] < templates[j]
+	})
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(u.Path, prefix) {
+			continue
+		}
+		path := strings.TrimPrefix(u.Path, prefix)
+		for _, template := range templates {
+			if !openAPIPathPattern(template).MatchString(path) {
+				continue
+			}
+			item, _ := paths[template].(map[string]interface{})
+			if operation, ok := item[strings.ToLower(method)].(map[string]interface{}); ok {
+				return openAPIOperation{method, template, operation}, true
+			}
+		}
+	}
+	return openAPIOperation{}, false
+}
+
+var OPENAPI_PARAMETER = regexp.MustCompile(`\\\{[^/]*?\\\}`)
+
+func openAPIPathPattern(template string) *regexp.Regexp {
+	return regexp.MustCompile("^" + OPENAPI_PARAMETER.ReplaceAllString(regexp.QuoteMeta(template), "[^/]+") + "/?$")
+}
+
+// openAPIResponse returns the response of the operation documented for the
+// status code, a "2XX" range or the default response
+func openAPIResponse(operation map[string]interface{}, status int) (map[string]interface{}, bool) {
+	responses, _ := operation["responses"].(map[string]interface{})
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if response, ok := responses[key].(map[string]interface{}); ok {
+			return response, true
+		}
+	}
+	return nil, false
+}
+
+// openAPIMediaType returns the content of the response documented for the
+// content type, wildcards included
+func openAPIMediaType(content map[string]interface{}, contentType string) (map[string]interface{}, bool) {
+	ctype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		ctype = contentType
+	}
+	major, _, _ := strings.Cut(ctype, "/")
+	for _, key := range []string{ctype, major + "/*", "*/*"} {
+		if media, ok := content[key].(map[string]interface{}); ok {
+			return media, true
+		}
+	}
+	return nil, false
+}
+
+// validateOpenAPI returns the differences between the response of r and the
+// operation of the spec matching its request
+func validateOpenAPI(spec interface{}, r *Request) ([]schemaViolation, error) {
+	u, err := url.Parse(r.Url)
+	if err != nil {
+		return nil, err
+	}
+	op, found := findOpenAPIOperation(spec, r.Method, u)
+	if !found {
+		return []schemaViolation{{"", fmt.Sprintf("no operation of the spec matches %v %v", r.Method, u.Path)}}, nil
+	}
+	response, found := openAPIResponse(op.operation, r.StatusCode)
+	if !found {
+		return []schemaViolation{{"", fmt.Sprintf("status %d is not documented for %v %v", r.StatusCode, op.method, op.path)}}, nil
+	}
+
+	var violations []schemaViolation
+	headers, _ := response["headers"].(map[string]interface{})
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		header, _ := headers[name].(map[string]interface{})
+		if required, _ := header["required"].(bool); required && r.ResponseHeader.Get(name) == "" {
+			violations = append(violations, schemaViolation{"", "missing required header " + http.CanonicalHeaderKey(name)})
+		}
+	}
+
+	content, _ := response["content"].(map[string]interface{})
+	if len(content) == 0 {
+		return violations, nil
+	}
+	media, found := openAPIMediaType(content, r.ContentType)
+	if !found {
+		return append(violations, schemaViolation{"", fmt.Sprintf("content type %q is not documented", r.ContentType)}), nil
+	}
+	schema, found := media["schema"]
+	if !found {
+		return violations, nil
+	}
+	bodyViolations, err := validateSchemaWithRoot(spec, schema, r.RawResponseBody)
+	if err != nil {
+		return nil, err
+	}
+	return append(violations, bodyViolations...), nil
+}
+
+// checkOpenAPI validates the response of r against spec, the OpenAPI spec
+// of the configuration
+func checkOpenAPI(spec interface{}, r *Request) {
+	if spec == nil {
+		return
+	}
+	r.ContractChecked = true
+	r.ContractViolations, r.ContractError = validateOpenAPI(spec, r)
+}
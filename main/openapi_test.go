@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateOpenAPI(t *testing.T) {
+	spec, err := decodeJSONNumbers([]byte(`{
+		"openapi": "3.0.3",
+		"servers": [{"url": "https://api.example.com/v1"}],
+		"paths": {
+			"/users/{id}": {
+				"get": {"responses": {
+					"200": {
+						"headers": {"X-Rate-Limit": {"required": true}},
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}
+					},
+					"4XX": {"description": "error"}
+				}}
+			},
+			"/users/me": {"get": {"responses": {"200": {"description": "current user"}}}}
+		},
+		"components": {"schemas": {"User": {
+			"type": "object",
+			"required": ["id"],
+			"properties": {"id": {"type": "integer"}, "name": {"type": "string", "nullable": true}}
+		}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(method, url string, status int, header http.Header, body string) string {
+		r := &Request{Method: method, Url: url, StatusCode: status, ResponseHeader: header, ContentType: header.Get("Content-Type"), RawResponseBody: []byte(body)}
+		violations, err := validateOpenAPI(spec, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var messages []string
+		for _, violation := range violations {
+			messages = append(messages, violation.String())
+		}
+		return strings.Join(messages, "\n")
+	}
+	json := http.Header{"Content-Type": {"application/json; charset=utf-8"}, "X-Rate-Limit": {"10"}}
+
+	if report := check("GET", "https://api.example.com/v1/users/42", 200, json, `{"id": 42, "name": null}`); report != "" {
+		t.Errorf("unexpected mismatches %v", report)
+	}
+	if report := check("GET", "https://api.example.com/v1/users/me", 200, http.Header{}, `anything`); report != "" {
+		t.Errorf("the literal path does not take precedence: %v", report)
+	}
+	if report := check("GET", "https://api.example.com/v1/users/42", 404, http.Header{}, ""); report != "" {
+		t.Errorf("the status range is not matched: %v", report)
+	}
+	for _, tc := range []struct {
+		method, url string
+		status      int
+		header      http.Header
+		body        string
+		expected    string
+	}{
+		{"DELETE", "https://api.example.com/v1/users/42", 204, http.Header{}, "", "(root): no operation of the spec matches DELETE /v1/users/42"},
+		{"GET", "https://api.example.com/v1/users/42", 500, http.Header{}, "", "(root): status 500 is not documented for GET /users/{id}"},
+		{"GET", "https://api.example.com/v1/users/42", 200, http.Header{"Content-Type": {"text/html"}}, "<p>", "(root): missing required header X-Rate-Limit\n(root): content type \"text/html\" is not documented"},
+		{"GET", "https://api.example.com/v1/users/42", 200, json, `{"id": "42"}`, "/id: expected integer, got string"},
+	} {
+		if report := check(tc.method, tc.url, tc.status, tc.header, tc.body); report != tc.expected {
+			t.Errorf("%v %v %v: expected %q, got %q", tc.method, tc.url, tc.status, tc.expected, report)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// PINS are the certificate pins of the hosts set by the config
+var PINS = &certificatePins{}
+
+// certificatePins holds the pins of the hosts, read by the connections and
+// replaced by the config reload
+type certificatePins struct {
+	mu   sync.Mutex
+	pins map[string][]string
+}
+
+func (p *certificatePins) set(pins map[string][]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pins = pins
+}
+
+// of returns the pins of host, the host names are case insensitive
+func (p *certificatePins) of(host string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var expected []string
+	for pinned, hostPins := range p.pins {
+		if strings.EqualFold(pinned, host) {
+			expected = hostPins
+		}
+	}
+	return expected
+}
+
+// pinnedAddress returns whether the URL host is an IP address with pins,
+// the TLS server name is empty for IP addresses so the transport verifies
+// them only once given the address
+func pinnedAddress(req *http.Request) bool {
+	host := req.URL.Hostname()
+	return req.URL.Scheme == "https" && net.ParseIP(host) != nil && len(PINS.of(host)) > 0
+}
+
+// spkiHash returns the pin of a certificate public key in the
+// "sha256/BASE64" format used by HPKP and curl's --pinnedpubkey
+func spkiHash(rawSubjectPublicKeyInfo []byte) string {
+	sum := sha256.Sum256(rawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyPins returns a VerifyConnection function failing the TLS handshake
+// when no certificate of the chain matches the pins of host, of the server
+// name if host is empty
+func verifyPins(host string) func(cs tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		name := host
+		if name == "" {
+			name = cs.ServerName
+		}
+		expected := PINS.of(name)
+		if len(expected) == 0 {
+			return nil
+		}
+		var presented []string
+		for _, cert := range cs.PeerCertificates {
+			pin := spkiHash(cert.RawSubjectPublicKeyInfo)
+			for _, e := range expected {
+				if pin == e {
+					return nil
+				}
+			}
+			presented = append(presented, pin)
+		}
+		return fmt.Errorf("certificate pinning failed for %s\nexpected one of:\n  %s\npresented chain:\n  %s",
+			name, strings.Join(expected, "\n  "), strings.Join(presented, "\n  "))
+	}
+}
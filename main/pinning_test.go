@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyPins(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	pin := spkiHash(server.Certificate().RawSubjectPublicKeyInfo)
+	defer PINS.set(nil)
+
+	for _, tc := range []struct {
+		pins    []string
+		success bool
+	}{
+		{[]string{pin}, true},
+		{[]string{"sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", pin}, true},
+		{[]string{"sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}, false},
+	} {
+		PINS.set(map[string][]string{"example.com": tc.pins})
+		client := &http.Client{Transport: &http.Transport{
+			// the certificate of the test server is valid for example.com
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+			},
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+				VerifyConnection:   verifyPins(""),
+			},
+		}}
+		resp, err := client.Get("https://example.com/")
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tc.success {
+			t.Errorf("%v: unexpected result %v", tc.pins, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), pin) {
+			t.Errorf("presented pin missing from the error: %v", err)
+		}
+	}
+}
+
+func TestVerifyPinsAddress(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	pin := spkiHash(server.Certificate().RawSubjectPublicKeyInfo)
+	defer PINS.set(nil)
+
+	// the URL of the test server has the 127.0.0.1 address
+	client := &http.Client{Transport: &optionsTransport{newSharedTransport(&http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection:   verifyPins(""),
+		},
+	})}}
+	for _, tc := range []struct {
+		pins    []string
+		success bool
+	}{
+		{[]string{pin}, true},
+		{[]string{"sha256/AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}, false},
+	} {
+		PINS.set(map[string][]string{"127.0.0.1": tc.pins})
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tc.success {
+			t.Errorf("%v: unexpected result %v", tc.pins, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "127.0.0.1") {
+			t.Errorf("the address is missing from the error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+const PROGRESS_INTERVAL = 100 * time.Millisecond
+
+// request phases displayed in the progress popup
+const (
+	PHASE_PREPARING   = "preparing"
+	PHASE_RESOLVING   = "resolving"
+	PHASE_CONNECTING  = "connecting"
+	PHASE_TLS         = "TLS handshake"
+	PHASE_SENDING     = "sending"
+	PHASE_WAITING     = "waiting"
+	PHASE_DOWNLOADING = "downloading"
+)
+
+var SPINNER_FRAMES = []string{"|", "/", "-", "\\"}
+
+// requestProgress tracks the phase of an in-flight request and renders it
+// with the elapsed time in the popup view until stop is called
+type requestProgress struct {
+	mu    sync.Mutex
+	phase string
+	start time.Time
+	done  bool
+	stopc chan struct{}
+}
+
+func newRequestProgress() *requestProgress {
+	return &requestProgress{
+		phase: PHASE_PREPARING,
+		start: time.Now(),
+		stopc: make(chan struct{}),
+	}
+}
+
+func (p *requestProgress) setPhase(phase string) {
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+}
+
+// trace returns the httptrace hooks updating the phase of the request
+func (p *requestProgress) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.setPhase(PHASE_RESOLVING)
+		},
+		ConnectStart: func(_, _ string) {
+			p.setPhase(PHASE_CONNECTING)
+		},
+		TLSHandshakeStart: func() {
+			p.setPhase(PHASE_TLS)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.setPhase(PHASE_SENDING)
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			p.setPhase(PHASE_SENDING)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.setPhase(PHASE_WAITING)
+		},
+		GotFirstResponseByte: func() {
+			p.setPhase(PHASE_DOWNLOADING)
+		},
+	}
+}
+
+func (p *requestProgress) message(frame int) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return fmt.Sprintf("%s %-13s %6.1fs", SPINNER_FRAMES[frame%len(SPINNER_FRAMES)], p.phase, time.Since(p.start).Seconds())
+}
+
+// run updates the popup view periodically, it returns after stop is called
+func (p *requestProgress) run(g *gocui.Gui) {
+	ticker := time.NewTicker(PROGRESS_INTERVAL)
+	defer ticker.Stop()
+	for frame := 0; ; frame++ {
+		msg := p.message(frame)
+		g.Update(func(g *gocui.Gui) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if !p.done {
+				popup(g, msg)
+			}
+			return nil
+		})
+		select {
+		case <-p.stopc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stop closes the popup view
+func (p *requestProgress) stop(g *gocui.Gui) {
+	p.mu.Lock()
+	p.done = true
+	p.mu.Unlock()
+	close(p.stopc)
+	g.Update(func(g *gocui.Gui) error {
+		g.DeleteView(POPUP_VIEW)
+		return nil
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := &rateLimiter{}
+	now := time.Now()
+	if delay := l.reserve(now); delay != 0 {
+		t.Errorf("unlimited: expected no delay, got %v", delay)
+	}
+
+	l.setRate(4)
+	for i, expected := range []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond} {
+		if delay := l.reserve(now); delay != expected {
+			t.Errorf("request %d: expected a delay of %v, got %v", i, expected, delay)
+		}
+	}
+	// the delay does not accumulate while no request is sent
+	if delay := l.reserve(now.Add(10 * time.Second)); delay != 0 {
+		t.Errorf("expected no delay after a pause, got %v", delay)
+	}
+
+	l.setRate(0)
+	if delay := l.reserve(now); delay != 0 {
+		t.Errorf("limit removed: expected no delay, got %v", delay)
+	}
+}
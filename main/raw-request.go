@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// splitRawRequest returns the lines of the head of the raw request without
+// their line endings and the body after the first empty line as it is
+func splitRawRequest(text string) ([]string, string) {
+	var lines []string
+	for text != "" {
+		line, rest, _ := strings.Cut(text, "\n")
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			return lines, rest
+		}
+		lines = append(lines, line)
+		text = rest
+	}
+	return lines, ""
+}
+
+// normalizeRawRequest ends every line of the head of the raw request with
+// CRLF and the head with an empty line, the body is sent as typed
+func normalizeRawRequest(text string) []byte {
+	lines, body := splitRawRequest(text)
+	return []byte(strings.Join(lines, "\r\n") + "\r\n\r\n" + body)
+}
+
+// parseRawRequest reads the method, target, headers and body of the raw
+// request for the history and the wire log. Only the request line has to
+// be valid, header lines without a colon are ignored. Targets which are not
+// absolute URLs are sent to the Host header with scheme.
+func parseRawRequest(text, scheme string) (*http.Request, error) {
+	lines, body := splitRawRequest(text)
+	var fields []string
+	if len(lines) > 0 {
+		fields = strings.Fields(lines[0])
+	}
+	if len(fields) < 2 {
+		return nil, errors.New("the request line must be METHOD TARGET [PROTOCOL]")
+	}
+	header := make(http.Header)
+	for _, line := range lines[1:] {
+		if name, value, found := strings.Cut(line, ":"); found {
+			header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+
+	method, target := fields[0], fields[1]
+	var u *url.URL
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target: %v", err)
+		}
+		u = parsed
+	} else {
+		host := header.Get("Host")
+		if host == "" {
+			return nil, errors.New("no Host header for the target " + target)
+		}
+		u = &url.URL{Scheme: scheme, Host: host}
+		if strings.HasPrefix(target, "/") {
+			path, query, _ := strings.Cut(target, "?")
+			u.Path, u.RawQuery = path, query
+		}
+	}
+
+	req := &http.Request{
+		Method:        method,
+		URL:           u,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Host:          u.Host,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	return req, nil
+}
+
+// rawTransport writes raw on a new connection to the host of the request
+// instead of the request itself, the connection is closed with the body of
+// the response
+type rawTransport struct {
+	raw []byte
+}
+
+// rawResponseBody closes the connection of a raw request with the response
+// body
+type rawResponseBody struct {
+	io.ReadCloser
+	conn   net.Conn
+	cancel context.CancelFunc
+}
+
+func (b *rawResponseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+	b.cancel()
+	return err
+}
+
+func (t *rawTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	if CLIENT.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), CLIENT.Timeout)
+	}
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace == nil {
+		trace = &httptrace.ClientTrace{}
+	}
+
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(req.URL.Hostname(), port)
+	transport := TRANSPORT.Load()
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	if trace.ConnectStart != nil {
+		trace.ConnectStart("tcp", addr)
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	if trace.ConnectDone != nil {
+		trace.ConnectDone("tcp", addr, err)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if deadline, found := ctx.Deadline(); found {
+		conn.SetDeadline(deadline)
+	}
+
+	var state *tls.ConnectionState
+	if req.URL.Scheme == "https" {
+		config := &tls.Config{}
+		if transport.TLSClientConfig != nil {
+			config = transport.TLSClientConfig.Clone()
+		}
+		config.ServerName = req.URL.Hostname()
+		if config.VerifyConnection != nil {
+			// the server name of the connection state is empty for IP addresses
+			config.VerifyConnection = verifyPins(req.URL.Hostname())
+		}
+		config.NextProtos = []string{"http/1.1"}
+		tlsConn := tls.Client(conn, config)
+		if trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		err := tlsConn.HandshakeContext(ctx)
+		connState := tlsConn.ConnectionState()
+		if trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(connState, err)
+		}
+		if err != nil {
+			conn.Close()
+			cancel()
+			return nil, err
+		}
+		state = &connState
+		conn = tlsConn
+	}
+	if trace.GotConn != nil {
+		trace.GotConn(httptrace.GotConnInfo{Conn: conn})
+	}
+
+	_, err = conn.Write(t.raw)
+	if trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+	if err != nil {
+		conn.Close()
+		cancel()
+		return nil, err
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		cancel()
+		return nil, err
+	}
+	response.TLS = state
+	response.Body = &rawResponseBody{response.Body, conn, cancel}
+	return response, nil
+}
+
+// rawRequestText returns the raw request of the displayed history entry if
+// it was a raw one, the request of the views otherwise
+func (a *App) rawRequestText(g *gocui.Gui) string {
+	if len(a.history) > 0 && a.history[a.historyIndex].RawRequest != "" {
+		return a.history[a.historyIndex].RawRequest
+	}
+	a.rememberURLCredentials(g)
+	r := &Request{}
+	req, err := a.buildRequest(g, r, a.variables, a.apiKey, a.config)
+	if err == nil {
+		// signed but not compressed, the body stays editable
+		err = signRequest(r, req, a.config)
+	}
+	if err != nil {
+		return "GET / HTTP/1.1\nHost: \n\n"
+	}
+	// streamed bodies are left out, the standard input could be read only
+	// once
+	_, streamed := bodyFilePath(r.Data)
+	streamed = streamed && req.ContentLength < 0
+	if streamed {
+		defer req.Body.Close()
+	}
+	dump, err := httputil.DumpRequestOut(req, !streamed)
+	if err != nil {
+		return "GET / HTTP/1.1\nHost: " + req.URL.Host + "\n\n"
+	}
+	return strings.ReplaceAll(string(dump), "\r\n", "\n")
+}
+
+// ToggleRawRequest opens an editor of the whole request, sent as it is by
+// the submit command
+func (a *App) ToggleRawRequest(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == RAW_REQUEST_VIEW {
+		a.closePopup(g, RAW_REQUEST_VIEW)
+		return nil
+	}
+	text := a.rawRequestText(g)
+	maxX, maxY := g.Size()
+	v, err := a.CreatePopupView(RAW_REQUEST_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	v.Title = VIEW_TITLES[RAW_REQUEST_VIEW]
+	v.Highlight = false
+	v.Editable = true
+	v.Editor = &defaultEditor
+	setViewTextAndCursor(v, text)
+	g.SetViewOnTop(RAW_REQUEST_VIEW)
+	g.SetCurrentView(RAW_REQUEST_VIEW)
+	return nil
+}
+
+// submitRawRequest sends the content of the raw request editor
+func (a *App) submitRawRequest(g *gocui.Gui) error {
+	v, err := g.View(RAW_REQUEST_VIEW)
+	if err != nil {
+		return nil
+	}
+	// the buffer ends every line with a newline
+	text := strings.TrimSuffix(v.Buffer(), "\n")
+	req, err := parseRawRequest(text, a.config.General.DefaultURLScheme)
+	if err != nil {
+		return a.OpenMessageView("Raw request error: "+err.Error(), g)
+	}
+	a.closePopup(g, RAW_REQUEST_VIEW)
+	return a.sendRequest(g, func(r *Request, _ *config.Config) (*http.Request, error) {
+		r.RawRequest = text
+		r.Notes = a.notes
+		fillFromRawRequest(r, req)
+		return req, nil
+	})
+}
+
+// fillFromRawRequest sets the fields of the history entry r shown in the
+// request views from the parsed raw request
+func fillFromRawRequest(r *Request, req *http.Request) {
+	r.Method = req.Method
+	u := *req.URL
+	r.GetParams = strings.ReplaceAll(u.RawQuery, "&", "\n")
+	u.RawQuery = ""
+	r.Url = u.String()
+	var headers bytes.Buffer
+	req.Header.Write(&headers)
+	r.Headers = strings.ReplaceAll(headers.String(), "\r\n", "\n")
+	if body, err := io.ReadAll(req.Body); err == nil {
+		r.Data = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeRawRequest(t *testing.T) {
+	for _, test := range []struct {
+		text, expected string
+	}{
+		{"GET / HTTP/1.1\nHost: example.com", "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"},
+		{"GET / HTTP/1.1\r\nHost: example.com\n\n", "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"},
+		{"POST / HTTP/1.1\nHost: a\n\n1\nx", "POST / HTTP/1.1\r\nHost: a\r\n\r\n1\nx"},
+		{"POST / HTTP/1.1\r\nHost: a\r\n\r\n1\r\n\nx", "POST / HTTP/1.1\r\nHost: a\r\n\r\n1\r\n\nx"},
+	} {
+		if raw := string(normalizeRawRequest(test.text)); raw != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.text, test.expected, raw)
+		}
+	}
+}
+
+func TestParseRawRequest(t *testing.T) {
+	req, err := parseRawRequest("PATCH /items/1?fields=a HTTP/1.1\nHost: api.example.com\nX-Custom:  value \nbroken line\n\n{\"a\": 1}", "https")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "PATCH" || req.URL.String() != "https://api.example.com/items/1?fields=a" || req.Header.Get("X-Custom") != "value" || req.ContentLength != 8 {
+		t.Errorf("unexpected request %v %v %v %v", req.Method, req.URL, req.Header, req.ContentLength)
+	}
+
+	req, err = parseRawRequest("GET http://other.example.com/path HTTP/1.0\nHost: ignored\n", "https")
+	if err != nil || req.URL.String() != "http://other.example.com/path" {
+		t.Errorf("unexpected absolute target %v: %v", req, err)
+	}
+
+	req, err = parseRawRequest("POST / HTTP/1.1\r\nHost: a\r\n\r\n1\n2\r\n", "https")
+	if body, _ := io.ReadAll(req.Body); err != nil || string(body) != "1\n2\r\n" {
+		t.Errorf("the body was changed: %q, %v", body, err)
+	}
+
+	for _, text := range []string{"GET\nHost: a", "GET /path HTTP/1.1\n", "\nGET / HTTP/1.1"} {
+		if _, err := parseRawRequest(text, "https"); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestRawTransport(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var head strings.Builder
+		for !strings.HasSuffix(head.String(), "\r\n\r\n") {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			head.WriteString(line)
+		}
+		received <- head.String()
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	}()
+
+	text := "GET /weird%zz HTTP/1.1\nHost: " + listener.Addr().String() + "\nx-lower: 1\nX-Dup: a\nX-Dup: b\n"
+	req, err := parseRawRequest(text, "http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := (&rawTransport{normalizeRawRequest(text)}).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+	if response.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("unexpected response %v %q", response.Status, body)
+	}
+	if head := <-received; head != string(normalizeRawRequest(text)) {
+		t.Errorf("the request was not sent as it is: %q", head)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// STDIN_BODY is the path of "@-" request bodies read from the standard input
+const STDIN_BODY = "-"
+
+// bodyFilePath returns the path of a "@path" request body
+func bodyFilePath(data string) (string, bool) {
+	if !strings.HasPrefix(data, "@") || len(data) < 2 || strings.ContainsAny(data, "\n") {
+		return "", false
+	}
+	return strings.TrimSpace(data[1:]), true
+}
+
+// openBodyFile opens the file of a "@path" request body. The standard input
+// can be read only once and only if it is not the terminal.
+func openBodyFile(path string) (io.ReadCloser, error) {
+	if path != STDIN_BODY {
+		return os.Open(path)
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return nil, errors.New("cannot read request body from the standard input: it is a terminal")
+	}
+	return io.NopCloser(os.Stdin), nil
+}
+
+// readBodyFile opens the file of a "@path" request body and returns its
+// length. The standard input is read in memory to know its length.
+func readBodyFile(path string) (io.Reader, int64, error) {
+	file, err := openBodyFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if f, ok := file.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// isChunked reports whether the request headers ask for chunked transfer
+// encoding
+func isChunked(transferEncoding string) bool {
+	return strings.EqualFold(strings.TrimSpace(transferEncoding), "chunked")
+}
+
+// detectContentType returns the content type of request bodies containing a
+// JSON object or array, or an XML document
+func detectContentType(data string) string {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return ""
+	}
+	if (data[0] == '{' || data[0] == '[') && json.Valid([]byte(data)) {
+		return config.ContentTypes["json"]
+	}
+	if data[0] == '<' {
+		decoder := xml.NewDecoder(strings.NewReader(data))
+		hasElement := false
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return ""
+			}
+			if _, ok := token.(xml.StartElement); ok {
+				hasElement = true
+			}
+		}
+		if hasElement {
+			return config.ContentTypes["xml"]
+		}
+	}
+	return ""
+}
+
+// addHeaderLine appends line to the headers of the content of the headers
+// view, before the trailers
+func addHeaderLine(text, line string) string {
+	headers, trailers := splitTrailers(text)
+	hasTrailers := headers != text
+	headers = strings.TrimRight(headers, "\n")
+	if headers != "" {
+		headers += "\n"
+	}
+	headers += line
+	if hasTrailers {
+		return headers + "\n" + TRAILERS_SEPARATOR + "\n" + trailers
+	}
+	return headers
+}
+
+// gzipRequestBody compresses the body of req and sets its Content-Encoding.
+// Bodies of unknown length are compressed while they are sent.
+func gzipRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	req.Header.Set("Content-Encoding", "gzip")
+	body := req.Body
+	if req.ContentLength <= 0 {
+		pr, pw := io.Pipe()
+		go func() {
+			defer body.Close()
+			zw := gzip.NewWriter(pw)
+			_, err := io.Copy(zw, body)
+			if err == nil {
+				err = zw.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+		req.Body = pr
+		req.GetBody = nil
+		req.ContentLength = -1
+		return nil
+	}
+	defer body.Close()
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := io.Copy(zw, body); err != nil {
+		return fmt.Errorf("cannot compress request body: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("cannot compress request body: %v", err)
+	}
+	data := compressed.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChunkedFileBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.bin")
+	if err := os.WriteFile(path, []byte("line 1\nline 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		io.WriteString(w, strings.Join(req.TransferEncoding, ",")+"|"+string(body))
+	}))
+	defer server.Close()
+
+	r := &Request{
+		Url:     server.URL,
+		Method:  http.MethodPut,
+		Data:    "@" + path,
+		Headers: "Transfer-Encoding: chunked",
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "chunked|line 1\nline 2\n" {
+		t.Errorf("unexpected request received: %q", body)
+	}
+}
+
+func TestGzipRequestBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			t.Errorf("body not compressed: %v", err)
+			return
+		}
+		body, _ := io.ReadAll(reader)
+		io.WriteString(w, req.Header.Get("Content-Encoding")+"|"+string(body))
+	}))
+	defer server.Close()
+
+	for _, headers := range []string{"Content-Type: application/json", "Transfer-Encoding: chunked"} {
+		r := &Request{
+			Url:     server.URL,
+			Method:  http.MethodPost,
+			Data:    `{"id": 1}`,
+			Headers: headers,
+		}
+		req, err := r.newHTTPRequest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := gzipRequestBody(req); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != `gzip|{"id": 1}` {
+			t.Errorf("unexpected request received with %s: %q", headers, body)
+		}
+	}
+}
+
+func TestRawFileBody(t *testing.T) {
+	content := "a=1\r\nb=\x00\xff\n"
+	path := filepath.Join(t.TempDir(), "body.bin")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		if req.ContentLength != int64(len(content)) || len(req.TransferEncoding) > 0 {
+			t.Errorf("unexpected length %d, transfer encoding %v", req.ContentLength, req.TransferEncoding)
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	r := &Request{
+		Url:     server.URL,
+		Method:  http.MethodPost,
+		Data:    "@" + path,
+		Headers: "Content-Type: application/x-www-form-urlencoded",
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != content {
+		t.Errorf("file content modified: %q", body)
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	for data, expected := range map[string]string{
+		`{"name": "buzz"}`:                 "application/json",
+		" [1, 2]\n":                        "application/json",
+		`{"name": `:                        "",
+		"42":                               "",
+		"name=buzz&version=1":              "",
+		`<?xml version="1.0"?><a><b/></a>`: "application/xml",
+		"<a>unclosed":                      "",
+		"":                                 "",
+	} {
+		if contentType := detectContentType(data); contentType != expected {
+			t.Errorf("%q: expected %q, got %q", data, expected, contentType)
+		}
+	}
+}
+
+func TestAddHeaderLine(t *testing.T) {
+	for _, tc := range []struct{ text, expected string }{
+		{"", "Content-Type: application/json"},
+		{"Accept: */*\n", "Accept: */*\nContent-Type: application/json"},
+		{"Accept: */*\n--- trailers ---\nX-Checksum: 1", "Accept: */*\nContent-Type: application/json\n--- trailers ---\nX-Checksum: 1"},
+	} {
+		if text := addHeaderLine(tc.text, "Content-Type: application/json"); text != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.text, tc.expected, text)
+		}
+	}
+}
+
+func TestBodyOnAnyMethod(t *testing.T) {
+	r := &Request{
+		Url:    "http://localhost/_search",
+		Method: http.MethodGet,
+		Data:   `{"query": {"match_all": {}}}`,
+	}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Body != nil || r.Data != "" {
+		t.Error("the body of a GET request was sent without asking")
+	}
+
+	r.Data = `{"query": {"match_all": {}}}`
+	r.SendBody = true
+	req, err = r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != r.Data {
+		t.Errorf("expected the body %q, got %q", r.Data, body)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// reformatRequestData replaces the content of the request data view with its
+// indented (pretty) or compacted JSON form. Invalid JSON is left untouched
+// and the error is shown in a popup.
+func (a *App) reformatRequestData(g *gocui.Gui, pretty bool) error {
+	data := getViewValue(g, REQUEST_DATA_VIEW)
+	if data == "" {
+		return nil
+	}
+	formatted, err := reformatJSON(data, pretty)
+	if err != nil {
+		return a.OpenMessageView(err.Error(), g)
+	}
+	v, err := g.View(REQUEST_DATA_VIEW)
+	if err != nil {
+		return nil
+	}
+	v.Clear()
+	fmt.Fprint(v, formatted)
+	v.SetCursor(0, 0)
+	v.SetOrigin(0, 0)
+	return nil
+}
+
+// reformatJSON validates data as JSON and returns it indented if pretty is
+// set, compacted otherwise. Syntax errors report the line and column of the
+// offending byte.
+func reformatJSON(data string, pretty bool) (string, error) {
+	var buf bytes.Buffer
+	var err error
+	if pretty {
+		err = json.Indent(&buf, []byte(data), "", "  ")
+	} else {
+		err = json.Compact(&buf, []byte(data))
+	}
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := offsetPosition(data, syntaxErr.Offset)
+			return "", fmt.Errorf("invalid JSON at line %d, column %d: %v", line, col, err)
+		}
+		return "", fmt.Errorf("invalid JSON: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// offsetPosition converts the offset reported by encoding/json (the number
+// of bytes read before the error) to a 1-based line and column
+func offsetPosition(s string, offset int64) (line, col int) {
+	if offset > int64(len(s)) {
+		offset = int64(len(s))
+	}
+	prefix := s[:offset]
+	line = strings.Count(prefix, "\n") + 1
+	col = len(prefix) - strings.LastIndex(prefix, "\n") - 1
+	if col < 1 {
+		col = 1
+	}
+	return line, col
+}
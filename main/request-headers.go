@@ -1,5 +1,128 @@
 package main
 
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// TRAILERS_SEPARATOR separates the request headers from the request trailers
+// in the headers view
+const TRAILERS_SEPARATOR = "--- trailers ---"
+
+// splitTrailers splits the content of the headers view into the header and
+// the trailer lines
+func splitTrailers(text string) (headers, trailers string) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == TRAILERS_SEPARATOR {
+			return strings.Join(lines[:i], "\n"), strings.Join(lines[i+1:], "\n")
+		}
+	}
+	return text, ""
+}
+
+// parseRequestHeaders parses "Name: value" lines. Repeated header names are
+// kept as separate values in the order they appear.
+func parseRequestHeaders(text string) (http.Header, error) {
+	headers := http.Header{}
+	headers.Set("User-Agent", "")
+	seen := make(map[string]bool)
+	for _, header := range strings.Split(text, "\n") {
+		if header == "" {
+			continue
+		}
+		header_parts := strings.SplitN(header, ": ", 2)
+		if len(header_parts) != 2 {
+			return nil, fmt.Errorf("Invalid header: %v", header)
+		}
+		name := http.CanonicalHeaderKey(header_parts[0])
+		if seen[name] {
+			headers.Add(name, header_parts[1])
+		} else {
+			headers.Set(name, header_parts[1])
+			seen[name] = true
+		}
+	}
+	return headers, nil
+}
+
+// formatRequestHeaders renders h as "Name: value" lines understood by
+// parseRequestHeaders
+func formatRequestHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range h[name] {
+			lines = append(lines, name+": "+value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addDefaultHeaders adds the configured default headers and the headers of
+// the request host which are not set by the request
+func addDefaultHeaders(conf *config.Config, req *http.Request) {
+	host := req.URL.Hostname()
+	if req.Host != "" && req.Host != req.URL.Host {
+		host = req.Host
+		if h, _, err := net.SplitHostPort(req.Host); err == nil {
+			host = h
+		}
+	}
+	defaults := make(map[string]string)
+	for name, value := range conf.DefaultHeaders {
+		defaults[http.CanonicalHeaderKey(name)] = value
+	}
+	for pattern, headers := range conf.Host {
+		if strings.EqualFold(pattern, host) {
+			for name, value := range headers {
+				defaults[http.CanonicalHeaderKey(name)] = value
+			}
+		}
+	}
+	if conf.General.UserAgent != "" && defaults["User-Agent"] == "" {
+		defaults["User-Agent"] = conf.General.UserAgent
+	}
+	for name, value := range defaults {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+}
+
+// addNetrcCredentials sets the basic auth credentials of the .netrc entry
+// of the request host if the request has no credentials
+func addNetrcCredentials(conf *config.Config, req *http.Request) error {
+	if !conf.General.Netrc || req.Header.Get("Authorization") != "" || req.URL.User != nil {
+		return nil
+	}
+	path := conf.General.NetrcFile
+	if path == "" {
+		path = netrcPath()
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && conf.General.NetrcFile == "" {
+			return nil
+		}
+		return fmt.Errorf("Netrc error: %v", err)
+	}
+	if entry, found := findNetrcEntry(parseNetrc(string(content)), req.URL.Hostname()); found && entry.login != "" {
+		req.SetBasicAuth(entry.login, entry.password)
+	}
+	return nil
+}
+
 var REQUEST_HEADERS = []string{
 	"Accept",
 	"Accept-Charset",
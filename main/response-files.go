@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+var CONTENT_TYPE_EXTENSIONS = map[string]string{
+	"application/json":       ".json",
+	"application/xml":        ".xml",
+	"application/javascript": ".js",
+	"application/pdf":        ".pdf",
+	"application/zip":        ".zip",
+	"application/gzip":       ".gz",
+	"application/x-gzip":     ".gz",
+	"application/x-tar":      ".tar",
+	"application/x-bzip2":    ".bz2",
+	"text/html":              ".html",
+	"text/xml":               ".xml",
+	"text/css":               ".css",
+	"text/csv":               ".csv",
+	"text/javascript":        ".js",
+	"text/plain":             ".txt",
+	"image/png":              ".png",
+	"image/jpeg":             ".jpg",
+	"image/gif":              ".gif",
+	"image/svg+xml":          ".svg",
+	"image/webp":             ".webp",
+}
+
+// extensionForContentType returns a file extension matching the media type
+// of contentType, falling back to .bin
+func extensionForContentType(contentType string) string {
+	ctype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ".bin"
+	}
+	if ext, found := CONTENT_TYPE_EXTENSIONS[ctype]; found {
+		return ext
+	}
+	switch {
+	case strings.HasSuffix(ctype, "+json"):
+		return ".json"
+	case strings.HasSuffix(ctype, "+xml"):
+		return ".xml"
+	case strings.HasPrefix(ctype, "text/"):
+		return ".txt"
+	}
+	if exts, err := mime.ExtensionsByType(ctype); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// autoSaveResponse writes the response body of r to a timestamped file in
+// dir, the configured auto save directory
+func autoSaveResponse(r *Request, dir string) error {
+	if dir == "" {
+		return fmt.Errorf("auto save directory is not configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	host := ""
+	if u, err := url.Parse(r.Url); err == nil {
+		host = u.Host
+	}
+	name := fmt.Sprintf("%s-%s-%s%s",
+		r.Time.Format("20060102-150405.000"),
+		r.Method,
+		unsafeFilenameChars.ReplaceAllString(host, "_"),
+		extensionForContentType(r.ContentType),
+	)
+	return os.WriteFile(filepath.Join(dir, name), r.RawResponseBody, 0o644)
+}
+
+// suggestedFilename returns the file name of the Content-Disposition header
+// of the response, or the last segment of the URL path with an extension
+// inferred from the content type
+func suggestedFilename(r *Request) string {
+	if _, params, err := mime.ParseMediaType(r.ResponseHeader.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(filepath.Clean("/" + params["filename"])); name != "/" && name != "." {
+			return name
+		}
+	}
+	name := "response"
+	if u, err := url.Parse(r.Url); err == nil {
+		if segment := path.Base(u.Path); segment != "/" && segment != "." {
+			name = unsafeFilenameChars.ReplaceAllString(segment, "_")
+		}
+	}
+	if path.Ext(name) == "" {
+		name += extensionForContentType(r.ContentType)
+	}
+	return name
+}
+
+// SaveResponseBody saves the raw response body, e.g. a downloaded archive,
+// to a file named after the response
+func (a *App) SaveResponseBody(g *gocui.Gui, _ *gocui.View) error {
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil {
+		return a.OpenMessageView("Error: no response", g)
+	}
+	req := a.history[a.historyIndex]
+	return a.OpenSaveDialog(VIEW_TITLES[SAVE_RESPONSE_DIALOG_VIEW], suggestedFilename(req), g,
+		func(g *gocui.Gui, _ *gocui.View) error {
+			saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
+			saveResult := fmt.Sprintf("Response body saved to %s (%d bytes).", saveLocation, len(req.RawResponseBody))
+			if err := os.WriteFile(saveLocation, req.RawResponseBody, 0o644); err != nil {
+				saveResult = "Error saving response: " + err.Error()
+			}
+			return a.OpenSaveResultView(saveResult, g)
+		})
+}
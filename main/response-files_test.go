@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSuggestedFilename(t *testing.T) {
+	for _, tc := range []struct {
+		url, contentType, disposition, expected string
+	}{
+		{"http://localhost/export", "text/csv", `attachment; filename="report 2024.csv"`, "report 2024.csv"},
+		{"http://localhost/export", "", `attachment; filename="../../etc/passwd"`, "passwd"},
+		{"http://localhost/export", "", `attachment; filename*=UTF-8''na%C3%AFve.txt`, "naïve.txt"},
+		{"http://localhost/api/users", "application/json; charset=utf-8", "", "users.json"},
+		{"http://localhost/files/logo.png?size=2", "image/png", "", "logo.png"},
+		{"http://localhost/", "text/html", "inline", "response.html"},
+	} {
+		r := &Request{Url: tc.url, ContentType: tc.contentType, ResponseHeader: http.Header{}}
+		if tc.disposition != "" {
+			r.ResponseHeader.Set("Content-Disposition", tc.disposition)
+		}
+		if name := suggestedFilename(r); name != tc.expected {
+			t.Errorf("%s %q: expected %q, got %q", tc.url, tc.disposition, tc.expected, name)
+		}
+	}
+}
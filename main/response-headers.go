@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alessio/shellescape"
+	"github.com/jroimartin/gocui"
+)
+
+// RESPONSE_HEADER_LINE_PATTERN matches the header lines of the response
+// headers view, without the color escapes
+var RESPONSE_HEADER_LINE_PATTERN = regexp.MustCompile(`^([!#$%&'*+.^_|~0-9A-Za-z-]+): (.*)$`)
+
+// previousResponseHeader returns the response header of the last history
+// entry requesting the same URL as r
+func (a *App) previousResponseHeader(r *Request) http.Header {
+	for i := len(a.history) - 1; i >= 0; i-- {
+		h := a.history[i]
+		if h != r && h.ResponseHeader != nil && h.fullURL() == r.fullURL() {
+			return h.ResponseHeader
+		}
+	}
+	return nil
+}
+
+// headerFilterPattern returns the case insensitive pattern of filter, a
+// substring in which "*" matches any characters
+func headerFilterPattern(filter string) *regexp.Regexp {
+	parts := strings.Split(filter, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("(?i)" + strings.Join(parts, ".*"))
+}
+
+// filterResponseHeaders returns the lines of the rendered response headers
+// matching filter, the status line is always kept. Headers whose value is
+// not in previous are highlighted, the headers of previous which are gone
+// are appended.
+func filterResponseHeaders(headers, filter string, previous http.Header) string {
+	var pattern *regexp.Regexp
+	if filter != "" {
+		pattern = headerFilterPattern(filter)
+	}
+	output := &strings.Builder{}
+	seen := make(map[string]bool)
+	trailers := false
+	for _, line := range strings.SplitAfter(headers, "\n") {
+		if line == "" {
+			continue
+		}
+		plain := ANSI_ESCAPE_PATTERN.ReplaceAllString(strings.TrimSuffix(line, "\n"), "")
+		if plain == "Trailers:" {
+			trailers = true
+		}
+		if pattern != nil && !strings.HasPrefix(plain, "HTTP/") && !pattern.MatchString(plain) {
+			continue
+		}
+		match := RESPONSE_HEADER_LINE_PATTERN.FindStringSubmatch(plain)
+		if match == nil || trailers || previous == nil {
+			output.WriteString(line)
+			continue
+		}
+		name := http.CanonicalHeaderKey(match[1])
+		seen[name] = true
+		if slices.Contains(previous.Values(name), match[2]) {
+			output.WriteString(line)
+			continue
+		}
+		fmt.Fprintf(output, "\x1b[1;35m%v:\x1b[0;0m %v\n", match[1], match[2])
+	}
+	if previous == nil {
+		return output.String()
+	}
+	removed := make(http.Header)
+	for name, values := range previous {
+		if seen[name] {
+			continue
+		}
+		for _, value := range values {
+			if pattern == nil || pattern.MatchString(name+": "+value) {
+				removed[name] = append(removed[name], value)
+			}
+		}
+	}
+	if len(removed) > 0 {
+		fmt.Fprint(output, "\n\x1b[0;36mRemoved since the previous response:\x1b[0;0m\n")
+		for _, name := range slices.Sorted(maps.Keys(removed)) {
+			for _, value := range removed[name] {
+				fmt.Fprintf(output, "\x1b[0;31m%v: %v\x1b[0;0m\n", name, value)
+			}
+		}
+	}
+	return output.String()
+}
+
+// responseHeadersText returns the content of the response headers view for
+// r, filtered by the response headers filter
+func (a *App) responseHeadersText(r *Request) string {
+	return filterResponseHeaders(r.ResponseHeaders, a.responseHeadersFilter, r.PreviousHeader)
+}
+
+// setResponseHeadersTitle shows the response headers filter in the title of
+// the response headers view
+func (a *App) setResponseHeadersTitle(g *gocui.Gui) {
+	v, err := g.View(RESPONSE_HEADERS_VIEW)
+	if err != nil {
+		return
+	}
+	v.Title = VIEW_PROPERTIES[RESPONSE_HEADERS_VIEW].title
+	if a.responseHeadersFilter != "" {
+		v.Title = fmt.Sprintf("%v (filter: %v)", v.Title, a.responseHeadersFilter)
+	}
+}
+
+// OpenResponseHeadersFilter opens the dialog of the substring the response
+// headers are filtered by
+func (a *App) OpenResponseHeadersFilter(g *gocui.Gui, _ *gocui.View) error {
+	dialog, err := a.CreatePopupView(RESPONSE_HEADERS_FILTER_VIEW, 60, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[RESPONSE_HEADERS_FILTER_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	setViewTextAndCursor(dialog, a.responseHeadersFilter)
+	g.SetViewOnTop(RESPONSE_HEADERS_FILTER_VIEW)
+	g.SetCurrentView(RESPONSE_HEADERS_FILTER_VIEW)
+	return nil
+}
+
+func (a *App) submitResponseHeadersFilter(g *gocui.Gui, _ *gocui.View) error {
+	a.responseHeadersFilter = strings.TrimSpace(getViewValue(g, RESPONSE_HEADERS_FILTER_VIEW))
+	a.closePopup(g, RESPONSE_HEADERS_FILTER_VIEW)
+	a.setResponseHeadersTitle(g)
+	if len(a.history) == 0 {
+		return nil
+	}
+	v, _ := g.View(RESPONSE_HEADERS_VIEW)
+	v.Clear()
+	v.SetOrigin(0, 0)
+	fmt.Fprint(v, a.responseHeadersText(a.history[a.historyIndex]))
+	return nil
+}
+
+// CopyHeaderValue copies the value of the response header under the cursor
+func (a *App) CopyHeaderValue(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	line, err := v.Line(cy)
+	if err != nil {
+		return a.OpenMessageView("No header under the cursor", g)
+	}
+	match := RESPONSE_HEADER_LINE_PATTERN.FindStringSubmatch(line)
+	if match == nil {
+		return a.OpenMessageView("No header under the cursor", g)
+	}
+	return a.copyAndNotify(g, match[2], "value of "+match[1])
+}
+
+// curlHeaderArgs returns the headers of h matching filter as curl -H
+// arguments
+func curlHeaderArgs(h http.Header, filter string) string {
+	var pattern *regexp.Regexp
+	if filter != "" {
+		pattern = headerFilterPattern(filter)
+	}
+	var args []string
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, value := range h[name] {
+			header := name + ": " + value
+			if pattern == nil || pattern.MatchString(header) {
+				args = append(args, "-H "+shellescape.Quote(header))
+			}
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// CopyHeadersAsCurl copies the displayed response headers as curl -H
+// arguments
+func (a *App) CopyHeadersAsCurl(g *gocui.Gui, _ *gocui.View) error {
+	if len(a.history) == 0 {
+		return a.OpenMessageView("No response", g)
+	}
+	args := curlHeaderArgs(a.history[a.historyIndex].ResponseHeader, a.responseHeadersFilter)
+	if args == "" {
+		return a.OpenMessageView("No response header to copy", g)
+	}
+	return a.copyAndNotify(g, args, "response headers as curl arguments")
+}
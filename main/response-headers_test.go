@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testResponseHeaders = "\x1b[0;32mHTTP/1.1 200 OK\x1b[0;0m\n" +
+	"\x1b[0;33mContent-Type:\x1b[0;0m application/json\n" +
+	"\x1b[0;33mX-Ratelimit-Limit:\x1b[0;0m 100\n" +
+	"\x1b[0;33mX-Ratelimit-Remaining:\x1b[0;0m 41\n"
+
+func TestFilterResponseHeaders(t *testing.T) {
+	filtered := ANSI_ESCAPE_PATTERN.ReplaceAllString(filterResponseHeaders(testResponseHeaders, "x-ratelimit-*", nil), "")
+	expected := "HTTP/1.1 200 OK\nX-Ratelimit-Limit: 100\nX-Ratelimit-Remaining: 41\n"
+	if filtered != expected {
+		t.Errorf("expected %q, got %q", expected, filtered)
+	}
+	if filtered := filterResponseHeaders(testResponseHeaders, "", nil); filtered != testResponseHeaders {
+		t.Errorf("expected the headers unchanged, got %q", filtered)
+	}
+	filtered = ANSI_ESCAPE_PATTERN.ReplaceAllString(filterResponseHeaders(testResponseHeaders, "JSON", nil), "")
+	if filtered != "HTTP/1.1 200 OK\nContent-Type: application/json\n" {
+		t.Errorf("unexpected filtered headers %q", filtered)
+	}
+}
+
+func TestFilterResponseHeadersChanges(t *testing.T) {
+	previous := http.Header{
+		"Content-Type":          {"application/json"},
+		"X-Ratelimit-Limit":     {"100"},
+		"X-Ratelimit-Remaining": {"42"},
+		"X-Request-Id":          {"abc"},
+	}
+	output := filterResponseHeaders(testResponseHeaders, "", previous)
+	if !strings.Contains(output, "\x1b[1;35mX-Ratelimit-Remaining:\x1b[0;0m 41\n") {
+		t.Errorf("changed header not highlighted: %q", output)
+	}
+	if !strings.Contains(output, "\x1b[0;33mX-Ratelimit-Limit:\x1b[0;0m 100\n") {
+		t.Errorf("unchanged header highlighted: %q", output)
+	}
+	if !strings.Contains(output, "\x1b[0;31mX-Request-Id: abc\x1b[0;0m\n") {
+		t.Errorf("removed header not listed: %q", output)
+	}
+	output = filterResponseHeaders(testResponseHeaders, "ratelimit", previous)
+	if strings.Contains(output, "X-Request-Id") {
+		t.Errorf("removed header not filtered: %q", output)
+	}
+}
+
+func TestCurlHeaderArgs(t *testing.T) {
+	h := http.Header{
+		"Content-Type":      {"application/json"},
+		"X-Ratelimit-Limit": {"100"},
+		"Set-Cookie":        {"a=1", "b=2; Path=/"},
+	}
+	expected := "-H 'Content-Type: application/json' -H 'Set-Cookie: a=1' -H 'Set-Cookie: b=2; Path=/' -H 'X-Ratelimit-Limit: 100'"
+	if args := curlHeaderArgs(h, ""); args != expected {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+	if args := curlHeaderArgs(h, "ratelimit"); args != "-H 'X-Ratelimit-Limit: 100'" {
+		t.Errorf("unexpected filtered arguments %q", args)
+	}
+}
+
+func TestFormatResponseHeadersProto(t *testing.T) {
+	response := &http.Response{Proto: "HTTP/2.0", StatusCode: http.StatusOK, Header: http.Header{}}
+	status, _, _ := strings.Cut(ANSI_ESCAPE_PATTERN.ReplaceAllString(formatResponseHeaders(&Request{}, response), ""), "\n")
+	if status != "HTTP/2.0 200 OK" {
+		t.Errorf("unexpected status line %q", status)
+	}
+}
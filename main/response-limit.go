@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/jroimartin/gocui"
+)
+
+// resetBodyLimit displays the first renderLimit KB of the next response
+// body
+func (a *App) resetBodyLimit() {
+	a.bodyLimit = a.config.General.RenderLimit * 1024
+}
+
+// writeLimitedBody writes the formatted response body up to the last line
+// ending before limit, followed by a marker if the body is truncated
+func writeLimitedBody(w io.Writer, formatted []byte, limit int) {
+	if limit <= 0 || len(formatted) <= limit {
+		w.Write(formatted)
+		return
+	}
+	cut := bytes.LastIndexByte(formatted[:limit], '\n') + 1
+	if cut <= 0 {
+		cut = limit
+	}
+	w.Write(formatted[:cut])
+	fmt.Fprintf(w, "\x1b[0;33m--- %s of %s displayed, alt+n loads more ---\x1b[0;0m\n",
+		formatSize(int64(cut)), formatSize(int64(len(formatted))))
+}
+
+// extendBodyLimit raises the limit of a truncated response body so that the
+// line of the formatted body is displayed
+func (a *App) extendBodyLimit(formatted []byte, line int) {
+	if a.bodyLimit <= 0 {
+		return
+	}
+	end := 0
+	for i := 0; i <= line; i++ {
+		next := bytes.IndexByte(formatted[end:], '\n')
+		if next < 0 {
+			end = len(formatted)
+			break
+		}
+		end += next + 1
+	}
+	a.raiseBodyLimit(end)
+}
+
+// raiseBodyLimit raises the limit of a truncated response body by steps of
+// renderLimit KB until it reaches the offset end of the formatted body
+func (a *App) raiseBodyLimit(end int) {
+	for a.bodyLimit > 0 && a.bodyLimit < end {
+		a.bodyLimit += a.config.General.RenderLimit * 1024
+	}
+}
+
+// LoadMoreBody displays renderLimit more KB of a truncated response body
+func (a *App) LoadMoreBody(g *gocui.Gui, v *gocui.View) error {
+	if a.bodyLimit <= 0 {
+		return nil
+	}
+	a.bodyLimit += a.config.General.RenderLimit * 1024
+	vrb, _ := g.View(RESPONSE_BODY_VIEW)
+	ox, oy := vrb.Origin()
+	a.printBody(g, func(v *gocui.View) {
+		v.SetOrigin(ox, oy)
+	})
+	return nil
+}
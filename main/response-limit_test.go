@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestWriteLimitedBody(t *testing.T) {
+	a := &App{config: &config.Config{General: config.GeneralOptions{RenderLimit: 1}}}
+	a.resetBodyLimit()
+	body := []byte(strings.Repeat(strings.Repeat("x", 99)+"\n", 30))
+
+	out := &bytes.Buffer{}
+	writeLimitedBody(out, body, a.bodyLimit)
+	if !strings.HasPrefix(out.String(), string(body[:1000])) || !strings.Contains(out.String(), "1000 B of 2.9 KiB displayed") {
+		t.Errorf("unexpected truncated body %q", out.String())
+	}
+
+	a.extendBodyLimit(body, 25)
+	if a.bodyLimit != 3072 {
+		t.Errorf("expected the limit to cover line 25, got %d", a.bodyLimit)
+	}
+	out.Reset()
+	writeLimitedBody(out, body, a.bodyLimit)
+	if out.String() != string(body) {
+		t.Errorf("expected the whole body")
+	}
+}
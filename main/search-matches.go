@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// SEARCH_MATCHES_LIMIT is the maximum number of lines listed by the jump list
+const SEARCH_MATCHES_LIMIT = 1000
+
+// SEARCH_MATCH_CONTEXT is the number of lines displayed above a match the
+// response body is scrolled to
+const SEARCH_MATCH_CONTEXT = 2
+
+var ANSI_ESCAPE_PATTERN = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+type searchMatch struct {
+	line int
+	text string
+}
+
+// findMatchingLines returns the lines of text matching the regular
+// expression q
+func findMatchingLines(text, q string) ([]searchMatch, error) {
+	return matchLines(strings.Split(text, "\n"), q)
+}
+
+// matchLines returns the lines matching the regular expression q
+func matchLines(lines []string, q string) ([]searchMatch, error) {
+	re, err := regexp.Compile(q)
+	if err != nil {
+		return nil, err
+	}
+	var matches []searchMatch
+	for i, line := range lines {
+		if re.MatchString(line) {
+			matches = append(matches, searchMatch{i, line})
+			if len(matches) == SEARCH_MATCHES_LIMIT {
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// displayFormatter returns the formatter of the response body displayed
+// without search
+func (a *App) displayFormatter(req *Request) formatter.ResponseFormatter {
+	if a.rawResponse {
+		return DEFAULT_FORMATTER
+	}
+	return req.Formatter
+}
+
+// formattedBody returns the response body as displayed without search
+func (a *App) formattedBody() ([]byte, error) {
+	req := a.history[a.historyIndex]
+	responseFormatter := a.displayFormatter(req)
+	if !responseFormatter.Searchable() {
+		return nil, fmt.Errorf("the response body cannot be searched")
+	}
+	if index := req.bodyIndexFor(responseFormatter); index != nil {
+		return index.formatted, nil
+	}
+	buf := &bytes.Buffer{}
+	if err := responseFormatter.Format(buf, req.RawResponseBody); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// matchingLines returns the lines of the displayed response body matching
+// the search q, from the index of large bodies
+func (a *App) matchingLines(q string) ([]searchMatch, error) {
+	req := a.history[a.historyIndex]
+	if index := req.bodyIndexFor(a.displayFormatter(req)); index != nil && index.formatter.Searchable() {
+		return index.matchingLines(q)
+	}
+	formatted, err := a.formattedBody()
+	if err != nil {
+		return nil, err
+	}
+	return findMatchingLines(ANSI_ESCAPE_PATTERN.ReplaceAllString(string(formatted), ""), q)
+}
+
+// ToggleSearchMatches opens a panel next to the response body listing the
+// lines matching the search, the selected line is displayed in the
+// response body
+func (a *App) ToggleSearchMatches(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == SEARCH_MATCHES_VIEW {
+		a.closePopup(g, SEARCH_MATCHES_VIEW)
+		return nil
+	}
+	q := getViewValue(g, SEARCH_VIEW)
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil || q == "" {
+		return nil
+	}
+	matches, err := a.matchingLines(q)
+	if err != nil {
+		return a.OpenMessageView("Search error: "+err.Error(), g)
+	}
+	if len(matches) == 0 {
+		return a.OpenMessageView("No matching line", g)
+	}
+	a.searchMatches = matches
+
+	v, err := a.CreatePopupView(SEARCH_MATCHES_VIEW, 40, len(matches), g)
+	if err != nil {
+		return err
+	}
+	// place the panel on the right side of the response body
+	a.popupPosition = func(maxX, maxY int) (int, int, int, int) {
+		pos := VIEW_POSITIONS[RESPONSE_BODY_VIEW]
+		x0 := maxInt(pos.x0.getCoordinate(maxX+1), pos.x1.getCoordinate(maxX+1)*3/5)
+		return x0, pos.y0.getCoordinate(maxY + 1), pos.x1.getCoordinate(maxX + 1), pos.y1.getCoordinate(maxY + 1)
+	}
+	maxX, maxY := g.Size()
+	x0, y0, x1, y1 := a.popupPosition(maxX, maxY)
+	if _, err := g.SetView(SEARCH_MATCHES_VIEW, x0, y0, x1, y1); err != nil {
+		return err
+	}
+	v.Title = fmt.Sprintf("%d matching lines (enter: show, ctrl+q: close)", len(matches))
+	width := len(fmt.Sprint(matches[len(matches)-1].line + 1))
+	for _, m := range matches {
+		fmt.Fprintf(v, "%*d: %s\n", width, m.line+1, strings.TrimSpace(m.text))
+	}
+	g.SetViewOnTop(SEARCH_MATCHES_VIEW)
+	g.SetCurrentView(SEARCH_MATCHES_VIEW)
+	selectListLine(v, 0)
+	return nil
+}
+
+// showSearchMatch displays the response body scrolled to the selected match
+func (a *App) showSearchMatch(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.searchMatches) {
+		return nil
+	}
+	match := a.searchMatches[cy+oy]
+	a.closePopup(g, SEARCH_MATCHES_VIEW)
+	a.searchJumped = true
+	req := a.history[a.historyIndex]
+	if index := req.bodyIndexFor(a.displayFormatter(req)); index != nil {
+		a.raiseBodyLimit(index.lineEnd(match.line))
+	} else if formatted, err := a.formattedBody(); err == nil {
+		a.extendBodyLimit(formatted, match.line)
+	}
+	a.printBody(g, func(vrb *gocui.View) {
+		row := viewRow(vrb, match.line)
+		oy := maxInt(row-SEARCH_MATCH_CONTEXT, 0)
+		vrb.SetOrigin(0, oy)
+		vrb.SetCursor(0, row-oy)
+		vrb.Title = fmt.Sprintf("%s - line %d", vrb.Title, match.line+1)
+	})
+	return a.setViewByName(g, RESPONSE_BODY_VIEW)
+}
+
+// writeCapturedValues writes the values captured by q with their count and
+// returns the title of the response body view
+func writeCapturedValues(ctx context.Context, w io.Writer, q string, body []byte) string {
+	values, matches, err := captureValues(ctx, q, body)
+	if err != nil {
+		fmt.Fprint(w, "Search error: ", err)
+		return "Search error"
+	}
+	if len(values) == 0 {
+		fmt.Fprint(w, "Error: no results")
+		return "No results"
+	}
+	width := len(fmt.Sprint(values[0].count))
+	for _, v := range values {
+		fmt.Fprintf(w, "%*d  %s\n", width, v.count, v.value)
+	}
+	return fmt.Sprintf("%d distinct values in %d matches", len(values), matches)
+}
+
+// SEARCH_CAPTURE_LIMIT is the maximum number of matches counted by the
+// capture search
+const SEARCH_CAPTURE_LIMIT = 100000
+
+type capturedValue struct {
+	value string
+	count int
+}
+
+// captureValues returns the distinct values captured by the groups of the
+// regular expression q with their number of occurrences, the most frequent
+// first. Values of several groups are joined with " | ", the whole match is
+// used if q has no group.
+func captureValues(ctx context.Context, q string, body []byte) ([]capturedValue, int, error) {
+	re, err := regexp.Compile(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	var values []capturedValue
+	index := make(map[string]int)
+	matches := re.FindAllSubmatch(body, SEARCH_CAPTURE_LIMIT)
+	for n, match := range matches {
+		if n%1000 == 0 && ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		groups := match[1:]
+		if len(groups) == 0 {
+			groups = match
+		}
+		parts := make([]string, len(groups))
+		for i, group := range groups {
+			parts[i] = string(group)
+		}
+		value := strings.Join(parts, " | ")
+		if i, found := index[value]; found {
+			values[i].count++
+			continue
+		}
+		index[value] = len(values)
+		values = append(values, capturedValue{value, 1})
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].count > values[j].count
+	})
+	return values, len(matches), nil
+}
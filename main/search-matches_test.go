@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestFindMatchingLines(t *testing.T) {
+	matches, err := findMatchingLines("alpha\nbeta\ngamma\nalphabet", "^alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []searchMatch{{0, "alpha"}, {3, "alphabet"}}
+	if !reflect.DeepEqual(matches, expected) {
+		t.Errorf("expected %v, got %v", expected, matches)
+	}
+	if _, err := findMatchingLines("text", "("); err == nil {
+		t.Error("invalid regular expression accepted")
+	}
+}
+
+func TestCaptureValues(t *testing.T) {
+	body := []byte(`[{"id": "a", "v": 1}, {"id": "b", "v": 2}, {"id": "b", "v": 3}]`)
+	values, matches, err := captureValues(context.Background(), `"id": "(.*?)"`, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []capturedValue{{"b", 2}, {"a", 1}}
+	if !reflect.DeepEqual(values, expected) || matches != 3 {
+		t.Errorf("expected %v, got %v in %d matches", expected, values, matches)
+	}
+
+	values, _, _ = captureValues(context.Background(), `"id": "(\w)", "v": (\d)`, body)
+	if len(values) != 3 || values[0].value != "a | 1" {
+		t.Errorf("unexpected values of several groups %v", values)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := captureValues(ctx, `"id"`, body); err != context.Canceled {
+		t.Errorf("expected the cancelled capture to stop, got %v", err)
+	}
+}
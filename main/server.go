@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+const (
+	DEFAULT_SERVER_ADDR = "localhost:8080"
+	// number of received requests kept for the server log
+	SERVER_LOG_LIMIT = 200
+	// bodies of received requests are displayed up to this size
+	SERVER_LOG_BODY_LIMIT = 4096
+	// number of output lines of the tunnel command kept for the server log
+	TUNNEL_OUTPUT_LIMIT = 10
+)
+
+// SERVER_COMMANDS are the subcommands starting a local server next to the
+// TUI, e.g. "buzz mock :8080"
+var SERVER_COMMANDS = map[string]func(a *App, g *gocui.Gui) http.Handler{
+	"mock": func(a *App, _ *gocui.Gui) http.Handler {
+		return &mockServer{a}
+	},
+	"listen": func(a *App, _ *gocui.Gui) http.Handler {
+		return &listenServer{a}
+	},
+	"echo": func(_ *App, _ *gocui.Gui) http.Handler {
+		return http.HandlerFunc(serveEcho)
+	},
+	"capture": func(a *App, g *gocui.Gui) http.Handler {
+		return &captureProxy{a, g}
+	},
+}
+
+// incomingRequest is a request received by a local server
+type incomingRequest struct {
+	Time       time.Time
+	Server     string
+	RemoteAddr string
+	Method     string
+	RequestURI string
+	Proto      string
+	Header     http.Header
+	Body       []byte
+	StatusCode int
+}
+
+// localServer is a server started by one of the SERVER_COMMANDS
+type localServer struct {
+	name string
+	addr string
+}
+
+// parseServerCommand removes the server subcommand and its optional listen
+// address from args
+func parseServerCommand(args []string) (name, addr string, rest []string) {
+	if len(args) < 2 {
+		return "", "", args
+	}
+	if _, found := SERVER_COMMANDS[args[1]]; !found {
+		return "", "", args
+	}
+	name = args[1]
+	addr = DEFAULT_SERVER_ADDR
+	rest = append([]string{args[0]}, args[2:]...)
+	if len(rest) > 1 && isListenAddress(rest[1]) {
+		addr = rest[1]
+		if !strings.Contains(addr, ":") {
+			addr = ":" + addr
+		}
+		rest = append(rest[:1], rest[2:]...)
+	}
+	return name, addr, rest
+}
+
+// isListenAddress reports whether arg looks like "[HOST]:PORT" or "PORT"
+// rather than a URL or an option
+func isListenAddress(arg string) bool {
+	if arg == "" || strings.HasPrefix(arg, "-") || strings.ContainsAny(arg, "/") {
+		return false
+	}
+	_, port, err := net.SplitHostPort(arg)
+	if err != nil {
+		port = arg
+	}
+	for _, c := range port {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return port != ""
+}
+
+// startServer listens on addr and serves h in the background, every received
+// request is added to the server log
+func (a *App) startServer(g *gocui.Gui, name, addr string, h http.Handler) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	a.servers = append(a.servers, localServer{name, l.Addr().String()})
+	go func() {
+		err := http.Serve(l, a.logIncoming(g, name, h))
+		g.Update(func(g *gocui.Gui) error {
+			return a.OpenMessageView(fmt.Sprintf("%s server stopped: %v", name, err), g)
+		})
+	}()
+	return nil
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.statusCode == 0 {
+		s.statusCode = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.statusCode == 0 {
+		s.statusCode = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the capture proxy tunnel connections
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, buf, err := hijacker.Hijack()
+	if err == nil && s.statusCode == 0 {
+		s.statusCode = http.StatusOK
+	}
+	return conn, buf, err
+}
+
+// logIncoming wraps h to record the requests it serves
+func (a *App) logIncoming(g *gocui.Gui, name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		in := &incomingRequest{
+			Time:       time.Now(),
+			Server:     name,
+			RemoteAddr: req.RemoteAddr,
+			Method:     req.Method,
+			RequestURI: req.RequestURI,
+			Proto:      req.Proto,
+			Header:     req.Header.Clone(),
+			Body:       body,
+		}
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, req)
+		in.StatusCode = rec.statusCode
+		g.Update(func(g *gocui.Gui) error {
+			a.addIncoming(g, in)
+			return nil
+		})
+	})
+}
+
+func (a *App) addIncoming(g *gocui.Gui, in *incomingRequest) {
+	a.incoming = append(a.incoming, in)
+	if len(a.incoming) > SERVER_LOG_LIMIT {
+		a.incoming = a.incoming[len(a.incoming)-SERVER_LOG_LIMIT:]
+	}
+	if v, err := g.View(SERVER_LOG_VIEW); err == nil {
+		a.renderServerLog(v)
+	}
+}
+
+// ToggleServerLog shows the requests received by the local servers
+func (a *App) ToggleServerLog(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == SERVER_LOG_VIEW {
+		a.closePopup(g, SERVER_LOG_VIEW)
+		return nil
+	}
+	maxX, maxY := g.Size()
+	v, err := a.CreatePopupView(SERVER_LOG_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[SERVER_LOG_VIEW]
+	v.Highlight = false
+	v.Autoscroll = true
+	a.renderServerLog(v)
+	g.SetViewOnTop(SERVER_LOG_VIEW)
+	g.SetCurrentView(SERVER_LOG_VIEW)
+	return nil
+}
+
+func (a *App) renderServerLog(v *gocui.View) {
+	v.Clear()
+	if len(a.servers) == 0 {
+		fmt.Fprintln(v, "[!] No server is running, start one with: buzz mock|listen|echo|capture [ADDR]")
+		return
+	}
+	for _, s := range a.servers {
+		fmt.Fprintf(v, "\x1b[0;36m%s server listening on %s\x1b[0;0m\n", s.name, s.addr)
+		if s.name == "capture" {
+			fmt.Fprintln(v, captureHint(s.addr))
+		}
+	}
+	if a.tunnel != nil {
+		fmt.Fprintf(v, "\x1b[0;36mtunnel: %s\x1b[0;0m\n", strings.Join(a.tunnel.Args, " "))
+		for _, line := range a.tunnelOutput {
+			fmt.Fprintln(v, line)
+		}
+	}
+	for _, in := range a.incoming {
+		fmt.Fprintln(v)
+		writeIncomingRequest(v, in)
+	}
+}
+
+func writeIncomingRequest(w io.Writer, in *incomingRequest) {
+	fmt.Fprintf(
+		w,
+		"%s [%s] %s \x1b[0;1m%s %s %s\x1b[0;0m -> \x1b[0;%dm%d\x1b[0;0m\n",
+		in.Time.Format("15:04:05"),
+		in.Server,
+		in.RemoteAddr,
+		in.Method,
+		in.RequestURI,
+		in.Proto,
+		statusColor(in.StatusCode),
+		in.StatusCode,
+	)
+	writeSortedHeaders(w, in.Header)
+	if len(in.Body) == 0 {
+		return
+	}
+	body := in.Body
+	truncated := len(body) > SERVER_LOG_BODY_LIMIT
+	if truncated {
+		body = body[:SERVER_LOG_BODY_LIMIT]
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.ReplaceAll(string(body), "\r\n", "\n"))
+	if truncated {
+		fmt.Fprintf(w, "[%s truncated]\n", formatSize(int64(len(in.Body)-SERVER_LOG_BODY_LIMIT)))
+	}
+}
+
+// mockServer serves the canned responses of the configuration
+type mockServer struct {
+	app *App
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	resp := findMockResponse(m.app.config.Mock, req)
+	if resp == nil {
+		http.Error(w, "no mock response configured for "+req.Method+" "+req.URL.Path, http.StatusNotFound)
+		return
+	}
+	if resp.Delay.Duration > 0 {
+		select {
+		case <-time.After(resp.Delay.Duration):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	for name, value := range resp.Headers {
+		w.Header().Set(name, value)
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body)
+}
+
+// findMockResponse returns the first response matching req
+func findMockResponse(responses []config.MockResponse, req *http.Request) *config.MockResponse {
+	for i, resp := range responses {
+		if resp.Method != "" && !strings.EqualFold(resp.Method, req.Method) {
+			continue
+		}
+		if prefix, found := strings.CutSuffix(resp.Path, "*"); found {
+			if strings.HasPrefix(req.URL.Path, prefix) {
+				return &responses[i]
+			}
+		} else if resp.Path == "" || resp.Path == req.URL.Path {
+			return &responses[i]
+		}
+	}
+	return nil
+}
+
+// listenServer accepts every request with the configured response, it is
+// meant to receive webhooks and OAuth callbacks
+type listenServer struct {
+	app *App
+}
+
+func (l *listenServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	opts := l.app.config.Listen
+	status := opts.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, opts.Body)
+}
+
+// echoResponse is the description of a request sent back by the echo server
+type echoResponse struct {
+	Method       string      `json:"method"`
+	URL          string      `json:"url"`
+	Proto        string      `json:"proto"`
+	RemoteAddr   string      `json:"remoteAddr"`
+	Headers      http.Header `json:"headers"`
+	Body         string      `json:"body"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// serveEcho answers with the method, headers and body of the request
+func serveEcho(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	resp := echoResponse{
+		Method:     req.Method,
+		URL:        req.RequestURI,
+		Proto:      req.Proto,
+		RemoteAddr: req.RemoteAddr,
+		Headers:    req.Header,
+	}
+	if req.Host != "" {
+		resp.Headers = req.Header.Clone()
+		resp.Headers.Set("Host", req.Host)
+	}
+	if utf8.Valid(body) {
+		resp.Body = string(body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.BodyEncoding = "base64"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(resp)
+}
+
+// startTunnel runs the tunnel command exposing the local server listening on
+// addr, its output is shown in the server log
+func (a *App) startTunnel(g *gocui.Gui, command, addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	args := strings.Fields(strings.ReplaceAll(command, "{port}", port))
+	if len(args) == 0 {
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("cannot start tunnel command: %v", err)
+	}
+	a.tunnel = cmd
+	go func() {
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			line := scanner.Text()
+			g.Update(func(g *gocui.Gui) error {
+				a.addTunnelOutput(g, line)
+				return nil
+			})
+		}
+		err := cmd.Wait()
+		g.Update(func(g *gocui.Gui) error {
+			a.addTunnelOutput(g, fmt.Sprintf("[tunnel command exited: %v]", err))
+			return nil
+		})
+	}()
+	return nil
+}
+
+func (a *App) addTunnelOutput(g *gocui.Gui, line string) {
+	a.tunnelOutput = append(a.tunnelOutput, line)
+	if len(a.tunnelOutput) > TUNNEL_OUTPUT_LIMIT {
+		a.tunnelOutput = a.tunnelOutput[len(a.tunnelOutput)-TUNNEL_OUTPUT_LIMIT:]
+	}
+	if v, err := g.View(SERVER_LOG_VIEW); err == nil {
+		a.renderServerLog(v)
+	}
+}
+
+// stopTunnel kills the tunnel command if it is still running
+func (a *App) stopTunnel() {
+	if a.tunnel != nil && a.tunnel.Process != nil {
+		a.tunnel.Process.Kill()
+	}
+}
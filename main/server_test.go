@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestParseServerCommand(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		name string
+		addr string
+		rest string
+	}{
+		{[]string{"buzz", "http://example.com"}, "", "", "buzz http://example.com"},
+		{[]string{"buzz", "mock"}, "mock", DEFAULT_SERVER_ADDR, "buzz"},
+		{[]string{"buzz", "mock", ":9000", "-k"}, "mock", ":9000", "buzz -k"},
+		{[]string{"buzz", "mock", "9000"}, "mock", ":9000", "buzz"},
+		{[]string{"buzz", "mock", "localhost:8080/users"}, "mock", DEFAULT_SERVER_ADDR, "buzz localhost:8080/users"},
+	} {
+		name, addr, rest := parseServerCommand(tc.args)
+		if name != tc.name || addr != tc.addr || strings.Join(rest, " ") != tc.rest {
+			t.Errorf("%v: got %q %q %q", tc.args, name, addr, rest)
+		}
+	}
+}
+
+func TestFindMockResponse(t *testing.T) {
+	responses := []config.MockResponse{
+		{Method: "POST", Path: "/users", Body: "created"},
+		{Path: "/users/*", Body: "user"},
+		{Path: "/health", Body: "ok"},
+	}
+	for target, expected := range map[string]string{
+		"POST /users":   "created",
+		"GET /users":    "",
+		"GET /users/1":  "user",
+		"PUT /users/2":  "user",
+		"GET /health":   "ok",
+		"GET /healthz":  "",
+		"post /users/1": "user",
+	} {
+		method, path, _ := strings.Cut(target, " ")
+		resp := findMockResponse(responses, httptest.NewRequest(strings.ToUpper(method), path, nil))
+		body := ""
+		if resp != nil {
+			body = resp.Body
+		}
+		if body != expected {
+			t.Errorf("%s: expected %q, got %q", target, expected, body)
+		}
+	}
+}
+
+func TestServeEcho(t *testing.T) {
+	req := httptest.NewRequest("PUT", "/items/1?full=true", strings.NewReader(`{"a":1}`))
+	req.Header.Set("X-Test", "yes")
+	w := httptest.NewRecorder()
+	serveEcho(w, req)
+
+	var resp echoResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Method != "PUT" || resp.URL != "/items/1?full=true" || resp.Body != `{"a":1}` || resp.BodyEncoding != "" {
+		t.Errorf("unexpected echo: %+v", resp)
+	}
+	if resp.Headers.Get("X-Test") != "yes" || resp.Headers.Get("Host") != "example.com" {
+		t.Errorf("unexpected echoed headers: %v", resp.Headers)
+	}
+
+	w = httptest.NewRecorder()
+	serveEcho(w, httptest.NewRequest("POST", "/", strings.NewReader("\xff\xfe")))
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.BodyEncoding != "base64" || resp.Body != "//4=" {
+		t.Errorf("expected base64 encoded body, got %q %q", resp.BodyEncoding, resp.Body)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// SESSION_FILENAME is the suggested name of the saved sessions
+const SESSION_FILENAME = "buzz-session.json"
+
+// SESSION_VIEWS are the request views saved with the session
+var SESSION_VIEWS = []string{URL_VIEW, REQUEST_METHOD_VIEW, URL_PARAMS_VIEW, REQUEST_DATA_VIEW, REQUEST_HEADERS_VIEW, AUTH_VIEW}
+
+// session is the state saved to pause an investigation: the content of the
+// request views, the history and the workspace environment
+type session struct {
+	Saved        time.Time
+	Views        map[string]string
+	History      []sessionRequest
+	HistoryIndex int
+	Workspace    string `json:",omitempty"`
+	Environment  string `json:",omitempty"`
+	// variables of the environment and extracted from the responses
+	Variables map[string]string `json:",omitempty"`
+}
+
+// sessionRequest is a history entry of a session, the fields of Request
+// which cannot be decoded are shadowed, the formatter is recreated when the
+// session is restored
+type sessionRequest struct {
+	*Request
+	SchemaViolations   []sessionViolation `json:",omitempty"`
+	SchemaError        string             `json:",omitempty"`
+	ContractViolations []sessionViolation `json:",omitempty"`
+	ContractError      string             `json:",omitempty"`
+	Formatter          string             `json:",omitempty"`
+}
+
+type sessionViolation struct {
+	Pointer string
+	Message string
+}
+
+func encodeViolations(violations []schemaViolation) []sessionViolation {
+	var encoded []sessionViolation
+	for _, v := range violations {
+		encoded = append(encoded, sessionViolation{v.pointer, v.message})
+	}
+	return encoded
+}
+
+func decodeViolations(encoded []sessionViolation) []schemaViolation {
+	var violations []schemaViolation
+	for _, v := range encoded {
+		violations = append(violations, schemaViolation{v.Pointer, v.Message})
+	}
+	return violations
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func textError(text string) error {
+	if text == "" {
+		return nil
+	}
+	return errors.New(text)
+}
+
+// newSessionRequest returns the history entry r of a session
+func newSessionRequest(r *Request) sessionRequest {
+	return sessionRequest{
+		Request:            r,
+		SchemaViolations:   encodeViolations(r.SchemaViolations),
+		SchemaError:        errorText(r.SchemaError),
+		ContractViolations: encodeViolations(r.ContractViolations),
+		ContractError:      errorText(r.ContractError),
+	}
+}
+
+// request returns the restored history entry
+func (s sessionRequest) request(f func(r *Request) formatter.ResponseFormatter) *Request {
+	r := s.Request
+	if r == nil {
+		r = &Request{}
+	}
+	r.SchemaViolations = decodeViolations(s.SchemaViolations)
+	r.SchemaError = textError(s.SchemaError)
+	r.ContractViolations = decodeViolations(s.ContractViolations)
+	r.ContractError = textError(s.ContractError)
+	if r.RawResponseBody != nil {
+		r.Formatter = f(r)
+	}
+	return r
+}
+
+// currentSession returns the session of the content of the request views,
+// the history and the environment
+func (a *App) currentSession(g *gocui.Gui, saved time.Time) *session {
+	s := &session{
+		Saved:        saved,
+		Views:        make(map[string]string),
+		HistoryIndex: a.historyIndex,
+		Workspace:    a.workspacePath,
+		Environment:  a.environment,
+		Variables:    a.variables,
+	}
+	for _, name := range SESSION_VIEWS {
+		s.Views[name] = getViewValue(g, name)
+	}
+	if a.schema != "" {
+		s.Views[SCHEMA_VIEW] = a.schema
+	}
+	if a.notes != "" {
+		s.Views[NOTES_VIEW] = a.notes
+	}
+	if options := a.transport.String(); options != "" {
+		s.Views[TRANSPORT_OPTIONS_VIEW] = options
+	}
+	for _, r := range a.history {
+		s.History = append(s.History, newSessionRequest(r))
+	}
+	return s
+}
+
+// SaveSession saves the content of the request views, the history and the
+// environment to a file
+func (a *App) SaveSession(g *gocui.Gui, _ *gocui.View) error {
+	return a.OpenSaveDialog(VIEW_TITLES[SAVE_SESSION_DIALOG_VIEW], SESSION_FILENAME, g,
+		func(g *gocui.Gui, _ *gocui.View) error {
+			defer a.closePopup(g, SAVE_DIALOG_VIEW)
+			saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
+			saveResult := fmt.Sprintf("Session saved to %v", saveLocation)
+			data, err := json.MarshalIndent(a.currentSession(g, time.Now()), "", "  ")
+			if err == nil {
+				err = os.WriteFile(saveLocation, data, 0o600)
+			}
+			if err != nil {
+				saveResult = "Error saving the session: " + err.Error()
+			}
+			return a.OpenSaveResultView(saveResult, g)
+		})
+}
+
+// OpenLoadSessionDialog asks for the session file to restore
+func (a *App) OpenLoadSessionDialog(g *gocui.Gui, _ *gocui.View) error {
+	return a.OpenSaveDialog(VIEW_TITLES[LOAD_SESSION_DIALOG_VIEW], SESSION_FILENAME, g,
+		func(g *gocui.Gui, _ *gocui.View) error {
+			a.closePopup(g, SAVE_DIALOG_VIEW)
+			if err := a.LoadSession(g, getViewValue(g, SAVE_DIALOG_VIEW)); err != nil {
+				return a.OpenMessageView("Session not restored: "+err.Error(), g)
+			}
+			return nil
+		})
+}
+
+// LoadSession restores the session saved to path, replacing the history
+func (a *App) LoadSession(g *gocui.Gui, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if s.Workspace != "" && s.Workspace != a.workspacePath {
+		if err := a.openWorkspace(s.Workspace, s.Environment); err != nil {
+			return err
+		}
+	} else if a.workspace != nil && s.Environment != a.environment {
+		if err := a.setEnvironment(s.Environment); err != nil {
+			return err
+		}
+	}
+	if s.Variables != nil {
+		a.variables = s.Variables
+	}
+
+	a.history = nil
+	for _, entry := range s.History {
+		r := entry.request(func(r *Request) formatter.ResponseFormatter {
+			return formatter.NewForBody(a.config, r.ContentType, r.RawResponseBody)
+		})
+		a.history = append(a.history, r)
+	}
+	OFFLINE_REPLAY.setHistory(a.history)
+	if len(a.history) > 0 {
+		a.restoreRequest(g, minInt(maxInt(s.HistoryIndex, 0), len(a.history)-1))
+	}
+	a.setRequestViews(g, s.Views)
+	refreshStatusLine(a, g)
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/formatter"
+)
+
+func TestSessionRequest(t *testing.T) {
+	r := &Request{
+		Url:              "http://example.com",
+		Method:           "GET",
+		Notes:            "expected 404",
+		ResponseHeader:   http.Header{"Content-Type": {"application/json"}},
+		RawResponseBody:  []byte(`{"a": 1}`),
+		ContentType:      "application/json",
+		StatusCode:       404,
+		Duration:         time.Second,
+		SchemaViolations: []schemaViolation{{"/a", "expected string"}},
+		ContractError:    errors.New("no matching path"),
+		Formatter:        &formatter.TextFormatter{},
+	}
+	data, err := json.Marshal(session{History: []sessionRequest{newSessionRequest(r)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.History) != 1 {
+		t.Fatalf("expected one history entry, got %d", len(s.History))
+	}
+	restored := s.History[0].request(func(r *Request) formatter.ResponseFormatter {
+		return DEFAULT_FORMATTER
+	})
+	if restored.Url != r.Url || restored.Notes != r.Notes || restored.StatusCode != 404 || restored.Duration != time.Second ||
+		string(restored.RawResponseBody) != string(r.RawResponseBody) || restored.ResponseHeader.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected restored request %+v", restored)
+	}
+	if len(restored.SchemaViolations) != 1 || restored.SchemaViolations[0] != r.SchemaViolations[0] {
+		t.Errorf("unexpected schema violations %v", restored.SchemaViolations)
+	}
+	if restored.ContractError == nil || restored.ContractError.Error() != "no matching path" || restored.SchemaError != nil {
+		t.Errorf("unexpected errors %v %v", restored.SchemaError, restored.ContractError)
+	}
+	if restored.Formatter != DEFAULT_FORMATTER {
+		t.Errorf("expected the formatter to be recreated")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// sharedTransport holds the transport of the requests. Once requests are
+// sent it is not changed but replaced by a changed copy, the requests in
+// progress keep the previous one.
+type sharedTransport struct {
+	atomic.Pointer[http.Transport]
+}
+
+func newSharedTransport(t *http.Transport) *sharedTransport {
+	s := &sharedTransport{}
+	s.Store(t)
+	return s
+}
+
+func (s *sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return s.Load().RoundTrip(req)
+}
+
+func (s *sharedTransport) CloseIdleConnections() {
+	s.Load().CloseIdleConnections()
+}
+
+// update replaces the transport with a copy changed by change, the idle
+// connections of the previous one are closed
+func (s *sharedTransport) update(change func(t *http.Transport)) {
+	previous := s.Load()
+	t := previous.Clone()
+	change(t)
+	s.Store(t)
+	previous.CloseIdleConnections()
+}
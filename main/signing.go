@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+// SIGNING_ALGORITHMS are the hash functions of the HMAC signing profiles
+var SIGNING_ALGORITHMS = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// SIGNING_DATE_FORMATS are the named date formats of the signing profiles,
+// other values are Go time layouts
+var SIGNING_DATE_FORMATS = map[string]func(time.Time) string{
+	"":        func(t time.Time) string { return t.UTC().Format(http.TimeFormat) },
+	"http":    func(t time.Time) string { return t.UTC().Format(http.TimeFormat) },
+	"rfc3339": func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"unix":    func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	"unixms":  func(t time.Time) string { return strconv.FormatInt(t.UnixMilli(), 10) },
+}
+
+// signingData are the values available in the templates of a signing
+// profile
+type signingData struct {
+	Method     string
+	URL        string
+	Host       string
+	Path       string
+	Query      string
+	Date       string
+	Timestamp  int64
+	Nonce      string
+	Body       string
+	BodySHA256 string
+	BodyMD5    string
+	Signature  string
+	header     http.Header
+}
+
+// Header returns the value of a request header, e.g. {{.Header "Content-Type"}}
+func (d *signingData) Header(name string) string {
+	return d.header.Get(name)
+}
+
+func decodeSigningKey(profile config.SigningProfile) ([]byte, error) {
+	switch strings.ToLower(profile.KeyEncoding) {
+	case "", "raw":
+		return []byte(profile.Key), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(profile.Key)
+	case "hex":
+		return hex.DecodeString(profile.Key)
+	}
+	return nil, fmt.Errorf("unknown key encoding %q", profile.KeyEncoding)
+}
+
+func executeSigningTemplate(name, text string, data *signingData) (string, error) {
+	tpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// signHMAC signs req with a signing profile: the string to sign rendered
+// from the request is signed with HMAC and the signature is sent in the
+// header of the profile
+func signHMAC(req *http.Request, profile config.SigningProfile, now time.Time) error {
+	newHash, found := SIGNING_ALGORITHMS[strings.ToLower(profile.Algorithm)]
+	if !found {
+		return fmt.Errorf("unknown algorithm %q", profile.Algorithm)
+	}
+	key, err := decodeSigningKey(profile)
+	if err != nil {
+		return fmt.Errorf("invalid key: %v", err)
+	}
+	if profile.Header == "" {
+		return fmt.Errorf("header is not set")
+	}
+	body, err := requestBody(req)
+	if err != nil {
+		return err
+	}
+	nonce, err := dynamicUUID(nil)
+	if err != nil {
+		return err
+	}
+
+	data := &signingData{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Host:       req.Host,
+		Path:       req.URL.EscapedPath(),
+		Query:      req.URL.RawQuery,
+		Timestamp:  now.Unix(),
+		Nonce:      nonce,
+		Body:       string(body),
+		BodySHA256: sha256Hex(body),
+		header:     req.Header,
+	}
+	if data.Host == "" {
+		data.Host = req.URL.Host
+	}
+	if data.Path == "" {
+		data.Path = "/"
+	}
+	bodyMD5 := md5.Sum(body)
+	data.BodyMD5 = hex.EncodeToString(bodyMD5[:])
+	if profile.DateHeader != "" && req.Header.Get(profile.DateHeader) != "" {
+		data.Date = req.Header.Get(profile.DateHeader)
+	} else {
+		formatDate, found := SIGNING_DATE_FORMATS[strings.ToLower(profile.DateFormat)]
+		if !found {
+			layout := profile.DateFormat
+			formatDate = func(t time.Time) string { return t.UTC().Format(layout) }
+		}
+		data.Date = formatDate(now)
+		if profile.DateHeader != "" {
+			req.Header.Set(profile.DateHeader, data.Date)
+		}
+	}
+
+	stringToSign, err := executeSigningTemplate("stringToSign", profile.StringToSign, data)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(stringToSign))
+	switch strings.ToLower(profile.SignatureEncoding) {
+	case "", "base64":
+		data.Signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	case "hex":
+		data.Signature = hex.EncodeToString(mac.Sum(nil))
+	default:
+		return fmt.Errorf("unknown signature encoding %q", profile.SignatureEncoding)
+	}
+
+	value := "{{.Signature}}"
+	if profile.Value != "" {
+		value = profile.Value
+	}
+	signed, err := executeSigningTemplate("value", value, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(profile.Header, signed)
+	return nil
+}
+
+// signRequest signs req with the signing profile or the AWS credentials
+// selected in the auth view. It runs once the body is final, after its
+// compression.
+func signRequest(r *Request, req *http.Request, conf *config.Config) error {
+	if r.Auth == "" {
+		return nil
+	}
+	settings, err := parseAuth(r.Auth)
+	if err != nil {
+		return err
+	}
+	if r.signAWS {
+		return signAWSv4(req, settings, time.Now())
+	}
+	if settings["type"] != "hmac" {
+		return nil
+	}
+	profile, found := conf.Signing[settings["profile"]]
+	if !found {
+		return fmt.Errorf("HMAC auth error: no signing profile %q in the config file", settings["profile"])
+	}
+	if req.Header.Get(profile.Header) != "" {
+		return nil
+	}
+	if err := signHMAC(req, profile, time.Now()); err != nil {
+		return fmt.Errorf("HMAC auth error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestSignHMAC(t *testing.T) {
+	now := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	r := &Request{Url: "https://api.example.com/v1/items", Method: http.MethodPost, Data: `{"id":1}`}
+	req, err := r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = signHMAC(req, config.SigningProfile{
+		Algorithm:    "sha256",
+		Key:          "secret",
+		StringToSign: "{{.Method}}\n{{.Path}}\n{{.Date}}\n{{.BodySHA256}}",
+		Header:       "Authorization",
+		Value:        "HMAC key-id:{{.Signature}}",
+		DateHeader:   "Date",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if date := req.Header.Get("Date"); date != "Mon, 02 Jan 2006 15:04:05 GMT" {
+		t.Errorf("unexpected date %s", date)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "HMAC key-id:oS0378Hu76+93exjNTfC6DpmIUR0eTod4BRxQ8FzhVk=" {
+		t.Errorf("unexpected signature %s", auth)
+	}
+
+	r = &Request{Url: "https://api.example.com/v1/items", GetParams: "a=1", Method: http.MethodGet}
+	req, err = r.newHTTPRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = signHMAC(req, config.SigningProfile{
+		Algorithm:         "sha1",
+		Key:               "00ff",
+		KeyEncoding:       "hex",
+		StringToSign:      "{{.Method}} {{.Path}}?{{.Query}} {{.Timestamp}}",
+		SignatureEncoding: "hex",
+		Header:            "X-Signature",
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature := req.Header.Get("X-Signature"); signature != "8ec171e02b04f60f53e0b7f1c00da9bcf313aeb6" {
+		t.Errorf("unexpected signature %s", signature)
+	}
+}
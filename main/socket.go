@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hitstill/buzz/formatter"
+	"github.com/jroimartin/gocui"
+)
+
+// SOCKET_SCHEMES are the URL schemes of plain TCP and TLS connections, the
+// data view is sent instead of an HTTP request
+var SOCKET_SCHEMES = map[string]bool{
+	"tcp": true,
+	"tls": true,
+}
+
+// socketURL returns the parsed URL if it has a socket scheme
+func socketURL(rawURL string) (*url.URL, bool) {
+	scheme, _, found := strings.Cut(rawURL, "://")
+	if !found || !SOCKET_SCHEMES[strings.ToLower(scheme)] {
+		return nil, false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+// socketPayload returns the bytes sent for the data view content: a
+// "@path" file as it is, or the text with every line ending with CRLF
+func socketPayload(data string) ([]byte, error) {
+	if path, found := bodyFilePath(data); found {
+		return os.ReadFile(path)
+	}
+	if data == "" {
+		return nil, nil
+	}
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	return []byte(strings.Join(lines, "\r\n") + "\r\n"), nil
+}
+
+// dialSocket connects to the host and port of u, with TLS for tls:// URLs
+func dialSocket(ctx context.Context, u *url.URL) (net.Conn, error) {
+	if u.Port() == "" {
+		return nil, errors.New("the URL has no port, e.g. tcp://localhost:6379")
+	}
+	transport := TRANSPORT.Load()
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(u.Scheme) != "tls" {
+		return conn, nil
+	}
+	config := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	}
+	config.ServerName = u.Hostname()
+	if config.VerifyConnection != nil {
+		config.VerifyConnection = verifyPins(u.Hostname())
+	}
+	tlsConn := tls.Client(conn, config)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// readSocket reads conn until it is closed, nothing is received for idle or
+// ctx is done. Every received chunk is passed to received. It returns why
+// the reading stopped.
+func readSocket(ctx context.Context, conn net.Conn, idle time.Duration, received func([]byte)) (string, error) {
+	deadline, hasDeadline := ctx.Deadline()
+	buf := make([]byte, 32*1024)
+	for {
+		readDeadline := time.Now().Add(idle)
+		if hasDeadline && deadline.Before(readDeadline) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		n, err := conn.Read(buf)
+		if n > 0 {
+			received(append([]byte(nil), buf[:n]...))
+		}
+		var netErr net.Error
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, io.EOF):
+			return "closed by the server", nil
+		case errors.As(err, &netErr) && netErr.Timeout():
+			if hasDeadline && !time.Now().Before(deadline) {
+				return "timeout", nil
+			}
+			return fmt.Sprintf("nothing received for %v", idle), nil
+		default:
+			return "", err
+		}
+	}
+}
+
+// sendSocket sends the data over a TCP or TLS connection to u and displays
+// what comes back while it is received
+func (a *App) sendSocket(g *gocui.Gui, u *url.URL, data string) error {
+	payload, err := socketPayload(data)
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	submission, cancel, render := a.startSubmission(g)
+	// the stale response is replaced by what comes back or the error,
+	// fresh is only accessed by the UI goroutine
+	fresh := false
+	replaceStale := func(g *gocui.Gui) *gocui.View {
+		vrb, _ := g.View(RESPONSE_BODY_VIEW)
+		if !fresh {
+			a.clearStaleResponse(g)
+			vrb.SetOrigin(0, 0)
+			fresh = true
+		}
+		return vrb
+	}
+	progress := newRequestProgress()
+	go progress.run(g)
+
+	r := &Request{
+		Url:         u.String(),
+		Method:      strings.ToUpper(u.Scheme),
+		Data:        data,
+		ContentType: "text/plain",
+		Time:        time.Now(),
+	}
+	idle := a.config.General.SocketIdleTimeout.Duration
+	go func() {
+		defer progress.stop(g)
+		defer cancel()
+		ctx := submission
+		if CLIENT.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, CLIENT.Timeout)
+			defer cancel()
+		}
+
+		err := RATE_LIMITER.wait(ctx)
+		var conn net.Conn
+		if err == nil {
+			WIRE_LOG.printf("*", "Connecting to %v", u)
+			conn, err = dialSocket(ctx, u)
+		}
+		if err != nil && submission.Err() != nil {
+			LOGGER.Info("connection cancelled by a newer request", "url", r.Url)
+			return
+		}
+		if err != nil {
+			WIRE_LOG.printf("*", "Error: %v", err)
+			LOGGER.Error("connection failed", "url", r.Url, "error", err)
+			render(func(g *gocui.Gui) error {
+				vrb := replaceStale(g)
+				fmt.Fprintf(vrb, "Connection error: %v", err)
+				return nil
+			})
+			return
+		}
+		defer conn.Close()
+		// a newer request interrupts the reading
+		stop := context.AfterFunc(submission, func() { conn.Close() })
+		defer stop()
+		r.RemoteAddr = conn.RemoteAddr().String()
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			r.TLSVersion = tlsConn.ConnectionState().Version
+		}
+		WIRE_LOG.printf("*", "Connected to %v", r.RemoteAddr)
+
+		if _, err := conn.Write(payload); err != nil {
+			WIRE_LOG.printf("*", "Error: %v", err)
+			render(func(g *gocui.Gui) error {
+				vrb := replaceStale(g)
+				fmt.Fprintf(vrb, "Sending failed: %v", err)
+				return nil
+			})
+			return
+		}
+		WIRE_LOG.printf(">", "%d bytes", len(payload))
+
+		var body []byte
+		reason, err := readSocket(ctx, conn, idle, func(chunk []byte) {
+			body = append(body, chunk...)
+			WIRE_LOG.printf("<", "%d bytes", len(chunk))
+			render(func(g *gocui.Gui) error {
+				vrb := replaceStale(g)
+				// gocui treats \r as a line reset
+				vrb.Write([]byte(strings.ReplaceAll(string(chunk), "\r\n", "\n")))
+				return nil
+			})
+		})
+		if submission.Err() != nil {
+			WIRE_LOG.printf("*", "Connection closed for a newer request")
+			LOGGER.Info("connection cancelled by a newer request", "url", r.Url)
+			return
+		}
+		if err != nil {
+			reason = "error: " + err.Error()
+		}
+		WIRE_LOG.printf("*", "Connection ended: %v", reason)
+		r.Duration = time.Since(r.Time)
+		r.RawResponseBody = body
+		r.TransferSize = int64(len(body))
+		r.Formatter = formatter.NewForBody(a.config, r.ContentType, body)
+		r.ResponseHeaders = formatSocketSummary(r, len(payload), reason)
+		LOGGER.Info("connection", "url", r.Url, "sent", len(payload), "received", len(body), "duration_ms", r.Duration.Milliseconds(), "end", reason)
+
+		render(func(g *gocui.Gui) error {
+			a.addToHistory(r)
+			replaceStale(g)
+			vrh, _ := g.View(RESPONSE_HEADERS_VIEW)
+			vrh.Clear()
+			fmt.Fprint(vrh, a.responseHeadersText(r))
+			a.resetBodyLimit()
+			a.PrintBody(g)
+			return nil
+		})
+	}()
+	return nil
+}
+
+// formatSocketSummary describes the connection of r for the response
+// headers view
+func formatSocketSummary(r *Request, sent int, reason string) string {
+	summary := &strings.Builder{}
+	writeConnectionInfo(summary, r)
+	fmt.Fprintf(summary, "\x1b[0;36m* %d bytes sent, %d bytes received in %v\x1b[0;0m\n", sent, len(r.RawResponseBody), r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(summary, "\x1b[0;36m* Connection %v\x1b[0;0m\n", reason)
+	return summary.String()
+}
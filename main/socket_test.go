@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSocketURL(t *testing.T) {
+	for rawURL, expected := range map[string]bool{
+		"tcp://localhost:6379":     true,
+		"TLS://example.com:465":    true,
+		"https://example.com":      false,
+		"localhost:6379":           false,
+		"tcpx://localhost:6379":    false,
+		"tcp://localhost:6379/foo": true,
+	} {
+		if _, found := socketURL(rawURL); found != expected {
+			t.Errorf("socketURL(%q) = %v, expected %v", rawURL, found, expected)
+		}
+	}
+}
+
+func TestSocketPayload(t *testing.T) {
+	for data, expected := range map[string]string{
+		"":                 "",
+		"PING":             "PING\r\n",
+		"HELO a\nQUIT":     "HELO a\r\nQUIT\r\n",
+		"HELO a\r\nQUIT\n": "HELO a\r\nQUIT\r\n\r\n",
+	} {
+		payload, err := socketPayload(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(payload) != expected {
+			t.Errorf("socketPayload(%q) = %q, expected %q", data, payload, expected)
+		}
+	}
+}
+
+func TestDialAndReadSocket(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		buf := make([]byte, 4)
+		io.ReadFull(conn, buf)
+		conn.Write([]byte("+OK\r\n"))
+		conn.Write(buf)
+		conn.Close()
+	}()
+
+	u, _ := url.Parse("tcp://" + listener.Addr().String())
+	conn, err := dialSocket(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("PING"))
+	var received []byte
+	reason, err := readSocket(context.Background(), conn, time.Second, func(chunk []byte) {
+		received = append(received, chunk...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(received) != "+OK\r\nPING" {
+		t.Errorf("received %q", received)
+	}
+	if reason != "closed by the server" {
+		t.Errorf("reason %q", reason)
+	}
+}
+
+func TestReadSocketIdle(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go server.Write([]byte("hello"))
+	var received []byte
+	reason, err := readSocket(context.Background(), client, 50*time.Millisecond, func(chunk []byte) {
+		received = append(received, chunk...)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(received) != "hello" || reason != "nothing received for 50ms" {
+		t.Errorf("received %q, reason %q", received, reason)
+	}
+}
+
+func TestDialSocketWithoutPort(t *testing.T) {
+	u, _ := url.Parse("tcp://localhost")
+	if _, err := dialSocket(context.Background(), u); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// STALE_MARK is appended to the titles of the response views while the
+// response they display is replaced by the one of a request in flight
+const STALE_MARK = " [stale]"
+
+// staleTitle returns the title marked as stale, once
+func staleTitle(title string) string {
+	if strings.HasSuffix(title, STALE_MARK) {
+		return title
+	}
+	return title + STALE_MARK
+}
+
+// markResponseStale keeps the displayed response while a new request is
+// sent, the titles of its views are marked until it is replaced
+func markResponseStale(g *gocui.Gui) {
+	for _, name := range []string{RESPONSE_HEADERS_VIEW, RESPONSE_BODY_VIEW} {
+		v, err := g.View(name)
+		if err != nil || v.Buffer() == "" {
+			continue
+		}
+		v.Title = staleTitle(v.Title)
+	}
+}
+
+// clearStaleResponse empties the response views before the new response or
+// error is displayed
+func (a *App) clearStaleResponse(g *gocui.Gui) {
+	a.clearStaleHeaders(g)
+	if vrb, err := g.View(RESPONSE_BODY_VIEW); err == nil {
+		vrb.Clear()
+		vrb.Title = VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title
+	}
+}
+
+// clearStaleHeaders empties the response headers view, the body view is
+// replaced once the new body is formatted
+func (a *App) clearStaleHeaders(g *gocui.Gui) {
+	if vrh, err := g.View(RESPONSE_HEADERS_VIEW); err == nil {
+		vrh.Clear()
+	}
+	a.setResponseHeadersTitle(g)
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+func TestStaleTitle(t *testing.T) {
+	title := staleTitle("Response body [JSON]")
+	if title != "Response body [JSON]"+STALE_MARK {
+		t.Errorf("unexpected title %q", title)
+	}
+	if marked := staleTitle(title); marked != title {
+		t.Errorf("expected the title to be marked once, got %q", marked)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// DEFAULT_STATUS_COLORS are the frame colors of the response views by status
+// class, the status_colors table of the config file overrides them
+var DEFAULT_STATUS_COLORS = map[string]string{
+	"2xx": "green",
+	"3xx": "yellow",
+	"4xx": "red",
+	"5xx": "red",
+}
+
+// FRAME_COLORS are the colors of the status_colors table, "default" keeps
+// the color of the other frames
+var FRAME_COLORS = map[string]gocui.Attribute{
+	"default": gocui.ColorDefault,
+	"black":   gocui.ColorBlack,
+	"red":     gocui.ColorRed,
+	"green":   gocui.ColorGreen,
+	"yellow":  gocui.ColorYellow,
+	"blue":    gocui.ColorBlue,
+	"magenta": gocui.ColorMagenta,
+	"cyan":    gocui.ColorCyan,
+	"white":   gocui.ColorWhite,
+}
+
+// STATUS_FRAME_VIEWS are the views whose frame is colored by the status of
+// the response
+var STATUS_FRAME_VIEWS = []string{RESPONSE_HEADERS_VIEW, RESPONSE_BODY_VIEW}
+
+// gocui draws every frame in the same color, the frame of the colored views
+// is drawn over by frameless views one cell thick: their top and bottom
+// lines and their left and right columns
+var frameSides = []string{"frame-top", "frame-bottom", "frame-left", "frame-right"}
+
+func frameStrip(view, side string) string {
+	return view + "-" + side
+}
+
+// frameStripParent returns the view whose frame is drawn by the view name
+func frameStripParent(name string) (string, bool) {
+	for _, view := range STATUS_FRAME_VIEWS {
+		for _, side := range frameSides {
+			if name == frameStrip(view, side) {
+				return view, true
+			}
+		}
+	}
+	return "", false
+}
+
+// statusClass returns the class of a status code, e.g. "4xx"
+func statusClass(code int) string {
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+// statusFrameColor returns the frame color of the responses with the status
+// code, false if their frames keep the default color
+func (a *App) statusFrameColor(code int) (gocui.Attribute, bool) {
+	if code == 0 {
+		return 0, false
+	}
+	class := statusClass(code)
+	name, found := a.config.StatusColors[class]
+	if !found {
+		name = DEFAULT_STATUS_COLORS[class]
+	}
+	color, found := FRAME_COLORS[strings.ToLower(name)]
+	if !found || color == gocui.ColorDefault {
+		return 0, false
+	}
+	return color, true
+}
+
+// layoutStatusFrames draws the frames of the response views in the color of
+// the status of the displayed response
+func (a *App) layoutStatusFrames(g *gocui.Gui) error {
+	color := g.FgColor
+	if len(a.history) > 0 {
+		if statusColor, found := a.statusFrameColor(a.history[a.historyIndex].StatusCode); found {
+			color = statusColor
+		}
+	}
+	for _, name := range STATUS_FRAME_VIEWS {
+		v, err := g.View(name)
+		if err != nil {
+			return err
+		}
+		x0, y0, x1, y1, _ := g.ViewPosition(name)
+		width := x1 - x0 + 1
+		top := []rune(strings.Repeat("─", width))
+		top[0], top[width-1] = '┌', '┐'
+		// as gocui draws the titles
+		for i, ch := range []rune(v.Title) {
+			if i+2 > width-3 {
+				break
+			}
+			top[i+2] = ch
+		}
+		bottom := "└" + strings.Repeat("─", width-2) + "┘"
+		side := strings.Repeat("│\n", maxInt(y1-y0-1, 0))
+
+		for _, strip := range []struct {
+			side           string
+			x0, y0, x1, y1 int
+			text           string
+		}{
+			{"frame-top", x0 - 1, y0 - 1, x1 + 1, y0 + 1, string(top)},
+			{"frame-bottom", x0 - 1, y1 - 1, x1 + 1, y1 + 1, bottom},
+			{"frame-left", x0 - 1, y0, x0 + 1, y1, side},
+			{"frame-right", x1 - 1, y0, x1 + 1, y1, side},
+		} {
+			v, err := g.SetView(frameStrip(name, strip.side), strip.x0, strip.y0, strip.x1, strip.y1)
+			if err != nil && err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Frame = false
+			v.FgColor = color
+			v.Clear()
+			fmt.Fprint(v, strip.text)
+		}
+	}
+	return nil
+}
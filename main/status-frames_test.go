@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+func TestStatusFrameColor(t *testing.T) {
+	a := &App{config: &config.Config{StatusColors: map[string]string{"3xx": "default", "5xx": "Magenta"}}}
+	for _, test := range []struct {
+		code     int
+		color    gocui.Attribute
+		expected bool
+	}{
+		{0, 0, false},
+		{204, gocui.ColorGreen, true},
+		{301, 0, false},
+		{404, gocui.ColorRed, true},
+		{503, gocui.ColorMagenta, true},
+		{101, 0, false},
+	} {
+		if color, found := a.statusFrameColor(test.code); color != test.color || found != test.expected {
+			t.Errorf("%d: expected %v %v, got %v %v", test.code, test.color, test.expected, color, found)
+		}
+	}
+
+	if parent, found := frameStripParent(frameStrip(RESPONSE_BODY_VIEW, "frame-left")); !found || parent != RESPONSE_BODY_VIEW {
+		t.Errorf("unexpected parent %q", parent)
+	}
+	if _, found := frameStripParent(RESPONSE_BODY_VIEW); found {
+		t.Error("the response body view is not a frame strip")
+	}
+}
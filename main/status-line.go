@@ -1,9 +1,13 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
+	"mime"
+	"net/http"
 	"strconv"
 	"text/template"
+	"time"
 
 	"github.com/jroimartin/gocui"
 )
@@ -14,17 +18,224 @@ type StatusLine struct {
 
 type StatusLineFunctions struct {
 	app *App
+	// name of the focused view
+	view string
 }
 
 func (*StatusLineFunctions) Version() string {
 	return VERSION
 }
 
-func (s *StatusLineFunctions) Duration() string {
+// request returns the currently displayed request or nil if the history
+// is empty
+func (s *StatusLineFunctions) request() *Request {
 	if len(s.app.history) == 0 {
+		return nil
+	}
+	return s.app.history[s.app.historyIndex]
+}
+
+func (s *StatusLineFunctions) Duration() string {
+	r := s.request()
+	if r == nil {
+		return ""
+	}
+	return r.Duration.String()
+}
+
+func (s *StatusLineFunctions) Size() string {
+	r := s.request()
+	if r == nil || r.RawResponseBody == nil {
+		return ""
+	}
+	return formatSize(int64(len(r.RawResponseBody)))
+}
+
+// TransferSize returns the number of response body bytes received, which
+// differs from Size for compressed responses
+func (s *StatusLineFunctions) TransferSize() string {
+	r := s.request()
+	if r == nil || r.RawResponseBody == nil {
+		return ""
+	}
+	return formatSize(r.TransferSize)
+}
+
+func (s *StatusLineFunctions) Speed() string {
+	r := s.request()
+	if r == nil || r.RawResponseBody == nil {
+		return ""
+	}
+	return formatSize(int64(r.Throughput())) + "/s"
+}
+
+// CacheStatus returns the outcome of the response cache lookup: HIT, MISS,
+// REVALIDATED or BYPASS
+func (s *StatusLineFunctions) CacheStatus() string {
+	r := s.request()
+	if r == nil {
+		return ""
+	}
+	return r.CacheStatus
+}
+
+// Schema returns the outcome of the validation of the response body against
+// the JSON Schema of the request
+func (s *StatusLineFunctions) Schema() string {
+	r := s.request()
+	switch {
+	case r == nil || r.Schema == "" || r.RawResponseBody == nil:
+		return ""
+	case r.SchemaError != nil:
+		return "error"
+	case len(r.SchemaViolations) > 0:
+		return fmt.Sprintf("%d violations", len(r.SchemaViolations))
+	}
+	return "valid"
+}
+
+// Contract returns the outcome of the validation of the response against
+// the OpenAPI spec
+func (s *StatusLineFunctions) Contract() string {
+	r := s.request()
+	switch {
+	case r == nil || !r.ContractChecked:
+		return ""
+	case r.ContractError != nil:
+		return "error"
+	case len(r.ContractViolations) > 0:
+		return fmt.Sprintf("%d mismatches", len(r.ContractViolations))
+	}
+	return "valid"
+}
+
+func (s *StatusLineFunctions) StatusCode() string {
+	r := s.request()
+	if r == nil || r.StatusCode == 0 {
+		return ""
+	}
+	return strconv.Itoa(r.StatusCode)
+}
+
+func (s *StatusLineFunctions) Status() string {
+	r := s.request()
+	if r == nil || r.StatusCode == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))
+}
+
+func (s *StatusLineFunctions) ContentType() string {
+	r := s.request()
+	if r == nil {
+		return ""
+	}
+	ctype, _, err := mime.ParseMediaType(r.ContentType)
+	if err != nil {
+		return r.ContentType
+	}
+	return ctype
+}
+
+// Elapsed returns the time passed since the displayed request was sent
+func (s *StatusLineFunctions) Elapsed() string {
+	r := s.request()
+	if r == nil || r.Time.IsZero() {
+		return ""
+	}
+	return time.Since(r.Time).Round(time.Second).String()
+}
+
+func (s *StatusLineFunctions) Proxy() string {
+	return s.app.proxyURL
+}
+
+func (s *StatusLineFunctions) TLSVersion() string {
+	r := s.request()
+	if r == nil || r.TLSVersion == 0 {
+		return ""
+	}
+	return tls.VersionName(r.TLSVersion)
+}
+
+// SPARKLINE_LENGTH is the number of requests displayed by DurationTrend
+const SPARKLINE_LENGTH = 10
+
+var SPARKLINE_BARS = []rune("▁▂▃▄▅▆▇█")
+
+// DurationTrend returns a sparkline of the response times of the previous
+// requests to the same URL, up to the displayed one
+func (s *StatusLineFunctions) DurationTrend() string {
+	r := s.request()
+	if r == nil {
+		return ""
+	}
+	var durations []time.Duration
+	for _, h := range s.app.history[:s.app.historyIndex+1] {
+		if h.Method == r.Method && h.fullURL() == r.fullURL() && h.Duration > 0 {
+			durations = append(durations, h.Duration)
+		}
+	}
+	if len(durations) < 2 {
+		return ""
+	}
+	if len(durations) > SPARKLINE_LENGTH {
+		durations = durations[len(durations)-SPARKLINE_LENGTH:]
+	}
+	return sparkline(durations)
+}
+
+func sparkline(values []time.Duration) string {
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if max > min {
+			level = int((v - min) * time.Duration(len(SPARKLINE_BARS)-1) / (max - min))
+		}
+		bars[i] = SPARKLINE_BARS[level]
+	}
+	return string(bars)
+}
+
+// CertExpiry returns a warning if the certificate of the server expires
+// within the certExpiryWarning duration of the configuration
+func (s *StatusLineFunctions) CertExpiry() string {
+	r := s.request()
+	if r == nil || r.CertExpiry.IsZero() {
 		return ""
 	}
-	return s.app.history[s.app.historyIndex].Duration.String()
+	return certExpiryWarning(r.CertExpiry, time.Now(), s.app.config.General.CertExpiryWarning.Duration)
+}
+
+func certExpiryWarning(expiry, now time.Time, window time.Duration) string {
+	left := expiry.Sub(now)
+	switch {
+	case window <= 0 || left > window:
+		return ""
+	case left < 0:
+		return fmt.Sprintf("Certificate expired %s ago", formatDays(-left))
+	}
+	return fmt.Sprintf("Certificate expires in %s", formatDays(left))
+}
+
+func formatDays(d time.Duration) string {
+	if days := int(d.Hours() / 24); days != 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return "1 day"
+}
+
+func (s *StatusLineFunctions) HistoryPosition() string {
+	return s.RequestNumber() + "/" + s.HistorySize()
 }
 
 func (s *StatusLineFunctions) HistorySize() string {
@@ -43,15 +254,18 @@ func (s *StatusLineFunctions) SearchType() string {
 	if len(s.app.history) > 0 && !s.app.history[s.app.historyIndex].Formatter.Searchable() {
 		return "none"
 	}
+	if s.app.captureSearch {
+		return "regex captures"
+	}
 	if s.app.config.General.ContextSpecificSearch {
 		return "response specific"
 	}
 	return "regex"
 }
 
-func (s *StatusLine) Update(v *gocui.View, a *App) {
+func (s *StatusLine) Update(v *gocui.View, a *App, focused string) {
 	v.Clear()
-	err := s.tpl.Execute(v, &StatusLineFunctions{app: a})
+	err := s.tpl.Execute(v, &StatusLineFunctions{app: a, view: focused})
 	if err != nil {
 		fmt.Fprintf(v, "StatusLine update error: %v", err)
 	}
@@ -64,6 +278,78 @@ func (s *StatusLineFunctions) DisableRedirect() string {
 	return "Activated"
 }
 
+// formatSize returns a human readable representation of n bytes
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// KeepAliveDisabled returns "Activated" if every request uses a new
+// connection which is closed afterwards
+func (s *StatusLineFunctions) KeepAliveDisabled() string {
+	if !s.app.config.General.DisableKeepAlives {
+		return ""
+	}
+	return "Activated"
+}
+
+// Offline returns "on" if the recorded responses are replayed instead of
+// sending the requests
+func (s *StatusLineFunctions) Offline() string {
+	if !s.app.config.General.Offline {
+		return ""
+	}
+	return "on"
+}
+
+// GraphQL returns the number of types of the GraphQL schema completed in the
+// data view
+func (s *StatusLineFunctions) GraphQL() string {
+	if s.app.graphQL == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d types", len(s.app.graphQL.types))
+}
+
+// Environment returns the workspace environment whose variables are used
+func (s *StatusLineFunctions) Environment() string {
+	return s.app.environment
+}
+
+// Transport returns the transport options of the edited request
+func (s *StatusLineFunctions) Transport() string {
+	return s.app.transport.String()
+}
+
+// GzipBody returns "on" if the request bodies are compressed
+func (s *StatusLineFunctions) GzipBody() string {
+	if !s.app.config.General.GzipRequestBody {
+		return ""
+	}
+	return "on"
+}
+
+// KeyHints returns the most relevant keybindings of the focused view, the
+// keyHints option sets how many
+func (s *StatusLineFunctions) KeyHints() string {
+	return keyHints(s.app.config.Keys, s.view, s.app.config.General.KeyHints)
+}
+
+func (s *StatusLineFunctions) AutoSave() string {
+	if !s.app.config.General.AutoSave {
+		return ""
+	}
+	return s.app.config.General.AutoSaveDirectory
+}
+
 func NewStatusLine(format string) (*StatusLine, error) {
 	tpl, err := template.New("status line").Parse(format)
 	if err != nil {
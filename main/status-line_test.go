@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	ms := time.Millisecond
+	if s := sparkline([]time.Duration{10 * ms, 20 * ms, 80 * ms, 45 * ms}); s != "▁▂█▄" {
+		t.Errorf("unexpected sparkline %s", s)
+	}
+	if s := sparkline([]time.Duration{10 * ms, 10 * ms}); s != "▁▁" {
+		t.Errorf("unexpected sparkline of identical durations %s", s)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// PLACEHOLDER_PATTERN matches the {{name}} placeholders of request templates
+var PLACEHOLDER_PATTERN = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// views searched for placeholders, in the order they are prompted
+var TEMPLATE_VIEWS = []string{
+	URL_VIEW,
+	URL_PARAMS_VIEW,
+	REQUEST_METHOD_VIEW,
+	REQUEST_HEADERS_VIEW,
+	REQUEST_DATA_VIEW,
+	AUTH_VIEW,
+}
+
+// findPlaceholders returns the names of the placeholders of a saved request
+// in the order of their first appearance
+func findPlaceholders(requestMap map[string]string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, view := range TEMPLATE_VIEWS {
+		for _, m := range PLACEHOLDER_PATTERN.FindAllStringSubmatch(requestMap[view], -1) {
+			if !seen[m[1]] && !isDynamicVariable(m[1]) {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// fillPlaceholders replaces the placeholders having a non empty value,
+// the others are kept as they are
+func fillPlaceholders(requestMap map[string]string, values map[string]string) {
+	for view, text := range requestMap {
+		requestMap[view] = PLACEHOLDER_PATTERN.ReplaceAllStringFunc(text, func(placeholder string) string {
+			name := PLACEHOLDER_PATTERN.FindStringSubmatch(placeholder)[1]
+			if value := values[name]; value != "" {
+				return value
+			}
+			return placeholder
+		})
+	}
+}
+
+// expandVariables replaces the placeholders having a value in variables
+func expandVariables(text string, variables map[string]string) string {
+	if len(variables) == 0 {
+		return text
+	}
+	return PLACEHOLDER_PATTERN.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, found := variables[PLACEHOLDER_PATTERN.FindStringSubmatch(placeholder)[1]]; found {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// parseTemplateForm parses the "name = value" lines of the template form
+func parseTemplateForm(text string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		name, value, found := strings.Cut(line, "=")
+		if found {
+			values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+// OpenTemplateForm prompts for the values of the placeholders, fill is
+// called with them when the form is submitted
+func (a *App) OpenTemplateForm(g *gocui.Gui, placeholders []string, fill func(g *gocui.Gui, values map[string]string)) error {
+	form, err := a.CreatePopupView(TEMPLATE_FORM_VIEW, 60, len(placeholders), g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	form.Title = VIEW_TITLES[TEMPLATE_FORM_VIEW]
+	form.Editable = true
+	form.Highlight = false
+	width := 0
+	for _, name := range placeholders {
+		width = maxInt(width, len(name))
+	}
+	for _, name := range placeholders {
+		fmt.Fprintf(form, "%-*s = \n", width, name)
+	}
+	g.SetViewOnTop(TEMPLATE_FORM_VIEW)
+	g.SetCurrentView(TEMPLATE_FORM_VIEW)
+	form.SetCursor(width+3, 0)
+
+	g.DeleteKeybinding(TEMPLATE_FORM_VIEW, gocui.KeyEnter, gocui.ModNone)
+	g.SetKeybinding(TEMPLATE_FORM_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		values := parseTemplateForm(getViewValue(g, TEMPLATE_FORM_VIEW))
+		a.closePopup(g, TEMPLATE_FORM_VIEW)
+		fill(g, values)
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFillPlaceholders(t *testing.T) {
+	requestMap := map[string]string{
+		URL_VIEW:             "https://{{host}}/users/{{ id }}",
+		REQUEST_HEADERS_VIEW: "Authorization: Bearer {{token}}\nX-Host: {{host}}",
+	}
+	names := findPlaceholders(requestMap)
+	if !reflect.DeepEqual(names, []string{"host", "id", "token"}) {
+		t.Fatalf("unexpected placeholders %v", names)
+	}
+	values := parseTemplateForm("host  = example.com\nid    = 42\ntoken = ")
+	fillPlaceholders(requestMap, values)
+	if requestMap[URL_VIEW] != "https://example.com/users/42" {
+		t.Errorf("unexpected url %q", requestMap[URL_VIEW])
+	}
+	if requestMap[REQUEST_HEADERS_VIEW] != "Authorization: Bearer {{token}}\nX-Host: example.com" {
+		t.Errorf("unexpected headers %q", requestMap[REQUEST_HEADERS_VIEW])
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var RESPONSE_EXPORT_FORMATS = []struct {
+	name   string
+	export func(r *Request) []byte
+	// extension replaces the one of the suggested file name
+	extension string
+}{
+	{
+		name:   "Response body (raw)",
+		export: exportResponseBody,
+	},
+	{
+		name:   "Response body (formatted)",
+		export: exportFormattedResponseBody,
+	},
+	{
+		name:      "Transcript (text)",
+		export:    exportTranscriptText,
+		extension: ".txt",
+	},
+	{
+		name:      "Transcript (JSON)",
+		export:    exportTranscriptJSON,
+		extension: ".json",
+	},
+}
+
+func exportResponseBody(r *Request) []byte {
+	return r.RawResponseBody
+}
+
+// exportFormattedResponseBody returns the output of the formatter of the
+// response without colors, or the raw body if it cannot be formatted
+func exportFormattedResponseBody(r *Request) []byte {
+	out := &bytes.Buffer{}
+	if r.Formatter == nil || r.Formatter.Format(out, r.RawResponseBody) != nil {
+		return r.RawResponseBody
+	}
+	return ANSI_ESCAPE_PATTERN.ReplaceAll(out.Bytes(), nil)
+}
+
+// exportTranscriptText writes the request and the response in a format
+// similar to the output of curl -v
+func exportTranscriptText(r *Request) []byte {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "> %s %s\n", r.Method, r.fullURL())
+	writeTranscriptHeaders(sb, "> ", r.RequestHeader)
+	fmt.Fprintln(sb, ">")
+	if r.Data != "" {
+		fmt.Fprintln(sb, r.Data)
+	}
+	fmt.Fprintln(sb)
+
+	fmt.Fprintf(sb, "< %s %d %s\n", r.Proto, r.StatusCode, http.StatusText(r.StatusCode))
+	writeTranscriptHeaders(sb, "< ", r.ResponseHeader)
+	fmt.Fprintln(sb, "<")
+	sb.Write(r.RawResponseBody)
+	if len(r.ResponseTrailer) > 0 {
+		fmt.Fprintln(sb)
+		writeTranscriptHeaders(sb, "< ", r.ResponseTrailer)
+	}
+	fmt.Fprintln(sb)
+
+	fmt.Fprintf(sb, "* Sent: %s\n", r.Time.Format(time.RFC3339))
+	fmt.Fprintf(sb, "* Response time: %s\n", r.Duration)
+	fmt.Fprintf(sb, "* Download time: %s\n", r.DownloadDuration)
+	fmt.Fprintf(sb, "* Size: %d bytes (%d bytes transferred)\n", len(r.RawResponseBody), r.TransferSize)
+	return []byte(sb.String())
+}
+
+func writeTranscriptHeaders(sb *strings.Builder, prefix string, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range h[name] {
+			fmt.Fprintf(sb, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+type transcriptBody struct {
+	Encoding string `json:"encoding,omitempty"`
+	Content  string `json:"content"`
+}
+
+func newTranscriptBody(b []byte) transcriptBody {
+	if utf8.Valid(b) {
+		return transcriptBody{Content: string(b)}
+	}
+	return transcriptBody{Encoding: "base64", Content: base64.StdEncoding.EncodeToString(b)}
+}
+
+func exportTranscriptJSON(r *Request) []byte {
+	transcript := map[string]interface{}{
+		"request": map[string]interface{}{
+			"method":  r.Method,
+			"url":     r.fullURL(),
+			"headers": r.RequestHeader,
+			"body":    newTranscriptBody([]byte(r.Data)),
+		},
+		"response": map[string]interface{}{
+			"proto":    r.Proto,
+			"status":   r.StatusCode,
+			"headers":  r.ResponseHeader,
+			"trailers": r.ResponseTrailer,
+			"body":     newTranscriptBody(r.RawResponseBody),
+		},
+		"timing": map[string]interface{}{
+			"sent":         r.Time.Format(time.RFC3339Nano),
+			"responseTime": r.Duration.String(),
+			"downloadTime": r.DownloadDuration.String(),
+		},
+	}
+	out, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return []byte{}
+	}
+	return out
+}
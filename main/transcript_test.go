@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/hitstill/buzz/formatter"
+)
+
+func TestExportFormattedResponseBody(t *testing.T) {
+	conf := &config.Config{General: config.GeneralOptions{FormatJSON: true}}
+	r := &Request{
+		RawResponseBody: []byte(`{"id":1}`),
+		Formatter:       formatter.New(conf, "application/json"),
+	}
+	if out := string(exportFormattedResponseBody(r)); out != "{\n  \"id\":1\n}" {
+		t.Errorf("unexpected formatted body %q", out)
+	}
+
+	r.RawResponseBody = []byte(`{"id":`)
+	if out := string(exportFormattedResponseBody(r)); out != `{"id":` {
+		t.Errorf("expected the raw body of invalid JSON, got %q", out)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// TLS_RENEGOTIATION are the TLS renegotiation choices of the transport
+// options, renegotiation is refused by default
+var TLS_RENEGOTIATION = map[string]tls.RenegotiationSupport{
+	"never":  tls.RenegotiateNever,
+	"once":   tls.RenegotiateOnceAsClient,
+	"freely": tls.RenegotiateFreelyAsClient,
+}
+
+// transportOptions change how a request is sent, the zero value sends it
+// like the others
+type transportOptions struct {
+	HTTP2         bool   `json:",omitempty"` // negotiate HTTP/2 over TLS
+	Renegotiation string `json:",omitempty"` // once or freely, never if empty
+	Compression   bool   `json:",omitempty"` // request and decode gzip responses
+}
+
+// String returns the options as they are saved with the requests, e.g.
+// "http2 renegotiation=once compression"
+func (o transportOptions) String() string {
+	var options []string
+	if o.HTTP2 {
+		options = append(options, "http2")
+	}
+	if o.Renegotiation != "" && o.Renegotiation != "never" {
+		options = append(options, "renegotiation="+o.Renegotiation)
+	}
+	if o.Compression {
+		options = append(options, "compression")
+	}
+	return strings.Join(options, " ")
+}
+
+// parseTransportOptions parses the options of String, the unknown ones are
+// reported and ignored
+func parseTransportOptions(text string) (transportOptions, error) {
+	var o transportOptions
+	var unknown []string
+	for _, option := range strings.Fields(text) {
+		name, value, _ := strings.Cut(option, "=")
+		switch {
+		case option == "http2":
+			o.HTTP2 = true
+		case option == "compression":
+			o.Compression = true
+		case name == "renegotiation" && TLS_RENEGOTIATION[value] != 0:
+			o.Renegotiation = value
+		case option == "renegotiation=never":
+		default:
+			unknown = append(unknown, option)
+		}
+	}
+	if len(unknown) > 0 {
+		return o, fmt.Errorf("unknown transport options: %v", strings.Join(unknown, ", "))
+	}
+	return o, nil
+}
+
+// curlArgs returns the curl options sending the request the same way
+func (o transportOptions) curlArgs() []string {
+	var args []string
+	if o.HTTP2 {
+		args = append(args, "--http2")
+	}
+	if o.Compression {
+		args = append(args, "--compressed")
+	}
+	return args
+}
+
+type transportOptionsKey struct{}
+
+// withTransportOptions sends req with the options o
+func withTransportOptions(req *http.Request, o transportOptions) *http.Request {
+	if o == (transportOptions{}) {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), transportOptionsKey{}, o))
+}
+
+// optionsTransport sends the requests having transport options, or sent to
+// an IP address with pins, with a copy of next changed accordingly, on a
+// new connection
+type optionsTransport struct {
+	next *sharedTransport
+}
+
+func (t *optionsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	o, found := req.Context().Value(transportOptionsKey{}).(transportOptions)
+	pinned := pinnedAddress(req)
+	if !found && !pinned {
+		return t.next.RoundTrip(req)
+	}
+	transport := t.next.Load().Clone()
+	transport.DisableKeepAlives = true
+	transport.ForceAttemptHTTP2 = o.HTTP2
+	transport.DisableCompression = !o.Compression
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.Renegotiation = TLS_RENEGOTIATION[o.Renegotiation]
+	if pinned {
+		transport.TLSClientConfig.VerifyConnection = verifyPins(req.URL.Hostname())
+	}
+	response, err := transport.RoundTrip(req)
+	if err != nil {
+		transport.CloseIdleConnections()
+		return nil, err
+	}
+	response.Body = &closeIdleBody{response.Body, transport}
+	return response, nil
+}
+
+// closeIdleBody closes the connections of the transport of the response
+// once its body is closed
+type closeIdleBody struct {
+	io.ReadCloser
+	transport *http.Transport
+}
+
+func (b *closeIdleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.transport.CloseIdleConnections()
+	return err
+}
+
+// lines returns the lines of the transport options popup
+func (o transportOptions) lines() []string {
+	version, compression := "HTTP/1.1", "off"
+	if o.HTTP2 {
+		version = "HTTP/2"
+	}
+	if o.Compression {
+		compression = "negotiated"
+	}
+	renegotiation := o.Renegotiation
+	if renegotiation == "" {
+		renegotiation = "never"
+	}
+	return []string{
+		"HTTP version       " + version,
+		"TLS renegotiation  " + renegotiation,
+		"Compression        " + compression,
+	}
+}
+
+// next returns the options with the value of the option of the line
+// changed to the next choice
+func (o transportOptions) next(line int) transportOptions {
+	switch line {
+	case 0:
+		o.HTTP2 = !o.HTTP2
+	case 1:
+		switch o.Renegotiation {
+		case "", "never":
+			o.Renegotiation = "once"
+		case "once":
+			o.Renegotiation = "freely"
+		default:
+			o.Renegotiation = ""
+		}
+	case 2:
+		o.Compression = !o.Compression
+	}
+	return o
+}
+
+// ToggleTransportOptions lists the transport options of the edited request
+func (a *App) ToggleTransportOptions(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == TRANSPORT_OPTIONS_VIEW {
+		a.closePopup(g, TRANSPORT_OPTIONS_VIEW)
+		return nil
+	}
+	lines := a.transport.lines()
+	v, err := a.CreatePopupView(TRANSPORT_OPTIONS_VIEW, 60, len(lines), g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[TRANSPORT_OPTIONS_VIEW]
+	fmt.Fprint(v, strings.Join(lines, "\n"))
+	g.SetViewOnTop(TRANSPORT_OPTIONS_VIEW)
+	g.SetCurrentView(TRANSPORT_OPTIONS_VIEW)
+	selectListLine(v, 0)
+	return nil
+}
+
+// nextTransportOption changes the selected option of the popup
+func (a *App) nextTransportOption(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	a.transport = a.transport.next(cy + oy)
+	v.Clear()
+	fmt.Fprint(v, strings.Join(a.transport.lines(), "\n"))
+	refreshStatusLine(a, g)
+	return nil
+}
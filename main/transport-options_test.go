@@ -0,0 +1,84 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTransportOptionsString(t *testing.T) {
+	for _, o := range []transportOptions{
+		{},
+		{HTTP2: true},
+		{Renegotiation: "once", Compression: true},
+		{HTTP2: true, Renegotiation: "freely", Compression: true},
+	} {
+		parsed, err := parseTransportOptions(o.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != o {
+			t.Errorf("%q: expected %+v, got %+v", o.String(), o, parsed)
+		}
+	}
+
+	if o, err := parseTransportOptions("renegotiation=never compression"); err != nil || o != (transportOptions{Compression: true}) {
+		t.Errorf("unexpected options %+v, %v", o, err)
+	}
+	if o, err := parseTransportOptions("http3 http2"); err == nil || !o.HTTP2 {
+		t.Errorf("expected an error and the known options, got %+v, %v", o, err)
+	}
+	if args := (transportOptions{HTTP2: true, Compression: true}).curlArgs(); !reflect.DeepEqual(args, []string{"--http2", "--compressed"}) {
+		t.Errorf("unexpected curl arguments %q", args)
+	}
+	if o := (transportOptions{}).next(1).next(1).next(1); o != (transportOptions{}) {
+		t.Errorf("expected the renegotiation choices to cycle, got %+v", o)
+	}
+}
+
+func TestOptionsTransport(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			io.WriteString(w, r.Proto)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		io.WriteString(gz, r.Proto)
+		gz.Close()
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	transport := &optionsTransport{newSharedTransport(&http.Transport{
+		DisableCompression: true,
+		TLSClientConfig:    &tls.Config{InsecureSkipVerify: true},
+	})}
+	for _, test := range []struct {
+		options  transportOptions
+		expected string
+	}{
+		{transportOptions{}, "HTTP/1.1"},
+		{transportOptions{HTTP2: true}, "HTTP/2.0"},
+		{transportOptions{HTTP2: true, Compression: true}, "HTTP/2.0"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		response, err := transport.RoundTrip(withTransportOptions(req, test.options))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != test.expected {
+			t.Errorf("%+v: expected %q, got %q", test.options, test.expected, body)
+		}
+	}
+}
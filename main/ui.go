@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httputil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/hitstill/buzz/formatter"
 	"github.com/jroimartin/gocui"
@@ -23,6 +29,7 @@ const (
 	REQUEST_METHOD_VIEW   = "method"
 	REQUEST_DATA_VIEW     = "data"
 	REQUEST_HEADERS_VIEW  = "headers"
+	AUTH_VIEW             = "auth"
 	STATUSLINE_VIEW       = "status-line"
 	SEARCH_VIEW           = "search"
 	RESPONSE_HEADERS_VIEW = "response-headers"
@@ -38,9 +45,39 @@ const (
 	LOAD_REQUEST_DIALOG_VIEW        = "load-request-dialog"
 	SAVE_REQUEST_FORMAT_DIALOG_VIEW = "save-request-format-dialog"
 	SAVE_REQUEST_DIALOG_VIEW        = "save-request-dialog"
+	EXPORT_HISTORY_DIALOG_VIEW      = "export-history-dialog"
+	SAVE_SESSION_DIALOG_VIEW        = "save-session-dialog"
+	LOAD_SESSION_DIALOG_VIEW        = "load-session-dialog"
+	RESPONSE_FORMAT_DIALOG_VIEW     = "save-response-format-dialog"
 	SAVE_RESULT_VIEW                = "save-result"
+	MESSAGE_VIEW                    = "message"
+	PREVIEW_VIEW                    = "preview"
 	METHOD_LIST_VIEW                = "method-list"
 	HELP_VIEW                       = "help"
+	SERVER_LOG_VIEW                 = "server-log"
+	MULTIPART_VIEW                  = "multipart"
+	MULTIPART_PART_DIALOG_VIEW      = "multipart-part-dialog"
+	FILE_PICKER_VIEW                = "file-picker"
+	TEMPLATE_FORM_VIEW              = "template-form"
+	SEARCH_MATCHES_VIEW             = "search-matches"
+	PIPE_COMMAND_VIEW               = "pipe-command"
+	RESPONSE_HEADERS_FILTER_VIEW    = "response-headers-filter"
+	CONFIRM_VIEW                    = "confirm"
+	EXTRACT_VIEW                    = "extract"
+	WORKSPACE_VIEW                  = "workspace"
+	SCHEMA_VIEW                     = "schema"
+	COMPARE_VIEW                    = "compare"
+	DIFF_VIEW                       = "env-diff"
+	WIRE_LOG_VIEW                   = "wire-log"
+	KEYBINDINGS_VIEW                = "keybindings"
+	KEYBINDING_EDIT_VIEW            = "keybinding-edit"
+	RAW_REQUEST_VIEW                = "raw-request"
+	USER_AGENT_VIEW                 = "user-agent"
+	NOTES_VIEW                      = "notes"
+	DUPLICATE_DIALOG_VIEW           = "duplicate-dialog"
+	WSDL_DIALOG_VIEW                = "wsdl-dialog"
+	WSDL_OPERATIONS_VIEW            = "wsdl-operations"
+	TRANSPORT_OPTIONS_VIEW          = "transport"
 )
 
 var VIEW_TITLES = map[string]string{
@@ -50,10 +87,39 @@ var VIEW_TITLES = map[string]string{
 	SAVE_RESPONSE_DIALOG_VIEW:       "Save Response (enter to submit, ctrl+q to cancel)",
 	LOAD_REQUEST_DIALOG_VIEW:        "Load Request (enter to submit, ctrl+q to cancel)",
 	SAVE_REQUEST_DIALOG_VIEW:        "Save Request (enter to submit, ctrl+q to cancel)",
+	EXPORT_HISTORY_DIALOG_VIEW:      "Export History as a curl script (enter to submit, ctrl+q to cancel)",
+	SAVE_SESSION_DIALOG_VIEW:        "Save Session (enter to submit, ctrl+q to cancel)",
+	LOAD_SESSION_DIALOG_VIEW:        "Restore Session (enter to submit, ctrl+q to cancel)",
 	SAVE_REQUEST_FORMAT_DIALOG_VIEW: "Choose export format",
+	RESPONSE_FORMAT_DIALOG_VIEW:     "Choose what to save",
 	SAVE_RESULT_VIEW:                "Save Result (press enter to close)",
+	MESSAGE_VIEW:                    "Info (press enter to close)",
+	PREVIEW_VIEW:                    "Request preview (press enter to close)",
 	METHOD_LIST_VIEW:                "Methods",
 	HELP_VIEW:                       "Help",
+	SERVER_LOG_VIEW:                 "Incoming requests",
+	MULTIPART_VIEW:                  "Multipart form (enter: edit, insert: add text, alt+o: add file, delete: remove)",
+	MULTIPART_PART_DIALOG_VIEW:      "Part: name=value or name=@path, optional ;type=CONTENT-TYPE (ctrl+q to cancel)",
+	FILE_PICKER_VIEW:                "Choose a file (ctrl+q to cancel)",
+	TEMPLATE_FORM_VIEW:              "Fill in the placeholders (enter to submit, ctrl+q to cancel)",
+	PIPE_COMMAND_VIEW:               "Pipe response body through (enter to submit, empty to reset, ctrl+q to cancel)",
+	DUPLICATE_DIALOG_VIEW:           "Name of the copy of the request (enter to submit, ctrl+q to cancel)",
+	NOTES_VIEW:                      "Notes of the request (ctrl+q to close)",
+	USER_AGENT_VIEW:                 "User-Agent (enter to select, ctrl+q to cancel)",
+	RESPONSE_HEADERS_FILTER_VIEW:    "Filter response headers, * matches anything (enter to submit, empty to reset, ctrl+q to cancel)",
+	CONFIRM_VIEW:                    "y: yes, n: no, ctrl+q: cancel",
+	SCHEMA_VIEW:                     "JSON Schema file or URL the responses are validated against (ctrl+q to cancel)",
+	COMPARE_VIEW:                    "Environments the request is sent to and compared (ctrl+q to cancel)",
+	DIFF_VIEW:                       "(press enter to close)",
+	WIRE_LOG_VIEW:                   "Wire log (* events, > sent, < received)",
+	KEYBINDINGS_VIEW:                "Keybindings (enter: change, n: new, ctrl+q: close)",
+	KEYBINDING_EDIT_VIEW:            "category key = command, empty command to unbind (ctrl+q to cancel)",
+	RAW_REQUEST_VIEW:                "Raw request sent as it is, lines end with CRLF (ctrl+r: send, ctrl+q: close)",
+	WORKSPACE_VIEW:                  "(enter: load, r: run with its dependencies, e: next environment, b: next base URL of the group, ctrl+q: close)",
+	EXTRACT_VIEW:                    "JSONPath to copy, or name = JSONPath to set {{name}} (ctrl+q to cancel)",
+	WSDL_DIALOG_VIEW:                "WSDL file or URL (enter to list its operations, ctrl+q to cancel)",
+	WSDL_OPERATIONS_VIEW:            "SOAP operations (enter: fill the request, ctrl+q: close)",
+	TRANSPORT_OPTIONS_VIEW:          "Transport options of the request (enter: change, ctrl+q: close)",
 }
 
 type position struct {
@@ -91,6 +157,12 @@ var VIEW_POSITIONS = map[string]viewPosition{
 		position{0.3, 0},
 		position{0.5, 1},
 	},
+	AUTH_VIEW: {
+		position{0.0, 0},
+		position{0.25, 2},
+		position{0.3, 0},
+		position{0.5, 1},
+	},
 	REQUEST_HEADERS_VIEW: {
 		position{0.0, 0},
 		position{0.5, 1},
@@ -180,18 +252,28 @@ var VIEW_PROPERTIES = map[string]viewProperties{
 		text:     DEFAULT_METHOD,
 	},
 	REQUEST_DATA_VIEW: {
-		title:    "Request data (POST/PUT/PATCH)",
+		title:    "Request data (POST/PUT/PATCH) - alt+a: auth",
+		frame:    true,
+		editable: true,
+		wrap:     false,
+		editor: &AutocompleteEditor{&defaultEditor, func(v *gocui.View, str string) []string {
+			return defaultEditor.app.graphQLCompletions(v, str)
+		}, []string{}, false},
+	},
+	AUTH_VIEW: {
+		title:    "Auth - alt+t: change type, alt+a: request data",
 		frame:    true,
 		editable: true,
 		wrap:     false,
 		editor:   &defaultEditor,
+		text:     DEFAULT_AUTH,
 	},
 	REQUEST_HEADERS_VIEW: {
 		title:    "Request headers",
 		frame:    true,
 		editable: true,
 		wrap:     false,
-		editor: &AutocompleteEditor{&defaultEditor, func(str string) []string {
+		editor: &AutocompleteEditor{&defaultEditor, func(_ *gocui.View, str string) []string {
 			return completeFromSlice(str, REQUEST_HEADERS)
 		}, []string{}, false},
 	},
@@ -266,6 +348,9 @@ const (
 	MIN_HEIGHT = 20
 )
 
+// SEARCH_DEBOUNCE is the pause in typing after which the search is run
+const SEARCH_DEBOUNCE = 150 * time.Millisecond
+
 type ViewEditor struct {
 	app           *App
 	g             *gocui.Gui
@@ -275,7 +360,7 @@ type ViewEditor struct {
 
 type AutocompleteEditor struct {
 	wuzzEditor         *ViewEditor
-	completions        func(string) []string
+	completions        func(*gocui.View, string) []string
 	currentCompletions []string
 	isAutocompleting   bool
 }
@@ -316,10 +401,12 @@ func (e *ViewEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modif
 		}
 	}
 
+	before := captureEditState(v)
 	e.origEditor.Edit(v, key, ch, mod)
+	e.app.recordEdit(v, before, ch != 0 && mod == gocui.ModNone && !unicode.IsSpace(ch))
 }
 
-var symbolPattern = regexp.MustCompile("[a-zA-Z0-9-]+$")
+var symbolPattern = regexp.MustCompile("[a-zA-Z0-9_-]+$")
 
 func getLastSymbol(str string) string {
 	return symbolPattern.FindString(str)
@@ -378,7 +465,7 @@ func (e *AutocompleteEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod goc
 	closeAutocomplete(e.wuzzEditor.g)
 	e.isAutocompleting = false
 
-	completions := e.completions(lastSymbol)
+	completions := e.completions(v, lastSymbol)
 	e.currentCompletions = completions
 
 	cx, cy = v.Cursor()
@@ -406,9 +493,14 @@ func (e *AutocompleteEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod goc
 
 func (e *SearchEditor) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
 	e.wuzzEditor.Edit(v, key, ch, mod)
-	e.wuzzEditor.g.Update(func(g *gocui.Gui) error {
-		e.wuzzEditor.app.PrintBody(g)
-		return nil
+	a := e.wuzzEditor.app
+	a.searchJumped = false
+	// search once typing pauses, printBody cancels the previous search
+	if a.searchTimer != nil {
+		a.searchTimer.Stop()
+	}
+	a.searchTimer = time.AfterFunc(SEARCH_DEBOUNCE, func() {
+		a.PrintBody(e.wuzzEditor.g)
 	})
 }
 
@@ -481,6 +573,8 @@ func setViewProperties(v *gocui.View, name string) {
 
 func (a *App) Layout(g *gocui.Gui) error {
 	maxX, maxY := g.Size()
+	resized := a.terminalSize != [2]int{} && a.terminalSize != [2]int{maxX, maxY}
+	a.terminalSize = [2]int{maxX, maxY}
 
 	if maxX < MIN_WIDTH || maxY < MIN_HEIGHT {
 		if v, err := setView(g, ERROR_VIEW); err != nil {
@@ -516,6 +610,7 @@ func (a *App) Layout(g *gocui.Gui) error {
 		URL_VIEW,
 		URL_PARAMS_VIEW,
 		REQUEST_METHOD_VIEW,
+		AUTH_VIEW,
 		REQUEST_DATA_VIEW,
 		REQUEST_HEADERS_VIEW,
 		RESPONSE_HEADERS_VIEW,
@@ -531,7 +626,14 @@ func (a *App) Layout(g *gocui.Gui) error {
 			setViewProperties(v, name)
 		}
 	}
+	if err := a.layoutStatusFrames(g); err != nil {
+		return err
+	}
+	a.checkHostOnBlur(g)
 	refreshStatusLine(a, g)
+	if resized {
+		a.reflow(g, maxX, maxY)
+	}
 
 	return nil
 }
@@ -553,6 +655,9 @@ func (a *App) setView(g *gocui.Gui) error {
 }
 
 func (a *App) setViewByName(g *gocui.Gui, name string) error {
+	if name == REQUEST_DATA_VIEW || name == AUTH_VIEW {
+		return a.showRequestTab(g, name)
+	}
 	for i, v := range VIEWS {
 		if v == name {
 			a.viewIndex = i
@@ -572,13 +677,16 @@ func popup(g *gocui.Gui, msg string) {
 	p.text = msg
 	VIEW_PROPERTIES[POPUP_VIEW] = p
 
-	if v, err := setView(g, POPUP_VIEW); err != nil {
+	v, err := setView(g, POPUP_VIEW)
+	if err != nil {
 		if err != gocui.ErrUnknownView {
 			return
 		}
 		setViewProperties(v, POPUP_VIEW)
 		g.SetViewOnTop(POPUP_VIEW)
+		return
 	}
+	setViewTextAndCursor(v, msg)
 }
 
 func closeAutocomplete(g *gocui.Gui) {
@@ -623,6 +731,17 @@ func showAutocomplete(completions []string, left, top, maxWidth, maxHeight int,
 	}
 }
 
+// statusColor returns the ANSI color code of a response status class
+func statusColor(code int) int {
+	switch {
+	case code >= 200 && code < 300:
+		return 32
+	case code >= 300 && code < 400:
+		return 33
+	}
+	return 31
+}
+
 func writeSortedHeaders(output io.Writer, h http.Header) {
 	hkeys := make([]string, 0, len(h))
 	for hname := range h {
@@ -632,11 +751,33 @@ func writeSortedHeaders(output io.Writer, h http.Header) {
 	sort.Strings(hkeys)
 
 	for _, hname := range hkeys {
-		fmt.Fprintf(output, "\x1b[0;33m%v:\x1b[0;0m %v\n", hname, strings.Join(h[hname], ","))
+		for _, hvalue := range h[hname] {
+			fmt.Fprintf(output, "\x1b[0;33m%v:\x1b[0;0m %v\n", hname, hvalue)
+		}
 	}
 }
 
+// contextWriter fails the writes once ctx is done, so that the formatting
+// of a replaced body stops
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
 func (a *App) PrintBody(g *gocui.Gui) {
+	a.printBody(g, nil)
+}
+
+// printBody formats the response body in the background, the previous
+// formatting is cancelled. done is called once the body is displayed.
+func (a *App) printBody(g *gocui.Gui, done func(v *gocui.View)) {
 	g.Update(func(g *gocui.Gui) error {
 		if len(a.history) == 0 {
 			return nil
@@ -645,44 +786,104 @@ func (a *App) PrintBody(g *gocui.Gui) {
 		if req.RawResponseBody == nil {
 			return nil
 		}
+		if a.cancelFormat != nil {
+			a.cancelFormat()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		a.cancelFormat = cancel
+
 		vrb, _ := g.View(RESPONSE_BODY_VIEW)
-		vrb.Clear()
 
 		var responseFormatter formatter.ResponseFormatter
 		responseFormatter = req.Formatter
+		formatterTitle := responseFormatter.Title()
+		if a.rawResponse {
+			responseFormatter = DEFAULT_FORMATTER
+			formatterTitle = "[raw]"
+		}
 
-		vrb.Title = VIEW_PROPERTIES[vrb.Name()].title + " " + responseFormatter.Title()
+		title := VIEW_PROPERTIES[vrb.Name()].title + " " + formatterTitle
+		vrb.Title = title + " formatting…"
 
+		index, searchIndex := req.bodyIndexFor(responseFormatter), req.index
 		search_text := getViewValue(g, "search")
-		if search_text == "" || !responseFormatter.Searchable() {
-			err := responseFormatter.Format(vrb, req.RawResponseBody)
-			if err != nil {
-				fmt.Fprintf(vrb, "Error: cannot decode response body: %v", err)
-				return nil
-			}
-			if _, err := vrb.Line(0); !a.config.General.PreserveScrollPosition || err != nil {
-				vrb.SetOrigin(0, 0)
-			}
-			return nil
-		}
-		if !a.config.General.ContextSpecificSearch {
+		searching := search_text != "" && !a.searchJumped && responseFormatter.Searchable()
+		if searching && !a.config.General.ContextSpecificSearch {
 			responseFormatter = DEFAULT_FORMATTER
 		}
-		vrb.SetOrigin(0, 0)
-		results, err := responseFormatter.Search(search_text, req.RawResponseBody)
-		if err != nil {
-			fmt.Fprint(vrb, "Search error: ", err)
-			return nil
-		}
-		if len(results) == 0 {
-			vrb.Title = "No results"
-			fmt.Fprint(vrb, "Error: no results")
-			return nil
-		}
-		vrb.Title = fmt.Sprintf("%d results", len(results))
-		for _, result := range results {
-			fmt.Fprintf(vrb, "-----\n%s\n", result)
+		capture := searching && a.captureSearch
+		limit := a.bodyLimit
+		body := req.RawResponseBody
+		pipeCommand := a.pipeCommand
+		if pipeCommand != "" && !searching {
+			title = VIEW_PROPERTIES[vrb.Name()].title + " | " + pipeCommand
 		}
+
+		go func() {
+			out := &bytes.Buffer{}
+			if pipeCommand != "" && !searching {
+				output, err := pipeBody(ctx, pipeCommand, body)
+				if err != nil {
+					fmt.Fprintf(out, "Error: %v", err)
+				} else {
+					writeLimitedBody(out, output, limit)
+				}
+			} else if !searching && index != nil {
+				writeLimitedBody(out, index.formatted, limit)
+			} else if !searching {
+				formatted := &bytes.Buffer{}
+				err := responseFormatter.Format(&contextWriter{ctx, formatted}, body)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					LOGGER.Error("cannot format response body", "formatter", formatterTitle, "url", req.fullURL(), "error", err)
+					fmt.Fprintf(out, "Error: cannot decode response body: %v", err)
+				} else {
+					writeLimitedBody(out, formatted.Bytes(), limit)
+					if formatted.Len() >= BODY_INDEX_MIN_SIZE && responseFormatter.Searchable() && ctx.Err() == nil {
+						index = newBodyIndex(responseFormatter, formatted.Bytes())
+					}
+				}
+			} else if capture {
+				title = writeCapturedValues(ctx, out, search_text, body)
+			} else if results, err := searchBody(ctx, searchIndex, responseFormatter, search_text, body); err != nil {
+				fmt.Fprint(out, "Search error: ", err)
+			} else if len(results) == 0 {
+				title = "No results"
+				fmt.Fprint(out, "Error: no results")
+			} else {
+				title = fmt.Sprintf("%d results", len(results))
+				for _, result := range results {
+					if ctx.Err() != nil {
+						return
+					}
+					fmt.Fprintf(out, "-----\n%s\n", result)
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			g.Update(func(g *gocui.Gui) error {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if index != nil {
+					req.index = index
+				}
+				vrb.Clear()
+				vrb.Title = title
+				vrb.Write(out.Bytes())
+				if _, err := vrb.Line(0); searching || !a.config.General.PreserveScrollPosition || err != nil {
+					vrb.SetOrigin(0, 0)
+				}
+				if done != nil {
+					done(vrb)
+				}
+				return nil
+			})
+		}()
 		return nil
 	})
 }
@@ -735,63 +936,34 @@ func (a *App) setKey(g *gocui.Gui, keyStr, commandStr, viewName string) error {
 	return nil
 }
 
-func (a *App) printViewKeybindings(v io.Writer, viewName string) {
-	keys, found := a.config.Keys[viewName]
-	if !found {
-		return
-	}
-	mk := make([]string, len(keys))
-	i := 0
-	for k := range keys {
-		mk[i] = k
-		i++
-	}
-	sort.Strings(mk)
-	fmt.Fprintf(v, "\n %v\n", viewName)
-	for _, key := range mk {
-		fmt.Fprintf(v, "  %-15v %v\n", key, keys[key])
-	}
-}
-
 func (a *App) SetKeys(g *gocui.Gui) error {
-	// load config keybindings
+	// load config keybindings, the invalid ones are listed once started and
+	// can be fixed in the keybinding editor
+	var invalid []string
 	for viewName, keys := range a.config.Keys {
+		category := viewName
 		if viewName == "global" {
 			viewName = ALL_VIEWS
 		}
 		for keyStr, commandStr := range keys {
 			if err := a.setKey(g, keyStr, commandStr, viewName); err != nil {
-				return err
+				invalid = append(invalid, fmt.Sprintf("%v %v: %v", category, keyStr, err))
 			}
 		}
 	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		g.Update(func(g *gocui.Gui) error {
+			return a.OpenMessageView("Invalid keybindings:\n"+strings.Join(invalid, "\n")+"\n\nThe keybindings command (ctrl+b by default) opens the keybinding editor", g)
+		})
+	}
 
-	g.SetKeybinding(ALL_VIEWS, gocui.KeyF1, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		if a.currentPopup == HELP_VIEW {
-			a.closePopup(g, HELP_VIEW)
-			return nil
-		}
-
-		help, err := a.CreatePopupView(HELP_VIEW, 60, 40, g)
-		if err != nil {
-			return err
-		}
-		help.Title = VIEW_TITLES[HELP_VIEW]
-		help.Highlight = false
-		fmt.Fprint(help, "Keybindings:\n")
-		a.printViewKeybindings(help, "global")
-		for _, viewName := range VIEWS {
-			if _, found := a.config.Keys[viewName]; !found {
-				continue
-			}
-			a.printViewKeybindings(help, viewName)
-		}
-		g.SetViewOnTop(HELP_VIEW)
-		g.SetCurrentView(HELP_VIEW)
-		return nil
-	})
+	g.SetKeybinding(ALL_VIEWS, gocui.KeyF1, gocui.ModNone, a.ToggleHelp)
 
 	g.SetKeybinding(ALL_VIEWS, gocui.MouseRelease, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if parent, found := frameStripParent(v.Name()); found {
+			v, _ = g.View(parent)
+		}
 		if g.CurrentView() != v {
 			g.SetCurrentView(v.Name())
 			v.SetCursor(0, 0)
@@ -821,15 +993,18 @@ func (a *App) SetKeys(g *gocui.Gui) error {
 		return nil
 	}
 	// history key bindings
-	g.SetKeybinding(HISTORY_VIEW, gocui.KeyArrowDown, gocui.ModNone, cursDown)
-	g.SetKeybinding(HISTORY_VIEW, gocui.KeyArrowUp, gocui.ModNone, cursUp)
+	g.SetKeybinding(HISTORY_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.historyEntries))
+	})
+	g.SetKeybinding(HISTORY_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.historyEntries))
+	})
 	g.SetKeybinding(HISTORY_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		_, cy := v.Cursor()
-		// TODO error
-		if len(a.history) <= cy {
+		idx, found := a.selectedHistoryEntry(v)
+		if !found {
 			return nil
 		}
-		a.restoreRequest(g, cy)
+		a.restoreRequest(g, idx)
 		return nil
 	})
 
@@ -864,22 +1039,199 @@ func (a *App) SetKeys(g *gocui.Gui) error {
 	})
 	g.SetKeybinding(SAVE_REQUEST_FORMAT_DIALOG_VIEW, gocui.KeyArrowDown, gocui.ModNone, cursDown)
 	g.SetKeybinding(SAVE_REQUEST_FORMAT_DIALOG_VIEW, gocui.KeyArrowUp, gocui.ModNone, cursUp)
+	g.SetKeybinding(RESPONSE_FORMAT_DIALOG_VIEW, gocui.KeyArrowDown, gocui.ModNone, cursDown)
+	g.SetKeybinding(RESPONSE_FORMAT_DIALOG_VIEW, gocui.KeyArrowUp, gocui.ModNone, cursUp)
 
 	g.SetKeybinding(SAVE_DIALOG_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		a.closePopup(g, SAVE_DIALOG_VIEW)
 		return nil
 	})
 
+	g.SetKeybinding(TEMPLATE_FORM_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, TEMPLATE_FORM_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(CONFIRM_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, CONFIRM_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(WORKSPACE_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.workspace.Requests))
+	})
+	g.SetKeybinding(WORKSPACE_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.workspace.Requests))
+	})
+	g.SetKeybinding(WORKSPACE_VIEW, gocui.KeyEnter, gocui.ModNone, a.loadWorkspaceRequest)
+	g.SetKeybinding(WORKSPACE_VIEW, 'e', gocui.ModNone, a.nextEnvironment)
+	g.SetKeybinding(WORKSPACE_VIEW, 'b', gocui.ModNone, a.nextGroupBaseURL)
+	g.SetKeybinding(WORKSPACE_VIEW, 'r', gocui.ModNone, a.runWorkspaceRequest)
+	g.SetKeybinding(WORKSPACE_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, WORKSPACE_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(WSDL_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitWSDL)
+	g.SetKeybinding(WSDL_DIALOG_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, WSDL_DIALOG_VIEW)
+		return nil
+	})
+	g.SetKeybinding(WSDL_OPERATIONS_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.wsdlOperations))
+	})
+	g.SetKeybinding(WSDL_OPERATIONS_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.wsdlOperations))
+	})
+	g.SetKeybinding(WSDL_OPERATIONS_VIEW, gocui.KeyEnter, gocui.ModNone, a.selectWSDLOperation)
+	g.SetKeybinding(WSDL_OPERATIONS_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, WSDL_OPERATIONS_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(TRANSPORT_OPTIONS_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.transport.lines()))
+	})
+	g.SetKeybinding(TRANSPORT_OPTIONS_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.transport.lines()))
+	})
+	g.SetKeybinding(TRANSPORT_OPTIONS_VIEW, gocui.KeyEnter, gocui.ModNone, a.nextTransportOption)
+	g.SetKeybinding(TRANSPORT_OPTIONS_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, TRANSPORT_OPTIONS_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(KEYBINDINGS_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.keyBindingList))
+	})
+	g.SetKeybinding(KEYBINDINGS_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.keyBindingList))
+	})
+	g.SetKeybinding(KEYBINDINGS_VIEW, gocui.KeyEnter, gocui.ModNone, a.editSelectedKeyBinding)
+	g.SetKeybinding(KEYBINDINGS_VIEW, 'n', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return a.openKeyBindingEditor(g, nil)
+	})
+	g.SetKeybinding(KEYBINDINGS_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, KEYBINDINGS_VIEW)
+		return nil
+	})
+	g.SetKeybinding(KEYBINDING_EDIT_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitKeyBinding)
+	g.SetKeybinding(KEYBINDING_EDIT_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, KEYBINDING_EDIT_VIEW)
+		return nil
+	})
+	g.SetKeybinding(COMPARE_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitCompare)
+	g.SetKeybinding(COMPARE_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, COMPARE_VIEW)
+		return nil
+	})
+	g.SetKeybinding(DIFF_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, DIFF_VIEW)
+		return nil
+	})
+	g.SetKeybinding(RAW_REQUEST_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, RAW_REQUEST_VIEW)
+		return nil
+	})
+	g.SetKeybinding(SCHEMA_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitSchema)
+	g.SetKeybinding(SCHEMA_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, SCHEMA_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(EXTRACT_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitExtract)
+	g.SetKeybinding(EXTRACT_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, EXTRACT_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(PIPE_COMMAND_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitPipeCommand)
+	g.SetKeybinding(PIPE_COMMAND_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, PIPE_COMMAND_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(RESPONSE_HEADERS_FILTER_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitResponseHeadersFilter)
+	g.SetKeybinding(RESPONSE_HEADERS_FILTER_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, RESPONSE_HEADERS_FILTER_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(DUPLICATE_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone, a.submitDuplicate)
+	g.SetKeybinding(DUPLICATE_DIALOG_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, DUPLICATE_DIALOG_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(NOTES_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return a.closeNotes(g)
+	})
+
+	g.SetKeybinding(USER_AGENT_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(USER_AGENT_PRESETS))
+	})
+	g.SetKeybinding(USER_AGENT_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(USER_AGENT_PRESETS))
+	})
+	g.SetKeybinding(USER_AGENT_VIEW, gocui.KeyEnter, gocui.ModNone, a.selectUserAgent)
+	g.SetKeybinding(USER_AGENT_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, USER_AGENT_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(SEARCH_MATCHES_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.searchMatches))
+	})
+	g.SetKeybinding(SEARCH_MATCHES_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.searchMatches))
+	})
+	g.SetKeybinding(SEARCH_MATCHES_VIEW, gocui.KeyEnter, gocui.ModNone, a.showSearchMatch)
+	g.SetKeybinding(SEARCH_MATCHES_VIEW, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, SEARCH_MATCHES_VIEW)
+		return nil
+	})
+
+	// multipart editor key bindings
+	g.SetKeybinding(MULTIPART_VIEW, gocui.KeyArrowDown, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, 1, len(a.multipartParts))
+	})
+	g.SetKeybinding(MULTIPART_VIEW, gocui.KeyArrowUp, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		return moveListCursor(v, -1, len(a.multipartParts))
+	})
+	for _, name := range []string{MULTIPART_VIEW, MULTIPART_PART_DIALOG_VIEW, FILE_PICKER_VIEW} {
+		g.SetKeybinding(name, gocui.KeyCtrlQ, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			a.closePopup(g, v.Name())
+			if v.Name() != MULTIPART_VIEW {
+				return a.openMultipartEditor(g, 0)
+			}
+			return nil
+		})
+	}
+
 	g.SetKeybinding(SAVE_RESULT_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		a.closePopup(g, SAVE_RESULT_VIEW)
 		return nil
 	})
+
+	g.SetKeybinding(MESSAGE_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, MESSAGE_VIEW)
+		return nil
+	})
+
+	g.SetKeybinding(PREVIEW_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		a.closePopup(g, PREVIEW_VIEW)
+		return nil
+	})
 	return nil
 }
 
 func (a *App) closePopup(g *gocui.Gui, viewname string) {
-	_, err := g.View(viewname)
+	v, err := g.View(viewname)
 	if err == nil {
+		// the notes are kept however their popup is closed
+		if viewname == NOTES_VIEW {
+			a.saveNotes(v)
+		}
 		a.currentPopup = ""
 		g.DeleteView(viewname)
 		g.SetCurrentView(VIEWS[a.viewIndex%len(VIEWS)])
@@ -887,6 +1239,43 @@ func (a *App) closePopup(g *gocui.Gui, viewname string) {
 	}
 }
 
+// popupPosition returns the coordinates of a popup in a terminal of the
+// given size
+type popupPosition func(maxX, maxY int) (x0, y0, x1, y1 int)
+
+// centeredPopupPosition centers a popup of width and height, those filling
+// the terminal of size maxX, maxY when created follow its size
+func centeredPopupPosition(width, height, maxX, maxY int) popupPosition {
+	fullWidth, fullHeight := width >= maxX-4, height >= maxY-4
+	return func(maxX, maxY int) (int, int, int, int) {
+		w, h := width, height
+		if fullWidth || w > maxX-4 {
+			w = maxX - 4
+		}
+		if fullHeight || h > maxY-4 {
+			h = maxY - 4
+		}
+		return maxX/2 - w/2 - 1, maxY/2 - h/2 - 1, maxX/2 + w/2, maxY/2 + h/2 + 1
+	}
+}
+
+// reflow places the popup again once the terminal is resized and wraps
+// the lines of the views again, gocui only wraps them when they are written
+func (a *App) reflow(g *gocui.Gui, maxX, maxY int) {
+	if a.currentPopup != "" && a.popupPosition != nil {
+		if _, err := g.View(a.currentPopup); err == nil {
+			x0, y0, x1, y1 := a.popupPosition(maxX, maxY)
+			g.SetView(a.currentPopup, x0, y0, x1, y1)
+		}
+	}
+	for _, v := range g.Views() {
+		if v.Wrap {
+			// an empty write marks the content as changed
+			v.Write(nil)
+		}
+	}
+}
+
 // CreatePopupView create a popup like view
 func (a *App) CreatePopupView(name string, width, height int, g *gocui.Gui) (v *gocui.View, err error) {
 	// Remove any concurrent popup
@@ -894,13 +1283,9 @@ func (a *App) CreatePopupView(name string, width, height int, g *gocui.Gui) (v *
 
 	g.Cursor = false
 	maxX, maxY := g.Size()
-	if height > maxY-4 {
-		height = maxY - 4
-	}
-	if width > maxX-4 {
-		width = maxX - 4
-	}
-	v, err = g.SetView(name, maxX/2-width/2-1, maxY/2-height/2-1, maxX/2+width/2, maxY/2+height/2+1)
+	a.popupPosition = centeredPopupPosition(width, height, maxX, maxY)
+	x0, y0, x1, y1 := a.popupPosition(maxX, maxY)
+	v, err = g.SetView(name, x0, y0, x1, y1)
 	if err != nil && err != gocui.ErrUnknownView {
 		return
 	}
@@ -921,7 +1306,7 @@ func (a *App) ToggleHistory(g *gocui.Gui, _ *gocui.View) (err error) {
 		return
 	}
 
-	history, err := a.CreatePopupView(HISTORY_VIEW, 100, len(a.history), g)
+	history, err := a.CreatePopupView(HISTORY_VIEW, 100, len(a.history)+1, g)
 	if err != nil {
 		return
 	}
@@ -932,22 +1317,142 @@ func (a *App) ToggleHistory(g *gocui.Gui, _ *gocui.View) (err error) {
 		setViewTextAndCursor(history, "[!] No items in history")
 		return
 	}
-	for i, r := range a.history {
-		req_str := fmt.Sprintf("[%02d] %v %v", i, r.Method, r.Url)
-		if r.GetParams != "" {
-			req_str += fmt.Sprintf("?%v", strings.Replace(r.GetParams, "\n", "&", -1))
+	history.Editable = true
+	history.Editor = &historyEditor{a, g}
+	a.historyFilter = ""
+	a.renderHistory(history)
+	g.SetViewOnTop(HISTORY_VIEW)
+	g.SetCurrentView(HISTORY_VIEW)
+	for i, idx := range a.historyEntries {
+		if idx == a.historyIndex {
+			selectListLine(history, i)
 		}
-		if r.Data != "" {
-			req_str += fmt.Sprintf(" %v", strings.Replace(r.Data, "\n", "&", -1))
+	}
+	return
+}
+
+// PreviewRequest shows the request as it would be sent on the wire
+func (a *App) PreviewRequest(g *gocui.Gui, _ *gocui.View) error {
+	// Destroy if present
+	if a.currentPopup == PREVIEW_VIEW {
+		a.closePopup(g, PREVIEW_VIEW)
+		return nil
+	}
+
+	a.rememberURLCredentials(g)
+	r := &Request{}
+	req, err := a.buildRequest(g, r, a.variables, a.apiKey, a.config)
+	if err != nil {
+		return a.OpenMessageView(err.Error(), g)
+	}
+	// streamed bodies are not read, the standard input could be read only once
+	path, streamed := bodyFilePath(r.Data)
+	streamed = streamed && req.ContentLength < 0
+	if streamed {
+		defer req.Body.Close()
+	}
+	compressed := a.config.General.GzipRequestBody && req.Body != nil && req.Body != http.NoBody && req.Header.Get("Content-Encoding") == ""
+	// the compressed body is not displayed, the body as typed is
+	var body []byte
+	if !streamed {
+		if compressed {
+			if body, err = requestBody(req); err != nil {
+				return a.OpenMessageView(err.Error(), g)
+			}
 		}
-		if r.Headers != "" {
-			req_str += fmt.Sprintf(" %v", strings.Replace(r.Headers, "\n", ";", -1))
+		// the digest and signature headers as sent
+		if err := finalizeRequest(r, req, a.config); err != nil {
+			return a.OpenMessageView(err.Error(), g)
 		}
-		fmt.Fprintln(history, req_str)
 	}
-	g.SetViewOnTop(HISTORY_VIEW)
-	g.SetCurrentView(HISTORY_VIEW)
-	history.SetCursor(0, a.historyIndex)
+	dump, err := httputil.DumpRequestOut(req, !streamed && !compressed)
+	if err != nil {
+		return a.OpenMessageView(err.Error(), g)
+	}
+	// gocui treats \r as a line reset
+	text := strings.ReplaceAll(string(dump)+string(body), "\r\n", "\n")
+	if streamed {
+		text += "[body streamed from " + path + ", its digest and signature headers are computed when sent]\n"
+	}
+	if compressed {
+		text += "\n[body sent gzip compressed with Content-Encoding: gzip]\n"
+	}
+
+	maxX, maxY := g.Size()
+	preview, err := a.CreatePopupView(PREVIEW_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	preview.Title = VIEW_TITLES[PREVIEW_VIEW]
+	preview.Highlight = false
+	preview.Wrap = true
+	fmt.Fprint(preview, text)
+	g.SetViewOnTop(PREVIEW_VIEW)
+	g.SetCurrentView(PREVIEW_VIEW)
+	return nil
+}
+
+func (a *App) SaveResponse(g *gocui.Gui, _ *gocui.View) (err error) {
+	// Destroy if present
+	if a.currentPopup == RESPONSE_FORMAT_DIALOG_VIEW {
+		a.closePopup(g, RESPONSE_FORMAT_DIALOG_VIEW)
+		return
+	}
+	if len(a.history) == 0 || a.history[a.historyIndex].RawResponseBody == nil {
+		return
+	}
+	// Create the view listing the possible formats
+	popup, err := a.CreatePopupView(RESPONSE_FORMAT_DIALOG_VIEW, 30, len(RESPONSE_EXPORT_FORMATS), g)
+	if err != nil {
+		return err
+	}
+
+	popup.Title = VIEW_TITLES[RESPONSE_FORMAT_DIALOG_VIEW]
+
+	for _, f := range RESPONSE_EXPORT_FORMATS {
+		fmt.Fprintln(popup, f.name)
+	}
+
+	g.SetViewOnTop(RESPONSE_FORMAT_DIALOG_VIEW)
+	g.SetCurrentView(RESPONSE_FORMAT_DIALOG_VIEW)
+	popup.SetCursor(0, 0)
+
+	g.DeleteKeybinding(RESPONSE_FORMAT_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone)
+	g.SetKeybinding(RESPONSE_FORMAT_DIALOG_VIEW, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		_, format := v.Cursor()
+		if format >= len(RESPONSE_EXPORT_FORMATS) {
+			return nil
+		}
+		filename := ""
+		if len(a.history) > 0 {
+			filename = suggestedFilename(a.history[a.historyIndex])
+			if ext := RESPONSE_EXPORT_FORMATS[format].extension; ext != "" {
+				filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + ext
+			}
+		}
+		return a.OpenSaveDialog(VIEW_TITLES[SAVE_RESPONSE_DIALOG_VIEW], filename, g,
+			func(g *gocui.Gui, _ *gocui.View) error {
+				saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
+
+				if len(a.history) == 0 {
+					return nil
+				}
+				req := a.history[a.historyIndex]
+				if req.RawResponseBody == nil {
+					return nil
+				}
+
+				err := os.WriteFile(saveLocation, RESPONSE_EXPORT_FORMATS[format].export(req), 0o644)
+
+				var saveResult string
+				if err == nil {
+					saveResult = "Response saved successfully."
+				} else {
+					saveResult = "Error saving response: " + err.Error()
+				}
+				return a.OpenSaveResultView(saveResult, g)
+			})
+	})
 	return
 }
 
@@ -980,7 +1485,7 @@ func (a *App) SaveRequest(g *gocui.Gui, _ *gocui.View) (err error) {
 		// Save the format index
 		_, format := v.Cursor()
 		// Open the Save popup
-		return a.OpenSaveDialog(VIEW_TITLES[SAVE_REQUEST_DIALOG_VIEW], g,
+		return a.OpenSaveDialog(VIEW_TITLES[SAVE_REQUEST_DIALOG_VIEW], "", g,
 			func(g *gocui.Gui, _ *gocui.View) error {
 				defer a.closePopup(g, SAVE_DIALOG_VIEW)
 				saveLocation := getViewValue(g, SAVE_DIALOG_VIEW)
@@ -991,6 +1496,9 @@ func (a *App) SaveRequest(g *gocui.Gui, _ *gocui.View) (err error) {
 					GetParams: getViewValue(g, URL_PARAMS_VIEW),
 					Data:      getViewValue(g, REQUEST_DATA_VIEW),
 					Headers:   getViewValue(g, REQUEST_HEADERS_VIEW),
+					Auth:      getViewValue(g, AUTH_VIEW),
+					Notes:     a.notes,
+					Transport: a.transport,
 				}
 
 				// Export the request using the chosent format
@@ -1039,7 +1547,9 @@ func (a *App) ToggleMethodList(g *gocui.Gui, _ *gocui.View) (err error) {
 	return
 }
 
-func (a *App) OpenSaveDialog(title string, g *gocui.Gui, save func(g *gocui.Gui, v *gocui.View) error) error {
+// OpenSaveDialog prompts for a path in the current directory, prefilled with
+// filename
+func (a *App) OpenSaveDialog(title, filename string, g *gocui.Gui, save func(g *gocui.Gui, v *gocui.View) error) error {
 	dialog, err := a.CreatePopupView(SAVE_DIALOG_VIEW, 60, 1, g)
 	if err != nil {
 		return err
@@ -1056,7 +1566,7 @@ func (a *App) OpenSaveDialog(title string, g *gocui.Gui, save func(g *gocui.Gui,
 	}
 	currentDir += "/"
 
-	setViewTextAndCursor(dialog, currentDir)
+	setViewTextAndCursor(dialog, currentDir+filename)
 
 	g.SetViewOnTop(SAVE_DIALOG_VIEW)
 	g.SetCurrentView(SAVE_DIALOG_VIEW)
@@ -1067,24 +1577,57 @@ func (a *App) OpenSaveDialog(title string, g *gocui.Gui, save func(g *gocui.Gui,
 }
 
 func (a *App) OpenSaveResultView(saveResult string, g *gocui.Gui) (err error) {
-	popupTitle := VIEW_TITLES[SAVE_RESULT_VIEW]
-	saveResHeight := 1
-	saveResWidth := len(saveResult) + 1
-	if len(popupTitle)+2 > saveResWidth {
-		saveResWidth = len(popupTitle) + 2
+	return a.openResultView(SAVE_RESULT_VIEW, VIEW_TITLES[SAVE_RESULT_VIEW], saveResult, g)
+}
+
+// OpenMessageView shows msg in a popup which is closed by pressing enter
+func (a *App) OpenMessageView(msg string, g *gocui.Gui) error {
+	return a.openResultView(MESSAGE_VIEW, VIEW_TITLES[MESSAGE_VIEW], msg, g)
+}
+
+// OpenConfirmView asks the question msg, answer is called once it is
+// answered with y or n
+func (a *App) OpenConfirmView(msg string, g *gocui.Gui, answer func(g *gocui.Gui, yes bool) error) error {
+	if err := a.openResultView(CONFIRM_VIEW, VIEW_TITLES[CONFIRM_VIEW], msg, g); err != nil {
+		return err
+	}
+	for key, yes := range map[rune]bool{'y': true, 'n': false} {
+		yes := yes
+		g.DeleteKeybinding(CONFIRM_VIEW, key, gocui.ModNone)
+		g.SetKeybinding(CONFIRM_VIEW, key, gocui.ModNone, func(g *gocui.Gui, _ *gocui.View) error {
+			a.closePopup(g, CONFIRM_VIEW)
+			return answer(g, yes)
+		})
+	}
+	return nil
+}
+
+func (a *App) openResultView(name, popupTitle, msg string, g *gocui.Gui) (err error) {
+	lines := strings.Split(msg, "\n")
+	resHeight := 0
+	resWidth := len(popupTitle) + 2
+	for _, l := range lines {
+		if len(l)+1 > resWidth {
+			resWidth = len(l) + 1
+		}
 	}
 	maxX, _ := g.Size()
-	if saveResWidth > maxX {
-		saveResHeight = saveResWidth/maxX + 1
-		saveResWidth = maxX
+	for _, l := range lines {
+		resHeight += len(l)/maxX + 1
+	}
+	if resWidth > maxX {
+		resWidth = maxX
 	}
 
-	saveResultPopup, err := a.CreatePopupView(SAVE_RESULT_VIEW, saveResWidth, saveResHeight, g)
-	saveResultPopup.Title = popupTitle
-	setViewTextAndCursor(saveResultPopup, saveResult)
-	g.SetViewOnTop(SAVE_RESULT_VIEW)
-	g.SetCurrentView(SAVE_RESULT_VIEW)
-	return err
+	resultPopup, err := a.CreatePopupView(name, resWidth, resHeight, g)
+	if err != nil {
+		return err
+	}
+	resultPopup.Title = popupTitle
+	setViewTextAndCursor(resultPopup, msg)
+	g.SetViewOnTop(name)
+	g.SetCurrentView(name)
+	return nil
 }
 
 func (a *App) restoreRequest(g *gocui.Gui, idx int) {
@@ -1093,6 +1636,8 @@ func (a *App) restoreRequest(g *gocui.Gui, idx int) {
 	}
 	a.closePopup(g, HISTORY_VIEW)
 	a.historyIndex = idx
+	a.searchJumped = false
+	a.resetBodyLimit()
 	r := a.history[idx]
 
 	v, _ := g.View(URL_VIEW)
@@ -1110,15 +1655,29 @@ func (a *App) restoreRequest(g *gocui.Gui, idx int) {
 	v, _ = g.View(REQUEST_HEADERS_VIEW)
 	setViewTextAndCursor(v, r.Headers)
 
+	v, _ = g.View(AUTH_VIEW)
+	if r.Auth != "" {
+		setViewTextAndCursor(v, r.Auth)
+	} else {
+		setViewTextAndCursor(v, DEFAULT_AUTH)
+	}
+
+	a.notes = r.Notes
+	a.transport = r.Transport
+
 	v, _ = g.View(RESPONSE_HEADERS_VIEW)
-	setViewTextAndCursor(v, r.ResponseHeaders)
+	setViewTextAndCursor(v, a.responseHeadersText(r))
 
 	a.PrintBody(g)
 }
 
 func refreshStatusLine(a *App, g *gocui.Gui) {
 	sv, _ := g.View(STATUSLINE_VIEW)
-	a.statusLine.Update(sv, a)
+	focused := ""
+	if v := g.CurrentView(); v != nil {
+		focused = v.Name()
+	}
+	a.statusLine.Update(sv, a, focused)
 }
 
 func initApp(a *App, g *gocui.Gui) {
@@ -1155,3 +1714,10 @@ func minInt(x, y int) int {
 	}
 	return y
 }
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestCenteredPopupPosition(t *testing.T) {
+	// a 40x10 popup keeps its size, a full screen one follows the terminal
+	small := centeredPopupPosition(40, 10, 100, 50)
+	full := centeredPopupPosition(100, 50, 100, 50)
+	for _, test := range []struct {
+		position       popupPosition
+		maxX, maxY     int
+		x0, y0, x1, y1 int
+	}{
+		{small, 100, 50, 29, 19, 70, 31},
+		{small, 160, 60, 59, 24, 100, 36},
+		{small, 30, 12, 1, 1, 28, 11},
+		{full, 100, 50, 1, 1, 98, 49},
+		{full, 160, 60, 1, 1, 158, 59},
+	} {
+		x0, y0, x1, y1 := test.position(test.maxX, test.maxY)
+		if x0 != test.x0 || y0 != test.y0 || x1 != test.x1 || y1 != test.y1 {
+			t.Errorf("%vx%v: expected %v,%v %v,%v, got %v,%v %v,%v", test.maxX, test.maxY, test.x0, test.y0, test.x1, test.y1, x0, y0, x1, y1)
+		}
+	}
+}
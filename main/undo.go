@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// UNDO_LIMIT is the maximum number of undo steps kept per view
+const UNDO_LIMIT = 200
+
+// editState is a snapshot of an editable view's content and cursor
+type editState struct {
+	text   string
+	cx, cy int
+	ox, oy int
+}
+
+type undoStack struct {
+	undo []editState
+	redo []editState
+	// true if the last recorded change was a typed word character, in which
+	// case consecutive characters are merged into a single undo step
+	typing bool
+}
+
+func captureEditState(v *gocui.View) editState {
+	cx, cy := v.Cursor()
+	ox, oy := v.Origin()
+	return editState{strings.TrimSuffix(v.Buffer(), "\n"), cx, cy, ox, oy}
+}
+
+func (s editState) apply(v *gocui.View) {
+	v.Clear()
+	fmt.Fprint(v, s.text)
+	v.SetOrigin(s.ox, s.oy)
+	v.SetCursor(s.cx, s.cy)
+}
+
+func (a *App) getUndoStack(name string) *undoStack {
+	if a.undoStacks == nil {
+		a.undoStacks = make(map[string]*undoStack)
+	}
+	s, found := a.undoStacks[name]
+	if !found {
+		s = &undoStack{}
+		a.undoStacks[name] = s
+	}
+	return s
+}
+
+// recordEdit stores before as an undo step of the view if its content was
+// changed. Typing continues the previous step if typing is set.
+func (a *App) recordEdit(v *gocui.View, before editState, typing bool) {
+	after := captureEditState(v)
+	if before.text == after.text {
+		return
+	}
+	s := a.getUndoStack(v.Name())
+	s.redo = s.redo[:0]
+	if typing && s.typing && len(s.undo) > 0 {
+		return
+	}
+	s.typing = typing
+	s.undo = append(s.undo, before)
+	if len(s.undo) > UNDO_LIMIT {
+		s.undo = s.undo[len(s.undo)-UNDO_LIMIT:]
+	}
+}
+
+// undoable wraps a command modifying the current view so that its changes
+// can be undone
+func (a *App) undoable(fn CommandFunc) CommandFunc {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if !isTextEditable(v) {
+			return fn(g, v)
+		}
+		before := captureEditState(v)
+		err := fn(g, v)
+		a.recordEdit(v, before, false)
+		return err
+	}
+}
+
+func (a *App) Undo(_ *gocui.Gui, v *gocui.View) error {
+	if !isTextEditable(v) {
+		return nil
+	}
+	s := a.getUndoStack(v.Name())
+	if len(s.undo) == 0 {
+		return nil
+	}
+	s.redo = append(s.redo, captureEditState(v))
+	s.undo[len(s.undo)-1].apply(v)
+	s.undo = s.undo[:len(s.undo)-1]
+	s.typing = false
+	return nil
+}
+
+func (a *App) Redo(_ *gocui.Gui, v *gocui.View) error {
+	if !isTextEditable(v) {
+		return nil
+	}
+	s := a.getUndoStack(v.Name())
+	if len(s.redo) == 0 {
+		return nil
+	}
+	s.undo = append(s.undo, captureEditState(v))
+	s.redo[len(s.redo)-1].apply(v)
+	s.redo = s.redo[:len(s.redo)-1]
+	s.typing = false
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// USER_AGENT_PRESETS are the User-Agent headers offered by the user agent
+// picker, an empty value removes the header of the headers view
+var USER_AGENT_PRESETS = []struct {
+	name  string
+	value string
+}{
+	{"Default", ""},
+	{"Firefox", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0"},
+	{"Chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36"},
+	{"Safari", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Safari/605.1.15"},
+	{"iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1"},
+	{"Android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Mobile Safari/537.36"},
+	{"curl", "curl/8.10.1"},
+	{"Googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+	{"buzz", "buzz/" + VERSION},
+}
+
+// setHeaderLine replaces the name header lines of the headers view text
+// with a single "name: value" line, or removes them if value is empty.
+// The trailers are left unchanged.
+func setHeaderLine(text, name, value string) string {
+	headers, trailers := splitTrailers(text)
+	var lines []string
+	replaced := false
+	for _, line := range strings.Split(headers, "\n") {
+		lineName, _, found := strings.Cut(line, ":")
+		if found && strings.EqualFold(strings.TrimSpace(lineName), name) {
+			if !replaced && value != "" {
+				lines = append(lines, name+": "+value)
+			}
+			replaced = true
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if !replaced && value != "" {
+		lines = append(lines, name+": "+value)
+	}
+	result := strings.Join(lines, "\n")
+	if text != headers {
+		result += "\n" + TRAILERS_SEPARATOR + "\n" + trailers
+	}
+	return result
+}
+
+// ToggleUserAgentList opens the list of User-Agent presets
+func (a *App) ToggleUserAgentList(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == USER_AGENT_VIEW {
+		a.closePopup(g, USER_AGENT_VIEW)
+		return nil
+	}
+	v, err := a.CreatePopupView(USER_AGENT_VIEW, 80, len(USER_AGENT_PRESETS), g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[USER_AGENT_VIEW]
+	current := ""
+	if h, err := parseRequestHeaders(getViewValue(g, REQUEST_HEADERS_VIEW)); err == nil {
+		current = h.Get("User-Agent")
+	}
+	selected := 0
+	for i, preset := range USER_AGENT_PRESETS {
+		value := preset.value
+		if value == "" {
+			value = "(no header"
+			if a.config.General.UserAgent != "" {
+				value += ", sends " + a.config.General.UserAgent
+			}
+			value += ")"
+		}
+		fmt.Fprintf(v, "%-10v %v\n", preset.name, value)
+		if preset.value == current {
+			selected = i
+		}
+	}
+	g.SetViewOnTop(USER_AGENT_VIEW)
+	g.SetCurrentView(USER_AGENT_VIEW)
+	selectListLine(v, selected)
+	return nil
+}
+
+// selectUserAgent sets the User-Agent header of the headers view to the
+// selected preset
+func (a *App) selectUserAgent(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(USER_AGENT_PRESETS) {
+		return nil
+	}
+	preset := USER_AGENT_PRESETS[cy+oy]
+	a.closePopup(g, USER_AGENT_VIEW)
+	vh, _ := g.View(REQUEST_HEADERS_VIEW)
+	setViewTextAndCursor(vh, setHeaderLine(getViewValue(g, REQUEST_HEADERS_VIEW), "User-Agent", preset.value))
+	return nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestSetHeaderLine(t *testing.T) {
+	for _, test := range []struct {
+		text, value, expected string
+	}{
+		{"", "curl/8", "User-Agent: curl/8"},
+		{"Accept: */*", "curl/8", "Accept: */*\nUser-Agent: curl/8"},
+		{"user-agent: old\nAccept: */*\nUser-Agent: older", "curl/8", "User-Agent: curl/8\nAccept: */*"},
+		{"Accept: */*\nUser-Agent: old", "", "Accept: */*"},
+		{"Accept: */*\n--- trailers ---\nUser-Agent: x", "curl/8", "Accept: */*\nUser-Agent: curl/8\n--- trailers ---\nUser-Agent: x"},
+	} {
+		if result := setHeaderLine(test.text, "User-Agent", test.value); result != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.text, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jroimartin/gocui"
+)
+
+// WIRE_LOG_LIMIT is the number of lines kept in the wire log
+const WIRE_LOG_LIMIT = 5000
+
+// wireLog records the conversation of the requests with the servers, like
+// the verbose output of curl
+type wireLog struct {
+	mu       sync.Mutex
+	lines    []string
+	onChange func()
+}
+
+var WIRE_LOG = &wireLog{}
+
+// printf adds a timestamped line, prefix is "*" for events, ">" for sent and
+// "<" for received data
+func (l *wireLog) printf(prefix, format string, args ...interface{}) {
+	line := time.Now().Format("15:04:05.000") + " " + prefix + " " + fmt.Sprintf(format, args...)
+	l.mu.Lock()
+	l.lines = append(l.lines, line)
+	if len(l.lines) > WIRE_LOG_LIMIT {
+		l.lines = l.lines[len(l.lines)-WIRE_LOG_LIMIT:]
+	}
+	onChange := l.onChange
+	l.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+func (l *wireLog) text() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func (l *wireLog) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			l.printf("*", "Resolving %v", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err != nil {
+				l.printf("*", "Resolution failed: %v", info.Err)
+				return
+			}
+			addrs := make([]string, len(info.Addrs))
+			for i, addr := range info.Addrs {
+				addrs[i] = addr.String()
+			}
+			l.printf("*", "Resolved to %v", strings.Join(addrs, ", "))
+		},
+		ConnectStart: func(network, addr string) {
+			l.printf("*", "Connecting to %v (%v)", addr, network)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				l.printf("*", "Connection to %v failed: %v", addr, err)
+				return
+			}
+			l.printf("*", "Connected to %v", addr)
+		},
+		TLSHandshakeStart: func() {
+			l.printf("*", "TLS handshake")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil {
+				l.printf("*", "TLS handshake failed: %v", err)
+				return
+			}
+			l.printf("*", "TLS %v, %v, ALPN %q", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite), state.NegotiatedProtocol)
+			if len(state.PeerCertificates) > 0 {
+				cert := state.PeerCertificates[0]
+				l.printf("*", "Server certificate: %v, issued by %v, expires %v", cert.Subject, cert.Issuer, cert.NotAfter.Format(time.DateOnly))
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				l.printf("*", "Reusing connection to %v", info.Conn.RemoteAddr())
+			}
+		},
+		WroteHeaderField: func(key string, value []string) {
+			l.printf(">", "%v: %v", key, strings.Join(value, ", "))
+		},
+		Got100Continue: func() {
+			l.printf("<", "100 Continue")
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err != nil {
+				l.printf("*", "Sending the request failed: %v", info.Err)
+				return
+			}
+			l.printf("*", "Request sent")
+		},
+	}
+}
+
+// wireLogTransport records the requests sent by next and their responses
+// in the wire log
+type wireLogTransport struct {
+	next http.RoundTripper
+}
+
+func (t *wireLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	WIRE_LOG.printf(">", "%v %v", req.Method, req.URL)
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), WIRE_LOG.trace()))
+	response, err := t.next.RoundTrip(req)
+	if err != nil {
+		WIRE_LOG.printf("*", "Error: %v", err)
+		return nil, err
+	}
+	WIRE_LOG.printf("<", "%v %v", response.Proto, response.Status)
+	names := make([]string, 0, len(response.Header))
+	for name := range response.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range response.Header[name] {
+			WIRE_LOG.printf("<", "%v: %v", name, value)
+		}
+	}
+	if location := response.Header.Get("Location"); location != "" && response.StatusCode/100 == 3 {
+		WIRE_LOG.printf("*", "Redirected to %v", location)
+	}
+	return response, nil
+}
+
+// ToggleWireLog shows the conversation of the requests with the servers
+func (a *App) ToggleWireLog(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == WIRE_LOG_VIEW {
+		a.closePopup(g, WIRE_LOG_VIEW)
+		return nil
+	}
+	maxX, maxY := g.Size()
+	v, err := a.CreatePopupView(WIRE_LOG_VIEW, maxX, maxY, g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[WIRE_LOG_VIEW]
+	v.Highlight = false
+	v.Autoscroll = true
+	renderWireLog(v)
+	WIRE_LOG.mu.Lock()
+	WIRE_LOG.onChange = func() {
+		g.Update(func(g *gocui.Gui) error {
+			if v, err := g.View(WIRE_LOG_VIEW); err == nil {
+				renderWireLog(v)
+			}
+			return nil
+		})
+	}
+	WIRE_LOG.mu.Unlock()
+	g.SetViewOnTop(WIRE_LOG_VIEW)
+	g.SetCurrentView(WIRE_LOG_VIEW)
+	return nil
+}
+
+func renderWireLog(v *gocui.View) {
+	v.Clear()
+	text := WIRE_LOG.text()
+	if text == "" {
+		fmt.Fprint(v, "[!] No request sent yet")
+		return
+	}
+	fmt.Fprint(v, text)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWireLogTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Served-By", "test")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	WIRE_LOG.lines = nil
+	client := &http.Client{Transport: &wireLogTransport{http.DefaultTransport}}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+	req.Header.Set("Accept", "application/json")
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	log := WIRE_LOG.text()
+	for _, expected := range []string{
+		"> GET " + server.URL + "/items",
+		"* Connected to " + server.Listener.Addr().String(),
+		"> Accept: application/json",
+		"* Request sent",
+		"< HTTP/1.1 204 No Content",
+		"< X-Served-By: test",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Errorf("%q missing from the wire log:\n%s", expected, log)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// fillRequest sets the fields of r to the content of the views of a saved
+// request
+func fillRequest(r *Request, requestMap map[string]string) {
+	r.Url = requestMap[URL_VIEW]
+	r.Method = requestMap[REQUEST_METHOD_VIEW]
+	r.GetParams = requestMap[URL_PARAMS_VIEW]
+	r.Headers = requestMap[REQUEST_HEADERS_VIEW]
+	r.Data = requestMap[REQUEST_DATA_VIEW]
+	r.Auth = requestMap[AUTH_VIEW]
+	r.Schema = requestMap[SCHEMA_VIEW]
+	r.Notes = requestMap[NOTES_VIEW]
+	r.Transport, _ = parseTransportOptions(requestMap[TRANSPORT_OPTIONS_VIEW])
+}
+
+// missingVariables returns the placeholders of requestMap which are not
+// variables
+func missingVariables(requestMap map[string]string, variables map[string]string) []string {
+	var missing []string
+	for _, name := range findPlaceholders(requestMap) {
+		if _, found := variables[name]; !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+func copyVariables(variables map[string]string) map[string]string {
+	copied := make(map[string]string, len(variables))
+	for name, value := range variables {
+		copied[name] = value
+	}
+	return copied
+}
+
+// runDependency sends the workspace request of requestMap and returns the
+// variables captured from its response
+func (a *App) runDependency(name string, requestMap, capture map[string]string, variables map[string]string, apiKey *config.APIKey, conf *config.Config) (map[string]string, error) {
+	if missing := missingVariables(requestMap, variables); len(missing) > 0 {
+		return nil, fmt.Errorf("%v: unknown variables: %v", name, strings.Join(missing, ", "))
+	}
+	r := &Request{}
+	fillRequest(r, requestMap)
+	req, err := a.newEnvironmentRequest(r, variables, apiKey, conf)
+	if err == nil {
+		err = finalizeRequest(r, req, conf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	response, err := CLIENT.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	LOGGER.Info("dependency sent", "name", name, "method", req.Method, "url", req.URL.String(), "status", response.StatusCode)
+	if response.StatusCode >= 400 {
+		return nil, fmt.Errorf("%v: %v", name, response.Status)
+	}
+
+	captured := make(map[string]string)
+	names := make([]string, 0, len(capture))
+	for variable := range capture {
+		names = append(names, variable)
+	}
+	sort.Strings(names)
+	for _, variable := range names {
+		values, err := evalJSONPath(capture[variable], body)
+		if err != nil {
+			return nil, fmt.Errorf("%v: capture %v: %v", name, variable, err)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%v: capture %v: no value matches %v", name, variable, capture[variable])
+		}
+		captured[variable] = formatJSONValues(values)
+	}
+	return captured, nil
+}
+
+// runWorkspaceRequest sends the requests needed by the selected request one
+// after the other, storing the variables they capture, then sends it
+func (a *App) runWorkspaceRequest(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.workspace.Requests) {
+		return nil
+	}
+	index := cy + oy
+	dependencies, err := a.workspace.Dependencies(index)
+	if err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	a.closePopup(g, WORKSPACE_VIEW)
+	if len(dependencies) == 0 {
+		return a.sendWorkspaceRequest(g, a.workspaceRequestMap(a.workspace.Requests[index]))
+	}
+
+	type dependency struct {
+		name       string
+		requestMap map[string]string
+		capture    map[string]string
+	}
+	var needed []dependency
+	for _, i := range dependencies {
+		r := a.workspace.Requests[i]
+		needed = append(needed, dependency{r.Name, a.workspaceRequestMap(r), r.Capture})
+	}
+	variables, apiKey, conf := copyVariables(a.variables), a.apiKey, *a.config
+	vrb, _ := g.View(RESPONSE_BODY_VIEW)
+	vrb.Title = fmt.Sprintf("%v running %d dependencies…", VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title, len(needed))
+
+	go func() {
+		captured := make(map[string]string)
+		for _, d := range needed {
+			values, err := a.runDependency(d.name, d.requestMap, d.capture, variables, apiKey, &conf)
+			if err != nil {
+				LOGGER.Error("dependency failed", "error", err)
+				g.Update(func(g *gocui.Gui) error {
+					vrb, _ := g.View(RESPONSE_BODY_VIEW)
+					vrb.Title = VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title
+					return a.OpenMessageView("Dependency failed: "+err.Error(), g)
+				})
+				return
+			}
+			for name, value := range values {
+				variables[name] = value
+				captured[name] = value
+			}
+		}
+		g.Update(func(g *gocui.Gui) error {
+			vrb, _ := g.View(RESPONSE_BODY_VIEW)
+			vrb.Title = VIEW_PROPERTIES[RESPONSE_BODY_VIEW].title
+			if a.variables == nil {
+				a.variables = make(map[string]string)
+			}
+			for name, value := range captured {
+				a.variables[name] = value
+			}
+			return a.sendWorkspaceRequest(g, a.workspaceRequestMap(a.workspace.Requests[index]))
+		})
+	}()
+	return nil
+}
+
+// sendWorkspaceRequest fills the views with requestMap and sends it, the
+// placeholders which are not variables are prompted for first
+func (a *App) sendWorkspaceRequest(g *gocui.Gui, requestMap map[string]string) error {
+	send := func(g *gocui.Gui) error {
+		a.setRequestViews(g, requestMap)
+		variables, apiKey := copyVariables(a.variables), a.apiKey
+		return a.sendRequest(g, func(r *Request, conf *config.Config) (*http.Request, error) {
+			fillRequest(r, requestMap)
+			return a.newEnvironmentRequest(r, variables, apiKey, conf)
+		})
+	}
+	if placeholders := missingVariables(requestMap, a.variables); len(placeholders) > 0 {
+		return a.OpenTemplateForm(g, placeholders, func(g *gocui.Gui, values map[string]string) {
+			fillPlaceholders(requestMap, values)
+			g.Update(send)
+		})
+	}
+	return send(g)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestWorkspaceDependencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.toml")
+	err := os.WriteFile(path, []byte(`
+[[requests]]
+name = "Order"
+url = "{{base}}/orders"
+needs = ["Login", "Cart"]
+
+[[requests]]
+name = "Login"
+method = "POST"
+url = "{{base}}/login"
+[requests.capture]
+token = "$.token"
+
+[[requests]]
+name = "Cart"
+url = "{{base}}/cart"
+headers = "Authorization: Bearer {{token}}"
+needs = ["Login"]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.DefaultConfig
+	workspace, err := config.LoadWorkspace(path, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := workspace.Dependencies(0)
+	if err != nil || len(order) != 2 || order[0] != 1 || order[1] != 2 {
+		t.Errorf("unexpected dependencies %v %v", order, err)
+	}
+
+	for _, workspace := range []string{
+		"[[requests]]\nname = \"a\"\nurl = \"/a\"\nneeds = [\"b\"]\n[[requests]]\nname = \"b\"\nurl = \"/b\"\nneeds = [\"a\"]\n",
+		"[[requests]]\nname = \"a\"\nurl = \"/a\"\nneeds = [\"missing\"]\n",
+	} {
+		if err := os.WriteFile(path, []byte(workspace), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := config.LoadWorkspace(path, &conf); err == nil {
+			t.Errorf("expected an error for %q", workspace)
+		}
+	}
+}
+
+func TestRunDependency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/login" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(`{"token": "s3cret", "user": {"id": 7}}`))
+	}))
+	defer server.Close()
+
+	a := &App{config: &config.Config{}}
+	requestMap := map[string]string{URL_VIEW: "{{base}}/login", REQUEST_METHOD_VIEW: "POST"}
+	variables := map[string]string{"base": server.URL}
+	captured, err := a.runDependency("Login", requestMap, map[string]string{"token": "$.token", "id": "$.user.id"}, variables, nil, a.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if captured["token"] != "s3cret" || captured["id"] != "7" {
+		t.Errorf("unexpected captured variables %v", captured)
+	}
+
+	requestMap[URL_VIEW] = "{{base}}/missing"
+	if _, err := a.runDependency("Missing", requestMap, nil, variables, nil, a.config); err == nil || err.Error() != "Missing: 404 Not Found" {
+		t.Errorf("expected a status error, got %v", err)
+	}
+	if _, err := a.runDependency("Login", requestMap, nil, map[string]string{}, nil, a.config); err == nil {
+		t.Errorf("expected an error for an unknown variable")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hitstill/buzz/config"
+	"github.com/jroimartin/gocui"
+)
+
+// parseWorkspaceCommand extracts the workspace file and the environment of
+// "buzz open FILE [--env NAME]"
+func parseWorkspaceCommand(args []string) (path, environment string, rest []string, err error) {
+	if len(args) < 2 || args[1] != "open" {
+		return "", "", args, nil
+	}
+	if len(args) < 3 {
+		return "", "", args, fmt.Errorf("missing workspace file")
+	}
+	path = args[2]
+	rest = []string{args[0]}
+	for i := 3; i < len(args); i++ {
+		if args[i] == "--env" {
+			if i+1 == len(args) {
+				return "", "", args, fmt.Errorf("missing environment name")
+			}
+			environment = args[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return path, environment, rest, nil
+}
+
+// openWorkspace loads the workspace file, its settings and the variables of
+// the environment
+func (a *App) openWorkspace(path, environment string) error {
+	workspace, err := config.LoadWorkspace(path, a.config)
+	if err != nil {
+		return fmt.Errorf("workspace %v: %v", path, err)
+	}
+	if a.statusLine, err = NewStatusLine(a.config.General.StatusLine); err != nil {
+		return err
+	}
+	a.workspace = workspace
+	a.workspacePath = path
+	a.groupBaseURLs = make(map[string]string)
+	return a.setEnvironment(environment)
+}
+
+func (a *App) setEnvironment(environment string) error {
+	variables, err := a.workspace.EnvironmentVariables(environment)
+	if err != nil {
+		return err
+	}
+	a.environment = environment
+	a.variables = variables
+	a.apiKey = a.workspace.EnvironmentAPIKey(environment)
+	return nil
+}
+
+// environments returns the environment names of the workspace, preceded by
+// the empty name using only the workspace variables
+func (a *App) environments() []string {
+	names := []string{""}
+	for name := range a.workspace.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+func (a *App) workspaceTitle() string {
+	environment := a.environment
+	if environment == "" {
+		environment = "no environment"
+	}
+	return fmt.Sprintf("%v [%v] %v", filepath.Base(a.workspacePath), environment, VIEW_TITLES[WORKSPACE_VIEW])
+}
+
+// ToggleWorkspace lists the requests of the workspace
+func (a *App) ToggleWorkspace(g *gocui.Gui, _ *gocui.View) error {
+	if a.currentPopup == WORKSPACE_VIEW {
+		a.closePopup(g, WORKSPACE_VIEW)
+		return nil
+	}
+	if a.workspace == nil {
+		return a.OpenMessageView("No workspace, run buzz open FILE", g)
+	}
+	v, err := a.CreatePopupView(WORKSPACE_VIEW, 100, len(a.workspace.Requests), g)
+	if err != nil {
+		return err
+	}
+	v.Title = a.workspaceTitle()
+	a.renderWorkspace(v)
+	g.SetViewOnTop(WORKSPACE_VIEW)
+	g.SetCurrentView(WORKSPACE_VIEW)
+	selectListLine(v, 0)
+	return nil
+}
+
+// renderWorkspace writes the requests of the workspace to v, with the base
+// URL of their group
+func (a *App) renderWorkspace(v *gocui.View) {
+	v.Clear()
+	for _, r := range a.workspace.Requests {
+		name := r.Name
+		if name == "" {
+			name = r.URL
+		}
+		if r.Group != "" {
+			name = fmt.Sprintf("%s [%s: %s]", name, r.Group, a.groupBaseURL(r.Group))
+		}
+		fmt.Fprintf(v, "%-7s %s\n", workspaceMethod(r), name)
+	}
+}
+
+// WORKSPACE_GROUP_KEY holds the group of a workspace request in the maps of
+// the request views, the copies of the request keep it
+const WORKSPACE_GROUP_KEY = "group"
+
+// groupBaseURL returns the base URL the group is switched to, its baseURL
+// by default
+func (a *App) groupBaseURL(group string) string {
+	if base, found := a.groupBaseURLs[group]; found {
+		return base
+	}
+	return a.workspace.Groups[group].BaseURL
+}
+
+// joinBaseURL appends the path to base, absolute URLs and URLs starting
+// with a placeholder are returned as they are
+func joinBaseURL(base, path string) string {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "{{") {
+		return path
+	}
+	if path == "" {
+		return base
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// nextGroupBaseURL switches the group of the selected request to its next
+// base URL, every request of the group is sent to it
+func (a *App) nextGroupBaseURL(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.workspace.Requests) {
+		return nil
+	}
+	name := a.workspace.Requests[cy+oy].Group
+	if name == "" {
+		return nil
+	}
+	group := a.workspace.Groups[name]
+	bases := append([]string{group.BaseURL}, group.BaseURLs...)
+	current := a.groupBaseURL(name)
+	next := 0
+	for i, base := range bases {
+		if base == current {
+			next = (i + 1) % len(bases)
+		}
+	}
+	a.groupBaseURLs[name] = bases[next]
+	a.renderWorkspace(v)
+	return nil
+}
+
+func workspaceMethod(r config.WorkspaceRequest) string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+	return r.Method
+}
+
+// workspaceRequestMap returns the content of the views of the workspace
+// request r, its URL prefixed with the base URL of its group
+func (a *App) workspaceRequestMap(r config.WorkspaceRequest) map[string]string {
+	url := r.URL
+	if r.Group != "" {
+		url = joinBaseURL(a.groupBaseURL(r.Group), url)
+	}
+	requestMap := map[string]string{
+		URL_VIEW:             url,
+		REQUEST_METHOD_VIEW:  workspaceMethod(r),
+		URL_PARAMS_VIEW:      r.Params,
+		REQUEST_HEADERS_VIEW: r.Headers,
+		REQUEST_DATA_VIEW:    r.Body,
+	}
+	if r.Auth != "" {
+		requestMap[AUTH_VIEW] = r.Auth
+	}
+	if r.Schema != "" {
+		requestMap[SCHEMA_VIEW] = r.Schema
+	}
+	if r.Notes != "" {
+		requestMap[NOTES_VIEW] = r.Notes
+	}
+	if r.Transport != "" {
+		requestMap[TRANSPORT_OPTIONS_VIEW] = r.Transport
+	}
+	if r.Group != "" {
+		requestMap[WORKSPACE_GROUP_KEY] = r.Group
+	}
+	return requestMap
+}
+
+// loadWorkspaceRequest fills the views with the selected request, the
+// placeholders which are not variables are prompted for
+func (a *App) loadWorkspaceRequest(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.workspace.Requests) {
+		return nil
+	}
+	a.closePopup(g, WORKSPACE_VIEW)
+	requestMap := a.workspaceRequestMap(a.workspace.Requests[cy+oy])
+	if placeholders := missingVariables(requestMap, a.variables); len(placeholders) > 0 {
+		return a.OpenTemplateForm(g, placeholders, func(g *gocui.Gui, values map[string]string) {
+			fillPlaceholders(requestMap, values)
+			a.setRequestViews(g, requestMap)
+		})
+	}
+	a.setRequestViews(g, requestMap)
+	return nil
+}
+
+// nextEnvironment switches to the next environment of the workspace
+func (a *App) nextEnvironment(g *gocui.Gui, v *gocui.View) error {
+	names := a.environments()
+	next := 0
+	for i, name := range names {
+		if name == a.environment {
+			next = (i + 1) % len(names)
+		}
+	}
+	if err := a.setEnvironment(names[next]); err != nil {
+		return a.OpenMessageView("Error: "+err.Error(), g)
+	}
+	v.Title = a.workspaceTitle()
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hitstill/buzz/config"
+)
+
+func TestParseWorkspaceCommand(t *testing.T) {
+	path, environment, rest, err := parseWorkspaceCommand([]string{"buzz", "open", "api.toml", "--env", "staging", "-k"})
+	if err != nil || path != "api.toml" || environment != "staging" || len(rest) != 2 || rest[1] != "-k" {
+		t.Errorf("unexpected result %q %q %q %v", path, environment, rest, err)
+	}
+	if path, _, rest, _ := parseWorkspaceCommand([]string{"buzz", "https://example.com"}); path != "" || len(rest) != 2 {
+		t.Errorf("unexpected workspace %q", path)
+	}
+	if _, _, _, err := parseWorkspaceCommand([]string{"buzz", "open"}); err == nil {
+		t.Error("expected an error without a file")
+	}
+}
+
+func TestOpenWorkspace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.toml")
+	err := os.WriteFile(path, []byte(`
+[general]
+timeout = "5s"
+
+[variables]
+base = "https://api.example.com"
+token = "t0ken"
+
+[environments.local]
+base = "http://localhost:8080"
+
+[apiKey]
+name = "X-Api-Key"
+value = "{{token}}"
+
+[apiKeys.local]
+in = "query"
+name = "key"
+value = "local-key"
+
+[[requests]]
+name = "List users"
+url = "{{base}}/users"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.DefaultConfig
+	a := &App{config: &conf}
+	if err := a.openWorkspace(path, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if a.config.General.Timeout.Duration != 5*time.Second {
+		t.Errorf("the settings were not applied: %v", a.config.General.Timeout)
+	}
+	if a.config.General.FormatJSON != config.DefaultConfig.General.FormatJSON {
+		t.Error("the settings missing from the workspace were changed")
+	}
+	if len(a.workspace.Requests) != 1 || expandVariables(a.workspace.Requests[0].URL, a.variables) != "http://localhost:8080/users" {
+		t.Errorf("unexpected requests %+v with %v", a.workspace.Requests, a.variables)
+	}
+	if a.apiKey == nil || a.apiKey.In != "query" || a.apiKey.Value != "local-key" {
+		t.Errorf("unexpected API key of the environment %+v", a.apiKey)
+	}
+	if a.variables["token"] != "t0ken" {
+		t.Errorf("the workspace variables are not inherited: %v", a.variables)
+	}
+	if err := a.setEnvironment("production"); err == nil {
+		t.Error("expected an error for an unknown environment")
+	}
+	if names := a.environments(); len(names) != 2 || names[1] != "local" {
+		t.Errorf("unexpected environments %q", names)
+	}
+}
+
+func TestWorkspaceGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspace.toml")
+	err := os.WriteFile(path, []byte(`
+[groups.users]
+baseURL = "https://api.example.com/v1/"
+baseURLs = ["http://localhost:8080"]
+
+[[requests]]
+name = "List users"
+group = "users"
+url = "/users"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := config.DefaultConfig
+	a := &App{config: &conf}
+	if err := a.openWorkspace(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	r := a.workspace.Requests[0]
+	if url := joinBaseURL(a.groupBaseURL(r.Group), r.URL); url != "https://api.example.com/v1/users" {
+		t.Errorf("unexpected URL %q", url)
+	}
+	a.groupBaseURLs["users"] = "http://localhost:8080"
+	if url := joinBaseURL(a.groupBaseURL(r.Group), r.URL); url != "http://localhost:8080/users" {
+		t.Errorf("unexpected URL %q after switching the base URL", url)
+	}
+	for path, expected := range map[string]string{
+		"":                    "http://localhost",
+		"users?page=2":        "http://localhost/users?page=2",
+		"https://other.com/x": "https://other.com/x",
+		"{{base}}/users":      "{{base}}/users",
+	} {
+		if url := joinBaseURL("http://localhost", path); url != expected {
+			t.Errorf("joinBaseURL(%q) = %q, expected %q", path, url, expected)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("[[requests]]\nurl = \"/users\"\ngroup = \"missing\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.openWorkspace(path, ""); err == nil {
+		t.Error("expected an error for an unknown group")
+	}
+}
+
+func TestAddAPIKey(t *testing.T) {
+	variables := map[string]string{"token": "t0ken"}
+	for _, tc := range []struct {
+		key                   config.APIKey
+		url, expected, cookie string
+	}{
+		{config.APIKey{Name: "X-Api-Key", Value: "{{token}}"}, "https://example.com/", "https://example.com/", ""},
+		{config.APIKey{In: "query", Name: "key", Value: "{{token}}"}, "https://example.com/?a=1", "https://example.com/?a=1&key=t0ken", ""},
+		{config.APIKey{In: "query", Name: "key", Value: "{{token}}"}, "https://example.com/?key=mine", "https://example.com/?key=mine", ""},
+		{config.APIKey{In: "cookie", Name: "session", Value: "{{token}}"}, "https://example.com/", "https://example.com/", "session=t0ken"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err := addAPIKey(req, &tc.key, variables); err != nil {
+			t.Fatal(err)
+		}
+		if req.URL.String() != tc.expected || req.Header.Get("Cookie") != tc.cookie {
+			t.Errorf("unexpected request %v with cookies %q for %+v", req.URL, req.Header.Get("Cookie"), tc.key)
+		}
+		if tc.key.In == "" && req.Header.Get(tc.key.Name) != "t0ken" {
+			t.Errorf("API key header not set: %v", req.Header)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jroimartin/gocui"
+)
+
+// namespaces of the SOAP 1.1 and 1.2 bindings of WSDL 1.1 and of their
+// envelopes
+const (
+	WSDL_SOAP11_NAMESPACE     = "http://schemas.xmlsoap.org/wsdl/soap/"
+	WSDL_SOAP12_NAMESPACE     = "http://schemas.xmlsoap.org/wsdl/soap12/"
+	SOAP11_ENVELOPE_NAMESPACE = "http://schemas.xmlsoap.org/soap/envelope/"
+	SOAP12_ENVELOPE_NAMESPACE = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// WSDL_SCHEMA_DEPTH limits the nesting of the elements of the generated
+// envelopes, recursive types would never end
+const WSDL_SCHEMA_DEPTH = 8
+
+type wsdlDefinitions struct {
+	TargetNamespace string         `xml:"targetNamespace,attr"`
+	Schemas         []xsdSchema    `xml:"types>schema"`
+	Messages        []wsdlMessage  `xml:"message"`
+	PortTypes       []wsdlPortType `xml:"portType"`
+	Bindings        []wsdlBinding  `xml:"binding"`
+	Services        []struct {
+		Ports []struct {
+			Binding string `xml:"binding,attr"`
+			Address []struct {
+				XMLName  xml.Name
+				Location string `xml:"location,attr"`
+			} `xml:"address"`
+		} `xml:"port"`
+	} `xml:"service"`
+}
+
+type wsdlMessage struct {
+	Name  string `xml:"name,attr"`
+	Parts []struct {
+		Name    string `xml:"name,attr"`
+		Element string `xml:"element,attr"`
+		Type    string `xml:"type,attr"`
+	} `xml:"part"`
+}
+
+type wsdlPortType struct {
+	Name       string `xml:"name,attr"`
+	Operations []struct {
+		Name  string `xml:"name,attr"`
+		Input struct {
+			Message string `xml:"message,attr"`
+		} `xml:"input"`
+	} `xml:"operation"`
+}
+
+type wsdlBinding struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+	SOAP struct {
+		XMLName xml.Name
+		Style   string `xml:"style,attr"`
+	} `xml:"binding"`
+	Operations []struct {
+		Name string `xml:"name,attr"`
+		SOAP struct {
+			Action string `xml:"soapAction,attr"`
+			Style  string `xml:"style,attr"`
+		} `xml:"operation"`
+		Input struct {
+			Body struct {
+				Namespace string `xml:"namespace,attr"`
+			} `xml:"body"`
+		} `xml:"input"`
+	} `xml:"operation"`
+}
+
+type xsdSchema struct {
+	TargetNamespace    string           `xml:"targetNamespace,attr"`
+	ElementFormDefault string           `xml:"elementFormDefault,attr"`
+	Elements           []xsdElement     `xml:"element"`
+	ComplexTypes       []xsdComplexType `xml:"complexType"`
+}
+
+type xsdElement struct {
+	Name        string          `xml:"name,attr"`
+	Type        string          `xml:"type,attr"`
+	Ref         string          `xml:"ref,attr"`
+	ComplexType *xsdComplexType `xml:"complexType"`
+}
+
+type xsdComplexType struct {
+	Name     string       `xml:"name,attr"`
+	Sequence []xsdElement `xml:"sequence>element"`
+	All      []xsdElement `xml:"all>element"`
+	Choice   []xsdElement `xml:"choice>element"`
+}
+
+func (t *xsdComplexType) elements() []xsdElement {
+	return append(append(append([]xsdElement{}, t.Sequence...), t.All...), t.Choice...)
+}
+
+// soapOperation is an operation of a SOAP binding of a WSDL
+type soapOperation struct {
+	Name     string
+	Binding  string
+	SOAP12   bool
+	Address  string
+	Action   string
+	Envelope string
+}
+
+// localName returns the name without its namespace prefix
+func localName(name string) string {
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// parseWSDL returns the operations of the SOAP bindings of a WSDL 1.1
+// document
+func parseWSDL(data []byte) ([]soapOperation, error) {
+	var definitions wsdlDefinitions
+	if err := xml.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid WSDL: %v", err)
+	}
+	var operations []soapOperation
+	for _, binding := range definitions.Bindings {
+		soap12 := binding.SOAP.XMLName.Space == WSDL_SOAP12_NAMESPACE
+		if !soap12 && binding.SOAP.XMLName.Space != WSDL_SOAP11_NAMESPACE {
+			// HTTP bindings
+			continue
+		}
+		address := definitions.address(binding.Name, binding.SOAP.XMLName.Space)
+		for _, op := range binding.Operations {
+			style := op.SOAP.Style
+			if style == "" {
+				style = binding.SOAP.Style
+			}
+			namespace := op.Input.Body.Namespace
+			if namespace == "" {
+				namespace = definitions.TargetNamespace
+			}
+			body := definitions.bodyElements(binding.Type, op.Name, style == "rpc", namespace)
+			operations = append(operations, soapOperation{
+				Name:     op.Name,
+				Binding:  binding.Name,
+				SOAP12:   soap12,
+				Address:  address,
+				Action:   op.SOAP.Action,
+				Envelope: soapEnvelope(soap12, body),
+			})
+		}
+	}
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("the WSDL has no SOAP operation")
+	}
+	return operations, nil
+}
+
+// address returns the location of the port of the binding
+func (d *wsdlDefinitions) address(binding, namespace string) string {
+	for _, service := range d.Services {
+		for _, port := range service.Ports {
+			if localName(port.Binding) != binding {
+				continue
+			}
+			for _, address := range port.Address {
+				if address.XMLName.Space == namespace {
+					return address.Location
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// inputMessage returns the input message of the operation of the port type
+func (d *wsdlDefinitions) inputMessage(portType, operation string) *wsdlMessage {
+	for _, pt := range d.PortTypes {
+		if pt.Name != localName(portType) {
+			continue
+		}
+		for _, op := range pt.Operations {
+			if op.Name != operation {
+				continue
+			}
+			for i, message := range d.Messages {
+				if message.Name == localName(op.Input.Message) {
+					return &d.Messages[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// bodyElements returns the skeleton of the content of the SOAP body of the
+// operation: the elements of the parts of the input message, wrapped in an
+// element named after the operation with the rpc style
+func (d *wsdlDefinitions) bodyElements(portType, operation string, rpc bool, namespace string) string {
+	message := d.inputMessage(portType, operation)
+	sb := &strings.Builder{}
+	depth := 2
+	if rpc {
+		fmt.Fprintf(sb, "    <m:%s xmlns:m=\"%s\">\n", operation, namespace)
+		depth++
+	}
+	if message != nil {
+		for _, part := range message.Parts {
+			if part.Element != "" {
+				d.writeElement(sb, localName(part.Element), depth)
+			} else {
+				fmt.Fprintf(sb, "%s<%s>?</%s>\n", strings.Repeat("  ", depth), part.Name, part.Name)
+			}
+		}
+	}
+	if rpc {
+		fmt.Fprintf(sb, "    </m:%s>\n", operation)
+	}
+	return sb.String()
+}
+
+// writeElement writes the skeleton of the global element name of the
+// schemas, with the namespace of its schema
+func (d *wsdlDefinitions) writeElement(w io.Writer, name string, depth int) {
+	for _, schema := range d.Schemas {
+		for _, element := range schema.Elements {
+			if element.Name == name {
+				fmt.Fprintf(w, "%s<m:%s xmlns:m=\"%s\">", strings.Repeat("  ", depth), name, schema.TargetNamespace)
+				d.writeContent(w, schema, element, depth, "m:")
+				fmt.Fprintf(w, "</m:%s>\n", name)
+				return
+			}
+		}
+	}
+	fmt.Fprintf(w, "%s<%s>?</%s>\n", strings.Repeat("  ", depth), name, name)
+}
+
+// writeContent writes the child elements of element, or "?" for simple
+// types
+func (d *wsdlDefinitions) writeContent(w io.Writer, schema xsdSchema, element xsdElement, depth int, prefix string) {
+	complexType := element.ComplexType
+	if complexType == nil && element.Type != "" {
+		complexType = schema.complexType(localName(element.Type))
+	}
+	if complexType == nil || depth >= WSDL_SCHEMA_DEPTH {
+		fmt.Fprint(w, "?")
+		return
+	}
+	children := complexType.elements()
+	if len(children) == 0 {
+		return
+	}
+	childPrefix := ""
+	if schema.ElementFormDefault == "qualified" {
+		childPrefix = prefix
+	}
+	fmt.Fprintln(w)
+	for _, child := range children {
+		name := child.Name
+		if child.Ref != "" {
+			name = localName(child.Ref)
+		}
+		fmt.Fprintf(w, "%s<%s%s>", strings.Repeat("  ", depth+1), childPrefix, name)
+		d.writeContent(w, schema, child, depth+1, prefix)
+		fmt.Fprintf(w, "</%s%s>\n", childPrefix, name)
+	}
+	fmt.Fprint(w, strings.Repeat("  ", depth))
+}
+
+// complexType returns the named complex type of the schema
+func (s xsdSchema) complexType(name string) *xsdComplexType {
+	for i, t := range s.ComplexTypes {
+		if t.Name == name {
+			return &s.ComplexTypes[i]
+		}
+	}
+	return nil
+}
+
+// soapEnvelope returns the SOAP envelope of the body content
+func soapEnvelope(soap12 bool, body string) string {
+	namespace := SOAP11_ENVELOPE_NAMESPACE
+	if soap12 {
+		namespace = SOAP12_ENVELOPE_NAMESPACE
+	}
+	return fmt.Sprintf("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n"+
+		"<soap:Envelope xmlns:soap=\"%s\">\n"+
+		"  <soap:Header/>\n"+
+		"  <soap:Body>\n%s  </soap:Body>\n"+
+		"</soap:Envelope>", namespace, body)
+}
+
+// headers returns the Content-Type and SOAPAction headers of the operation
+func (o soapOperation) headers() []string {
+	if o.SOAP12 {
+		contentType := "Content-Type: application/soap+xml; charset=utf-8"
+		if o.Action != "" {
+			contentType += fmt.Sprintf("; action=%q", o.Action)
+		}
+		return []string{contentType}
+	}
+	return []string{"Content-Type: text/xml; charset=utf-8", fmt.Sprintf("SOAPAction: %q", o.Action)}
+}
+
+// label returns the line of the operation in the list of operations
+func (o soapOperation) label() string {
+	version := "SOAP 1.1"
+	if o.SOAP12 {
+		version = "SOAP 1.2"
+	}
+	return fmt.Sprintf("%-30s %s %s", o.Name, version, o.Binding)
+}
+
+// readWSDL returns the WSDL of the file or URL location
+func readWSDL(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+	response, err := CLIENT.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v: %v", location, response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
+
+// OpenWSDLDialog asks for the file or URL of the WSDL whose operations are
+// listed
+func (a *App) OpenWSDLDialog(g *gocui.Gui, _ *gocui.View) error {
+	dialog, err := a.CreatePopupView(WSDL_DIALOG_VIEW, 80, 1, g)
+	if err != nil {
+		return err
+	}
+	g.Cursor = true
+	dialog.Title = VIEW_TITLES[WSDL_DIALOG_VIEW]
+	dialog.Editable = true
+	dialog.Wrap = false
+	dialog.Editor = &singleLineEditor{&defaultEditor}
+	location := a.wsdlLocation
+	if location == "" {
+		if u := getViewValue(g, URL_VIEW); u != "" {
+			location = u + "?wsdl"
+		}
+	}
+	setViewTextAndCursor(dialog, location)
+	g.SetViewOnTop(WSDL_DIALOG_VIEW)
+	g.SetCurrentView(WSDL_DIALOG_VIEW)
+	return nil
+}
+
+// submitWSDL loads the WSDL in the background and lists its operations
+func (a *App) submitWSDL(g *gocui.Gui, _ *gocui.View) error {
+	location := strings.TrimSpace(getViewValue(g, WSDL_DIALOG_VIEW))
+	a.closePopup(g, WSDL_DIALOG_VIEW)
+	if location == "" {
+		return nil
+	}
+	a.wsdlLocation = location
+	go func() {
+		data, err := readWSDL(location)
+		var operations []soapOperation
+		if err == nil {
+			operations, err = parseWSDL(data)
+		}
+		g.Update(func(g *gocui.Gui) error {
+			if err != nil {
+				return a.OpenMessageView("WSDL error: "+err.Error(), g)
+			}
+			a.wsdlOperations = operations
+			return a.openWSDLOperations(g)
+		})
+	}()
+	return nil
+}
+
+func (a *App) openWSDLOperations(g *gocui.Gui) error {
+	v, err := a.CreatePopupView(WSDL_OPERATIONS_VIEW, 80, len(a.wsdlOperations), g)
+	if err != nil {
+		return err
+	}
+	v.Title = VIEW_TITLES[WSDL_OPERATIONS_VIEW]
+	for _, op := range a.wsdlOperations {
+		fmt.Fprintln(v, op.label())
+	}
+	g.SetViewOnTop(WSDL_OPERATIONS_VIEW)
+	g.SetCurrentView(WSDL_OPERATIONS_VIEW)
+	selectListLine(v, 0)
+	return nil
+}
+
+// selectWSDLOperation fills the request views with the selected operation:
+// its address, the SOAP headers and the envelope skeleton
+func (a *App) selectWSDLOperation(g *gocui.Gui, v *gocui.View) error {
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	if cy+oy >= len(a.wsdlOperations) {
+		return nil
+	}
+	op := a.wsdlOperations[cy+oy]
+	a.closePopup(g, WSDL_OPERATIONS_VIEW)
+	headers := getViewValue(g, REQUEST_HEADERS_VIEW)
+	if op.SOAP12 {
+		// the action is a parameter of the content type
+		headers = setHeaderLine(headers, "SOAPAction", "")
+	}
+	for _, line := range op.headers() {
+		name, value, _ := strings.Cut(line, ": ")
+		headers = setHeaderLine(headers, name, value)
+	}
+	requestMap := map[string]string{
+		REQUEST_METHOD_VIEW:    http.MethodPost,
+		REQUEST_HEADERS_VIEW:   headers,
+		REQUEST_DATA_VIEW:      op.Envelope,
+		AUTH_VIEW:              getViewValue(g, AUTH_VIEW),
+		SCHEMA_VIEW:            a.schema,
+		NOTES_VIEW:             a.notes,
+		TRANSPORT_OPTIONS_VIEW: a.transport.String(),
+	}
+	if op.Address != "" {
+		requestMap[URL_VIEW] = op.Address
+	}
+	a.setRequestViews(g, requestMap)
+	return a.setViewByName(g, REQUEST_DATA_VIEW)
+}
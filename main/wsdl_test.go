@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWSDL = `<?xml version="1.0"?>
+<definitions xmlns="http://schemas.xmlsoap.org/wsdl/"
+    xmlns:soap="http://schemas.xmlsoap.org/wsdl/soap/"
+    xmlns:soap12="http://schemas.xmlsoap.org/wsdl/soap12/"
+    xmlns:http="http://schemas.xmlsoap.org/wsdl/http/"
+    xmlns:xsd="http://www.w3.org/2001/XMLSchema"
+    xmlns:tns="http://example.com/stock"
+    targetNamespace="http://example.com/stock">
+  <types>
+    <xsd:schema targetNamespace="http://example.com/stock" elementFormDefault="qualified">
+      <xsd:element name="GetPrice">
+        <xsd:complexType>
+          <xsd:sequence>
+            <xsd:element name="symbol" type="xsd:string"/>
+            <xsd:element name="period" type="tns:Period"/>
+          </xsd:sequence>
+        </xsd:complexType>
+      </xsd:element>
+      <xsd:complexType name="Period">
+        <xsd:all>
+          <xsd:element name="from" type="xsd:date"/>
+          <xsd:element name="to" type="xsd:date"/>
+        </xsd:all>
+      </xsd:complexType>
+    </xsd:schema>
+  </types>
+  <message name="GetPriceInput">
+    <part name="parameters" element="tns:GetPrice"/>
+  </message>
+  <message name="AddInput">
+    <part name="a" type="xsd:int"/>
+    <part name="b" type="xsd:int"/>
+  </message>
+  <portType name="StockPortType">
+    <operation name="GetPrice"><input message="tns:GetPriceInput"/></operation>
+    <operation name="Add"><input message="tns:AddInput"/></operation>
+  </portType>
+  <binding name="StockSoap" type="tns:StockPortType">
+    <soap:binding style="document" transport="http://schemas.xmlsoap.org/soap/http"/>
+    <operation name="GetPrice">
+      <soap:operation soapAction="http://example.com/GetPrice"/>
+      <input><soap:body use="literal"/></input>
+    </operation>
+    <operation name="Add">
+      <soap:operation soapAction="http://example.com/Add" style="rpc"/>
+      <input><soap:body use="literal" namespace="urn:calc"/></input>
+    </operation>
+  </binding>
+  <binding name="StockSoap12" type="tns:StockPortType">
+    <soap12:binding style="document" transport="http://schemas.xmlsoap.org/soap/http"/>
+    <operation name="GetPrice">
+      <soap12:operation soapAction="http://example.com/GetPrice"/>
+      <input><soap12:body use="literal"/></input>
+    </operation>
+  </binding>
+  <binding name="StockHttp" type="tns:StockPortType">
+    <http:binding verb="GET"/>
+  </binding>
+  <service name="StockService">
+    <port name="StockSoap" binding="tns:StockSoap"><soap:address location="http://example.com/soap"/></port>
+    <port name="StockSoap12" binding="tns:StockSoap12"><soap12:address location="http://example.com/soap12"/></port>
+  </service>
+</definitions>`
+
+func TestParseWSDL(t *testing.T) {
+	operations, err := parseWSDL([]byte(testWSDL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(operations) != 3 {
+		t.Fatalf("expected 3 operations, got %+v", operations)
+	}
+
+	getPrice := operations[0]
+	if getPrice.Name != "GetPrice" || getPrice.SOAP12 || getPrice.Address != "http://example.com/soap" || getPrice.Action != "http://example.com/GetPrice" {
+		t.Errorf("unexpected operation %+v", getPrice)
+	}
+	expected := `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Header/>
+  <soap:Body>
+    <m:GetPrice xmlns:m="http://example.com/stock">
+      <m:symbol>?</m:symbol>
+      <m:period>
+        <m:from>?</m:from>
+        <m:to>?</m:to>
+      </m:period>
+    </m:GetPrice>
+  </soap:Body>
+</soap:Envelope>`
+	if getPrice.Envelope != expected {
+		t.Errorf("expected envelope\n%v\ngot\n%v", expected, getPrice.Envelope)
+	}
+	if headers := getPrice.headers(); !reflect.DeepEqual(headers, []string{"Content-Type: text/xml; charset=utf-8", `SOAPAction: "http://example.com/GetPrice"`}) {
+		t.Errorf("unexpected headers %q", headers)
+	}
+
+	add := operations[1]
+	if !strings.Contains(add.Envelope, "    <m:Add xmlns:m=\"urn:calc\">\n      <a>?</a>\n      <b>?</b>\n    </m:Add>\n") {
+		t.Errorf("unexpected rpc envelope\n%v", add.Envelope)
+	}
+
+	soap12 := operations[2]
+	if !soap12.SOAP12 || soap12.Address != "http://example.com/soap12" || !strings.Contains(soap12.Envelope, SOAP12_ENVELOPE_NAMESPACE) {
+		t.Errorf("unexpected SOAP 1.2 operation %+v", soap12)
+	}
+	if headers := soap12.headers(); !reflect.DeepEqual(headers, []string{`Content-Type: application/soap+xml; charset=utf-8; action="http://example.com/GetPrice"`}) {
+		t.Errorf("unexpected headers %q", headers)
+	}
+
+	if _, err := parseWSDL([]byte("<definitions/>")); err == nil {
+		t.Error("expected an error for a WSDL without operations")
+	}
+}